@@ -0,0 +1,58 @@
+// Package git holds helpers that mirror git's own rules without needing a server round trip.
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateRefName checks a branch or tag name against the rules of git check-ref-format,
+// so invalid names are rejected before any command is sent to the server.
+func ValidateRefName(name string) error {
+	if name == "" {
+		return fmt.Errorf("ref name is empty")
+	}
+	if name == "@" {
+		return fmt.Errorf("ref name cannot be the single character '@'")
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("ref name %q cannot start with '-'", name)
+	}
+	if strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") {
+		return fmt.Errorf("ref name %q cannot start or end with '/'", name)
+	}
+	if strings.HasSuffix(name, ".") {
+		return fmt.Errorf("ref name %q cannot end with '.'", name)
+	}
+	if strings.Contains(name, "..") {
+		return fmt.Errorf("ref name %q cannot contain '..'", name)
+	}
+	if strings.Contains(name, "@{") {
+		return fmt.Errorf("ref name %q cannot contain '@{'", name)
+	}
+	if strings.Contains(name, "//") {
+		return fmt.Errorf("ref name %q cannot contain consecutive slashes", name)
+	}
+
+	for _, c := range name {
+		switch {
+		case c < 0x20 || c == 0x7f:
+			return fmt.Errorf("ref name %q cannot contain control characters", name)
+		case c == ' ':
+			return fmt.Errorf("ref name %q cannot contain spaces", name)
+		case strings.ContainsRune("~^:?*[\\", c):
+			return fmt.Errorf("ref name %q cannot contain '%c'", name, c)
+		}
+	}
+
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") {
+			return fmt.Errorf("ref name %q has a component starting with '.'", name)
+		}
+		if strings.HasSuffix(component, ".lock") {
+			return fmt.Errorf("ref name %q has a component ending with '.lock'", name)
+		}
+	}
+
+	return nil
+}
@@ -1,17 +1,60 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
+	"mime"
+	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	texttemplate "text/template"
 	"time"
-
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pkg/sftp"
+	"github.com/robfig/cron/v3"
+	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"remote-git-manager/internal/git"
 )
 
 type Config struct {
@@ -20,12 +63,133 @@ type Config struct {
 	SSHUser      string `json:"ssh_user"`
 	SSHKeyPath   string `json:"ssh_key_path"`
 	SSHPassword  string `json:"ssh_password"`
-	AuthMethod   string `json:"auth_method"` // "password" or "key"
+	AuthMethod   string `json:"auth_method"` // "password", "key" or "agent"
 	WorkingDir   string `json:"working_dir"`
-	GitHubToken  string `json:"github_token"`
 	IsConfigured bool   `json:"is_configured"`
+
+	// GitHubTokens are handed out by githubTokens, which picks the one with the most API requests left
+	GitHubTokens []TokenEntry `json:"github_tokens"`
+
+	// SSHKeyPassphrase unlocks an encrypted SSH key; saveConfig stores it encrypted
+	SSHKeyPassphrase string `json:"ssh_key_passphrase"`
+
+	SlackSigningSecret string `json:"slack_signing_secret"`
+
+	GitAuthorName  string `json:"git_author_name"`
+	GitAuthorEmail string `json:"git_author_email"`
+
+	// CommitMessageTemplate pre-fills the commit modal; see CommitTemplateData for placeholders
+	CommitMessageTemplate string `json:"commit_message_template"`
+
+	ArchiveS3Bucket    string `json:"archive_s3_bucket"`
+	ArchiveS3Region    string `json:"archive_s3_region"`
+	ArchiveS3KeyID     string `json:"archive_s3_key_id"`
+	ArchiveS3SecretKey string `json:"archive_s3_secret_key"`
+	ArchiveS3Endpoint  string `json:"archive_s3_endpoint"` // optional, for S3-compatible storage such as MinIO
+
+	IPAllowlist []string `json:"ip_allowlist"`
+	IPDenylist  []string `json:"ip_denylist"`
+	BehindProxy bool     `json:"behind_proxy"`
+
+	// TrustedProxies is the number of proxies in front of the server that append to X-Forwarded-For; 0 means 1
+	TrustedProxies int `json:"trusted_proxies"`
+
+	// ListenAddr is the TCP address of the web UI, read at startup; "" means defaultListenAddr, --addr overrides it
+	ListenAddr string `json:"listen_addr"`
+
+	// With both set, every page except the health probes asks for these credentials. The password is a bcrypt hash;
+	// a plain one written into config.json is hashed on startup.
+	WebUIUser     string `json:"web_ui_user"`
+	WebUIPassword string `json:"web_ui_password"`
+
+	// APIKey authenticates programmatic clients on the API routes; generated on first start, saveConfig stores it encrypted
+	APIKey string `json:"api_key"`
+
+	TrustedNetworks []string `json:"trusted_networks"` // hosts in these ranges skip fingerprint verification
+	KnownHostsPath  string   `json:"known_hosts_path"` // defaults to ~/.ssh/known_hosts
+
+	// SearchDepth is the find -maxdepth used to look for repositories; 0 means defaultSearchDepth
+	SearchDepth int `json:"search_depth"`
+
+	// DeployRoot is the directory deploy paths must stay inside; "" means WorkingDir
+	DeployRoot string `json:"deploy_root"`
+
+	// ConnectTimeout bounds the TCP dial and the SSH handshake each, in seconds; 0 means defaultConnectTimeout
+	ConnectTimeout int `json:"connect_timeout"`
+
+	MaxUploadBytes int64 `json:"max_upload_bytes"` // 0 means defaultMaxUploadBytes
+	MaxViewBytes   int64 `json:"max_view_bytes"`   // 0 means defaultMaxViewBytes
+
+	ActiveProfile string `json:"active_profile"` // ID of the Profile these settings were activated from
+}
+
+// Profile is a saved server configuration that can be switched to from the main page.
+type Profile struct {
+	Config
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// clearInstanceSettings removes what belongs to this manager rather than to the server it manages,
+// so that profiles neither store the credentials of the web UI nor change them when activated.
+func (c *Config) clearInstanceSettings() {
+	c.ListenAddr = ""
+	c.WebUIUser = ""
+	c.WebUIPassword = ""
+	c.APIKey = ""
+}
+
+const (
+	defaultSearchDepth = 2
+	maxSearchDepth     = 10 // deeper searches can take very long on large filesystems
+
+	defaultPullConcurrency = 4
+	maxPullConcurrency     = 16 // every pull holds its own SSH session, and servers limit those per connection
+
+	defaultMaxUploadBytes = 100 << 20
+	defaultConnectTimeout = 10 * time.Second
+	defaultMaxViewBytes   = 1 << 20
+
+	defaultShutdownTimeout = 30 * time.Second // how long running git operations may finish after SIGTERM
+	defaultListenAddr      = ":8080"
+
+	maxJSONContentBytes = 1 << 20 // text files above this are only served raw
+)
+
+// TokenEntry is one GitHub token. RemainingRequests is -1 until the rate limit poller has checked the token.
+type TokenEntry struct {
+	Token             string    `json:"token"`
+	Label             string    `json:"label"`
+	ExpiresAt         time.Time `json:"expires_at"` // zero means the token does not expire
+	RemainingRequests int       `json:"remaining_requests"`
 }
 
+// errUploadTooLarge is returned by UploadStream when the source is bigger than the allowed size.
+var errUploadTooLarge = errors.New("upload exceeds the maximum size")
+
+// errFileTooLarge is returned by ReadFile for files bigger than the configured view limit.
+var errFileTooLarge = errors.New("file exceeds the maximum viewable size")
+
+// ErrInvalidPath is returned by the git methods of SSHManager for repository paths rejected by validateRepoPath.
+type ErrInvalidPath struct {
+	Path   string
+	Reason string
+}
+
+func (e *ErrInvalidPath) Error() string {
+	return fmt.Sprintf("invalid repository path %q: %s", e.Path, e.Reason)
+}
+
+// repoPathMetacharacters are refused in repository paths even though commands quote them, so a path can never
+// reach a shell unquoted.
+const repoPathMetacharacters = ";&|$()<>\\`\n\r"
+
+// errOutsideWorkingDir is returned for file paths that resolve outside the configured working directory.
+var errOutsideWorkingDir = errors.New("path is outside the working directory")
+
+// errSameFile is returned when a copy's source and destination are the same file.
+var errSameFile = errors.New("source and destination are the same file")
+
 type Project struct {
 	Name string `json:"name"`
 	Path string `json:"path"`
@@ -47,21 +211,372 @@ type FileInfo struct {
 	ModTime string `json:"mod_time"`
 }
 
+type CommitInfo struct {
+	Hash    string     `json:"hash"`
+	Author  string     `json:"author"`
+	Date    string     `json:"date"`
+	Subject string     `json:"subject"`
+	Branch  string     `json:"branch,omitempty"`
+	Files   []string   `json:"files,omitempty"`
+	Stats   []FileStat `json:"stats,omitempty"`
+	Patch   string     `json:"patch,omitempty"`
+}
+
+type FileStat struct {
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// commitLogFormat is the pretty format parsed by parseCommitLog.
+// Fields are separated by the unit separator, which unlike "|" cannot appear in author names or subjects.
+const commitLogFormat = "%H%x1f%an%x1f%ai%x1f%s"
+
+// commitRecordFormat starts every commit with a NUL, for output that carries free-form lines after each header.
+const commitRecordFormat = "%x00" + commitLogFormat
+
+type CommitSearchHit struct {
+	Project string    `json:"project"`
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Message string    `json:"message"`
+}
+
+// GitLogOptions narrows down the commits returned by GitLog.
+type GitLogOptions struct {
+	Filter string // "merges", "no-merges" or "" for all commits
+	Grep   string // only commits whose message matches
+
+	AllBranches bool // log every ref instead of HEAD, tagging each commit with the ref it was reached from
+
+	AuthorFilter    string // --author pattern
+	CommitterFilter string // --committer pattern
+
+	IncludeStats    bool // fill CommitInfo.Stats from --numstat
+	FirstParentOnly bool // follow only the mainline, skipping commits brought in by merges
+
+	Path string // only commits touching this file or directory
+
+	SortOrder string // "" for git's own order, "date", "topo" or "author-date"
+
+	IncludePatch bool   // fill CommitInfo.Patch, returning at most maxPatchCommits commits
+	Revision     string // start from this commit instead of HEAD
+
+	// From and To select the range From..To (To defaults to HEAD); with AncestryPath only commits that are both
+	// descendants of From and ancestors of To are listed, e.g. the path from one release tag to the next
+	From         string
+	To           string
+	AncestryPath bool
+
+	Before time.Time // only commits older than this, ignored when zero
+	After  time.Time // only commits newer than this, ignored when zero
+}
+
+// CompactCommit is a one-line log entry: abbreviated hash and subject.
+type CompactCommit struct {
+	Hash    string `json:"hash"`
+	Message string `json:"message"`
+}
+
+// maxPatchCommits caps the log when patches are requested to keep responses small.
+const maxPatchCommits = 5
+
+type BlameEntry struct {
+	Hash    string `json:"hash"`
+	Author  string `json:"author"`
+	Date    string `json:"date"`
+	Summary string `json:"summary"`
+	Line    int    `json:"line"`
+	Content string `json:"content"`
+}
+
+type GistInfo struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Public      bool      `json:"public"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type IgnoreResult struct {
+	File    string `json:"file"`
+	Ignored bool   `json:"ignored"`
+	Pattern string `json:"pattern,omitempty"`
+	Source  string `json:"source,omitempty"` // ignore file containing the matching rule
+	Line    int    `json:"line,omitempty"`
+}
+
+type CredentialResult struct {
+	Protocol string `json:"protocol"`
+	Host     string `json:"host"`
+	Username string `json:"username"` // as returned by the helper
+	Found    bool   `json:"found"`    // a password or token is stored
+}
+
+// CommitTemplateData holds the placeholders available to Config.CommitMessageTemplate.
+type CommitTemplateData struct {
+	RepoName string
+	Branch   string
+	Date     string
+	User     string
+}
+
+type TrackedFile struct {
+	Path   string `json:"path"`
+	Status string `json:"status"` // "M" modified, "D" deleted, "?" untracked, or the index status (e.g. "A") of staged-only changes
+	Staged bool   `json:"staged"`
+}
+
+type TagInfo struct {
+	Name        string `json:"name"`
+	Hash        string `json:"hash"` // commit the tag points to
+	Date        string `json:"date"`
+	IsAnnotated bool   `json:"is_annotated"`
+}
+
+type TagVerifyResult struct {
+	Tag            string `json:"tag"`
+	Signed         bool   `json:"signed"`
+	SignerID       string `json:"signer_id"`
+	Timestamp      string `json:"timestamp"`
+	Validity       string `json:"validity"` // good, bad or unknown
+	KeyFingerprint string `json:"key_fingerprint"`
+	Output         string `json:"output"`
+}
+
+type ActivityBucket struct {
+	Period        string `json:"period"` // 2024-01-31, 2024-W05 or 2024-01
+	CommitCount   int    `json:"commit_count"`
+	UniqueAuthors int    `json:"unique_authors"`
+}
+
+type StashEntry struct {
+	Index   int    `json:"index"`
+	Branch  string `json:"branch"`
+	Message string `json:"message"`
+}
+
+type ReflogEntry struct {
+	Selector string `json:"selector"` // e.g. HEAD@{3}
+	Hash     string `json:"hash"`
+	Date     string `json:"date"`    // when the ref moved, not the commit date
+	Action   string `json:"action"`  // reflog message, e.g. "reset: moving to HEAD~1"
+	Subject  string `json:"subject"` // subject of the commit the ref pointed to
+}
+
+type SubmoduleInfo struct {
+	Path     string `json:"path"`
+	Commit   string `json:"commit"`
+	Status   string `json:"status"` // "ok", "uninitialized", "modified" or "conflict"
+	Describe string `json:"describe,omitempty"`
+}
+
+type WorktreeInfo struct {
+	Path       string `json:"path"`
+	Head       string `json:"head"`
+	Branch     string `json:"branch"`
+	Bare       bool   `json:"bare"`
+	Detached   bool   `json:"detached"`
+	Locked     bool   `json:"locked"`
+	LockReason string `json:"lock_reason"`
+	Prunable   bool   `json:"prunable"`
+}
+
+type RemoteInfo struct {
+	Name     string `json:"name"`
+	FetchURL string `json:"fetch_url"`
+	PushURL  string `json:"push_url"`
+}
+
+type TreeEntry struct {
+	Mode string `json:"mode"`
+	Type string `json:"type"` // "blob", "tree" or "commit" (submodule)
+	Hash string `json:"hash"`
+	Size int64  `json:"size"` // -1 for trees and submodules
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+type ProcessInfo struct {
+	PID     int     `json:"pid"`
+	User    string  `json:"user"`
+	CPU     float64 `json:"cpu"`
+	Mem     float64 `json:"mem"`
+	Command string  `json:"command"`
+}
+
 type SSHManager struct {
 	config *Config
 	client *ssh.Client
+	logger Logger
+
+	// executor, when set, runs ExecuteCommand's and ExecuteCommandWithInput's commands instead of the SSH client,
+	// e.g. a fake server in tests
+	executor CommandExecutor
+	// openSFTP, when set, opens the SFTP sessions instead of the SSH client
+	openSFTP func() (*sftp.Client, error)
+}
+
+// CommandExecutor runs a shell command on the server and returns its combined output.
+type CommandExecutor interface {
+	Execute(command string) (string, error)
+	// ExecuteWithInput runs command with input streamed to its stdin.
+	ExecuteWithInput(command string, input io.Reader) (string, error)
+}
+
+// Fields carries the structured context of a log message, e.g. the repository path or the failed command.
+type Fields map[string]interface{}
+
+// Logger receives SSHManager's log messages. Messages start with the usual emoji, which JSONLogger leaves out.
+// Only connecting, running commands and the clone, pull, push, status and remove operations log through it, as
+// these are what log aggregation needs; everything else still writes plain log.Printf lines, whatever --log-format says.
+type Logger interface {
+	Info(msg string, fields Fields)
+	Warn(msg string, fields Fields)
+	Error(msg string, fields Fields)
+}
+
+// defaultLogger is given to every new SSHManager; main replaces it according to --log-format.
+var defaultLogger Logger = TextLogger{}
+
+// TextLogger writes "msg key=value ..." lines through the standard log package.
+type TextLogger struct{}
+
+func (TextLogger) Info(msg string, fields Fields)  { log.Print(formatTextLog(msg, fields)) }
+func (TextLogger) Warn(msg string, fields Fields)  { log.Print(formatTextLog(msg, fields)) }
+func (TextLogger) Error(msg string, fields Fields) { log.Print(formatTextLog(msg, fields)) }
+
+func formatTextLog(msg string, fields Fields) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var line strings.Builder
+	line.WriteString(msg)
+	for _, key := range keys {
+		value := fmt.Sprint(fields[key])
+		if value == "" || strings.ContainsAny(value, " \t\r\n\"") {
+			value = strconv.Quote(value)
+		}
+		line.WriteString(" " + key + "=" + value)
+	}
+	return line.String()
+}
+
+// JSONLogger writes one {"level", "ts", "msg", "fields"} object per line, for log aggregation systems.
+type JSONLogger struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func NewJSONLogger(out io.Writer) *JSONLogger {
+	return &JSONLogger{out: out}
+}
+
+func (l *JSONLogger) Info(msg string, fields Fields)  { l.write("info", msg, fields) }
+func (l *JSONLogger) Warn(msg string, fields Fields)  { l.write("warn", msg, fields) }
+func (l *JSONLogger) Error(msg string, fields Fields) { l.write("error", msg, fields) }
+
+func (l *JSONLogger) write(level, msg string, fields Fields) {
+	// Errors would marshal as {}, so they are logged by their message
+	values := make(Fields, len(fields))
+	for key, value := range fields {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		values[key] = value
+	}
+
+	// Commands are logged as is, without HTML escaping of && and redirections
+	var line bytes.Buffer
+	encoder := json.NewEncoder(&line)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(struct {
+		Level  string `json:"level"`
+		Ts     string `json:"ts"`
+		Msg    string `json:"msg"`
+		Fields Fields `json:"fields"`
+	}{
+		Level: level,
+		Ts:    time.Now().UTC().Format(time.RFC3339Nano),
+		Msg: strings.TrimLeftFunc(msg, func(r rune) bool {
+			return r > unicode.MaxASCII || unicode.IsSpace(r)
+		}),
+		Fields: values,
+	})
+	if err != nil {
+		log.Printf("⚠️ Log entry encoding failed: %v", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out.Write(line.Bytes())
+}
+
+// ErrUnknownHost is returned by Connect when the server's host key is not in the known_hosts file yet.
+type ErrUnknownHost struct {
+	Host        string `json:"host"`
+	KeyType     string `json:"key_type"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+func (e *ErrUnknownHost) Error() string {
+	return fmt.Sprintf("unknown host %s (%s key fingerprint %s), confirm it on the setup page to add it to known_hosts", e.Host, e.KeyType, e.Fingerprint)
 }
 
 func NewSSHManager(config *Config) *SSHManager {
-	return &SSHManager{config: config}
+	return &SSHManager{config: config, logger: defaultLogger}
 }
 
 func (s *SSHManager) Connect() error {
+	return s.ConnectWithProgress(func(string) {})
+}
+
+// ConnectWithProgress connects like Connect and reports each completed step, e.g. to show it in the setup page
+// while a slow server is being reached.
+func (s *SSHManager) ConnectWithProgress(progress func(step string)) (err error) {
+	fields := Fields{"host": s.config.SSHHost, "port": s.config.SSHPort, "user": s.config.SSHUser, "auth_method": s.config.AuthMethod}
+	defer func() {
+		if err != nil {
+			fields["error"] = err
+			s.logger.Error("❌ SSH connection failed", fields)
+		} else {
+			s.logger.Info("🔌 SSH connected", fields)
+		}
+	}()
+
 	var authMethods []ssh.AuthMethod
 
 	if s.config.AuthMethod == "password" {
 		// Password authentication
 		authMethods = append(authMethods, ssh.Password(s.config.SSHPassword))
+	} else if s.config.AuthMethod == "agent" {
+		// Identities from the ssh-agent of the manager process
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return fmt.Errorf("SSH_AUTH_SOCK is not set, start the manager from an environment with a running ssh-agent")
+		}
+
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return fmt.Errorf("SSH agent connection failed: %v", err)
+		}
+		// The agent signs during the handshake only, so it can be released once Connect returns
+		defer conn.Close()
+
+		signers, err := agent.NewClient(conn).Signers()
+		if err != nil {
+			return fmt.Errorf("SSH agent key listing failed: %v", err)
+		}
+		if len(signers) == 0 {
+			return fmt.Errorf("SSH agent has no identities, add one with ssh-add")
+		}
+
+		authMethods = append(authMethods, ssh.PublicKeys(signers...))
 	} else {
 		// SSH key authentication
 		keyBytes, err := os.ReadFile(s.config.SSHKeyPath)
@@ -69,60 +584,304 @@ func (s *SSHManager) Connect() error {
 			return fmt.Errorf("SSH key read failed: %v", err)
 		}
 
-		signer, err := ssh.ParsePrivateKey(keyBytes)
+		signer, err := parsePrivateKey(keyBytes, s.config.SSHKeyPassphrase)
 		if err != nil {
-			return fmt.Errorf("SSH key parse failed: %v", err)
+			return err
 		}
 
 		authMethods = append(authMethods, ssh.PublicKeys(signer))
 	}
 
+	hostKeyCallback, err := s.hostKeyCallback()
+	if err != nil {
+		return err
+	}
+	progress(fmt.Sprintf("Credentials loaded (%s authentication)", s.config.AuthMethod))
+
+	timeout := defaultConnectTimeout
+	if s.config.ConnectTimeout > 0 {
+		timeout = time.Duration(s.config.ConnectTimeout) * time.Second
+	}
 	config := &ssh.ClientConfig{
 		User:            s.config.SSHUser,
 		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         10 * time.Second,
+		HostKeyCallback: hostKeyCallback,
 	}
 
-	var err error
-	s.client, err = ssh.Dial("tcp", s.config.SSHHost+":"+s.config.SSHPort, config)
+	// Dial and handshake separately, so an unreachable host is told apart from a slow or failing SSH server
+	addr := net.JoinHostPort(s.config.SSHHost, s.config.SSHPort)
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return fmt.Errorf("SSH connection failed: %w", err)
+	}
+	progress("TCP connected to " + addr)
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		// %w keeps ErrUnknownHost reachable for the HTTP layer
+		return fmt.Errorf("SSH connection failed: %w", err)
+	}
+	conn.SetDeadline(time.Time{})
+	progress("SSH handshake complete, logged in as " + s.config.SSHUser)
+
+	s.client = ssh.NewClient(sshConn, chans, reqs)
+	return nil
+}
+
+// hostKeyCallback picks how the server's host key is checked.
+// Connections to an address in a trusted network are accepted without verification, but their fingerprint is still
+// logged for auditing. The address is the one actually dialed, not a lookup made beforehand, so a DNS answer that
+// changes in between cannot skip verification. Every other host must be listed in the known_hosts file.
+func (s *SSHManager) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	strict, err := s.knownHostsCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	trusted := parseIPRanges(s.config.TrustedNetworks)
+	if len(trusted) == 0 {
+		return strict, nil
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if !isTrustedAddr(remote, trusted) {
+			return strict(hostname, remote, key)
+		}
+		log.Printf("🛡️ Accepted host key for %s (%s) in a trusted network: %s %s", hostname, remote, key.Type(), ssh.FingerprintSHA256(key))
+		return nil
+	}, nil
+}
+
+// isTrustedAddr reports whether remote is a TCP address inside one of the trusted ranges.
+func isTrustedAddr(remote net.Addr, trusted []netip.Prefix) bool {
+	tcpAddr, ok := remote.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	addr, ok := netip.AddrFromSlice(tcpAddr.IP)
+	return ok && ipInRanges(addr.Unmap(), trusted)
+}
+
+// knownHostsCallback accepts only host keys listed in the known_hosts file.
+func (s *SSHManager) knownHostsCallback() (ssh.HostKeyCallback, error) {
+	path, err := s.knownHostsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	callback, err := knownhosts.New(path)
+	if os.IsNotExist(err) {
+		// No known_hosts file yet, so every host is unknown until the user trusts it
+		callback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			return &knownhosts.KeyError{}
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("known_hosts read failed: %v", err)
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+
+		fingerprint := ssh.FingerprintSHA256(key)
+		if len(keyErr.Want) == 0 {
+			log.Printf("❓ Unknown host key for %s: %s %s", hostname, key.Type(), fingerprint)
+			return &ErrUnknownHost{Host: hostname, KeyType: key.Type(), Fingerprint: fingerprint}
+		}
+		log.Printf("🚨 Host key mismatch for %s: got %s %s", hostname, key.Type(), fingerprint)
+		return fmt.Errorf("host key mismatch for %s (got %s %s), the server may be impersonated; check %s", hostname, key.Type(), fingerprint, path)
+	}, nil
+}
+
+func (s *SSHManager) knownHostsPath() (string, error) {
+	path := s.config.KnownHostsPath
+	if path == "" {
+		path = "~/.ssh/known_hosts"
+	}
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("home directory lookup failed: %v", err)
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	return path, nil
+}
+
+// TrustHost appends the server's host key to the known_hosts file.
+// The key is fetched again and must still match the fingerprint the user confirmed.
+func (s *SSHManager) TrustHost(fingerprint string) error {
+	address := s.config.SSHHost + ":" + s.config.SSHPort
+	log.Printf("🔑 Trusting host key for %s (%s)", address, fingerprint)
+
+	// Abort the handshake as soon as the host key is known; no authentication is attempted
+	var hostKey ssh.PublicKey
+	errKeyCaptured := errors.New("host key captured")
+	config := &ssh.ClientConfig{
+		User: s.config.SSHUser,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			return errKeyCaptured
+		},
+		Timeout: 10 * time.Second,
+	}
+	if _, err := ssh.Dial("tcp", address, config); hostKey == nil {
+		return fmt.Errorf("host key scan failed: %v", err)
+	}
+
+	if actual := ssh.FingerprintSHA256(hostKey); actual != fingerprint {
+		log.Printf("🚨 Host key changed while trusting %s: %s", address, actual)
+		return fmt.Errorf("host key fingerprint changed to %s, refusing to trust it", actual)
+	}
+
+	path, err := s.knownHostsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("known_hosts directory create failed: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
-		return fmt.Errorf("SSH connection failed: %v", err)
+		return fmt.Errorf("known_hosts open failed: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintln(file, knownhosts.Line([]string{knownhosts.Normalize(address)}, hostKey)); err != nil {
+		return fmt.Errorf("known_hosts write failed: %v", err)
 	}
 
+	log.Printf("✅ Host key added to %s", path)
 	return nil
 }
 
 func (s *SSHManager) ExecuteCommand(command string) (string, error) {
+	if s.executor != nil {
+		return s.executor.Execute(command)
+	}
 	if s.client == nil {
 		return "", fmt.Errorf("SSH connection not established")
 	}
 
 	// Log command
-	log.Printf("📋 SSH Command: %s", command)
+	s.logger.Info("📋 SSH Command", Fields{"command": githubTokens.Redact(command)})
 
 	session, err := s.client.NewSession()
 	if err != nil {
-		log.Printf("❌ Session creation failed: %v", err)
+		s.logger.Error("❌ Session creation failed", Fields{"command": githubTokens.Redact(command), "error": err})
+		return "", err
+	}
+	defer session.Close()
+
+	outputStr, err := s.runRecorded(session, command)
+
+	if err != nil {
+		s.logger.Error("❌ Command failed", Fields{"command": githubTokens.Redact(command), "error": err, "output": outputStr})
+	} else {
+		s.logger.Info("✅ Command success", Fields{"command": githubTokens.Redact(command), "output": outputStr})
+	}
+
+	return outputStr, err
+}
+
+// ExecuteCommandWithInput runs a command with input streamed to its stdin.
+func (s *SSHManager) ExecuteCommandWithInput(command string, input io.Reader) (string, error) {
+	if s.executor != nil {
+		return s.executor.ExecuteWithInput(command, input)
+	}
+	if s.client == nil {
+		return "", fmt.Errorf("SSH connection not established")
+	}
+
+	// Log command
+	s.logger.Info("📋 SSH Command (with stdin)", Fields{"command": githubTokens.Redact(command)})
+
+	session, err := s.client.NewSession()
+	if err != nil {
+		s.logger.Error("❌ Session creation failed", Fields{"command": githubTokens.Redact(command), "error": err})
 		return "", err
 	}
 	defer session.Close()
 
-	output, err := session.CombinedOutput(command)
-	outputStr := string(output)
+	session.Stdin = input
+	outputStr, err := s.runRecorded(session, command)
 
 	if err != nil {
-		log.Printf("❌ Command failed: %s -> Error: %v, Output: %s", command, err, outputStr)
+		s.logger.Error("❌ Command failed", Fields{"command": githubTokens.Redact(command), "error": err, "output": outputStr})
 	} else {
-		log.Printf("✅ Command success: %s -> Output: %s", command, outputStr)
+		s.logger.Info("✅ Command success", Fields{"command": githubTokens.Redact(command), "output": outputStr})
 	}
 
 	return outputStr, err
 }
 
+// lockedWriter serializes writes from a session's stdout and stderr into one buffer.
+type lockedWriter struct {
+	mu  *sync.Mutex
+	buf *bytes.Buffer
+}
+
+func (w lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+// runRecorded runs command on session and returns its combined output like CombinedOutput,
+// adding the command with its separate stdout and stderr to the operation log.
+func (s *SSHManager) runRecorded(session *ssh.Session, command string) (string, error) {
+	var mu sync.Mutex
+	var combined, stdout, stderr bytes.Buffer
+	session.Stdout = io.MultiWriter(&stdout, lockedWriter{&mu, &combined})
+	session.Stderr = io.MultiWriter(&stderr, lockedWriter{&mu, &combined})
+
+	started := time.Now()
+	err := session.Run(command)
+	observeSSHCommand(time.Since(started), err)
+
+	exitCode := 0
+	if err != nil {
+		exitCode = -1
+		var exitErr *ssh.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitStatus()
+		}
+	}
+	if err := operationLog.Append(OperationRecord{
+		ProfileID:  s.config.ActiveProfile,
+		Command:    command,
+		ExitCode:   exitCode,
+		DurationMs: time.Since(started).Milliseconds(),
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
+		Timestamp:  started,
+	}); err != nil {
+		s.logger.Warn("⚠️ Operation log write failed", Fields{"error": err})
+	}
+
+	return combined.String(), err
+}
+
 func (s *SSHManager) ListProjects() ([]Project, error) {
+	return s.ListProjectsAtDepth(s.config.SearchDepth)
+}
+
+// ListProjectsAtDepth looks for repositories up to depth levels below the working directory; 0 means defaultSearchDepth.
+func (s *SSHManager) ListProjectsAtDepth(depth int) ([]Project, error) {
+	if depth == 0 {
+		depth = defaultSearchDepth
+	}
+	if depth < 0 || depth > maxSearchDepth {
+		return nil, fmt.Errorf("invalid search depth %d (allowed: 1-%d)", depth, maxSearchDepth)
+	}
+
 	// Find Git repositories in working directory
-	command := fmt.Sprintf("find %s -maxdepth 2 -name '.git' -type d", s.config.WorkingDir)
+	command := fmt.Sprintf("find %s -maxdepth %d -name '.git' -type d", shellQuote(s.config.WorkingDir), depth)
 	log.Printf("🔍 Searching for Git repositories: %s", command)
 
 	output, err := s.ExecuteCommand(command)
@@ -161,898 +920,14170 @@ func (s *SSHManager) ListProjects() ([]Project, error) {
 	return projects, nil
 }
 
+// ListFiles lists the entries of a remote directory over SFTP, so names with spaces or shell characters are handled as is.
 func (s *SSHManager) ListFiles(path string) ([]FileInfo, error) {
 	if path == "" {
 		path = s.config.WorkingDir
 	}
+	// Convert to Linux path format
+	path = strings.Replace(path, "\\", "/", -1)
 
-	command := fmt.Sprintf("find %s -maxdepth 1 -type f -exec ls -la {} \\; && find %s -maxdepth 1 -type d -exec ls -ld {} \\;", path, path)
-	output, err := s.ExecuteCommand(command)
+	client, err := s.newSFTPClient()
 	if err != nil {
 		return nil, err
 	}
+	defer client.Close()
 
-	var files []FileInfo
-	lines := strings.Split(output, "\n")
+	return readDirFiles(client, path)
+}
 
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
+// ListWorkingDirFiles lists a directory given relative to the working directory, or absolute inside it, and returns
+// its resolved path. Symlinks are resolved on the server first, so a link inside the working directory cannot be
+// used to list a directory outside it.
+func (s *SSHManager) ListWorkingDirFiles(remotePath string) (string, []FileInfo, error) {
+	dir, err := s.resolveWorkingDirPath(remotePath)
+	if err != nil {
+		return "", nil, err
+	}
 
-		parts := strings.Fields(line)
-		if len(parts) >= 9 {
-			name := parts[8]
-			if name == "." || name == ".." {
-				continue
-			}
+	client, err := s.newSFTPClient()
+	if err != nil {
+		return "", nil, err
+	}
+	defer client.Close()
 
-			file := FileInfo{
-				Name:    name,
-				Path:    filepath.Join(path, name),
-				IsDir:   strings.HasPrefix(line, "d"),
-				ModTime: strings.Join(parts[5:8], " "),
-			}
-			files = append(files, file)
-		}
+	if err := s.confineRealPath(client, dir); err != nil {
+		return "", nil, err
+	}
+	files, err := readDirFiles(client, dir)
+	return dir, files, err
+}
+
+func readDirFiles(client *sftp.Client, path string) ([]FileInfo, error) {
+	entries, err := client.ReadDir(path)
+	if err != nil {
+		log.Printf("❌ Directory read failed: %v", err)
+		return nil, fmt.Errorf("directory read failed: %v", err)
+	}
+
+	files := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		files = append(files, FileInfo{
+			Name:    entry.Name(),
+			Path:    filepath.Join(path, entry.Name()),
+			IsDir:   entry.IsDir(),
+			Size:    entry.Size(),
+			ModTime: entry.ModTime().Format("2006-01-02 15:04:05"),
+		})
 	}
 
 	return files, nil
 }
 
-func (s *SSHManager) GitClone(repoURL, branch string) (string, error) {
-	log.Printf("📥 Clone starting: %s (branch: %s)", repoURL, branch)
+// GitClone clones repoURL into the working directory. A depth above zero makes a shallow clone of all branch tips;
+// git pull keeps working on it, but git fetch --unshallow is needed to get the full history later.
+// With recursive set, submodules are cloned too and checked out at their remote tracking branch.
+func (s *SSHManager) GitClone(repoURL, branch string, depth int, recursive bool) (string, error) {
+	fields := Fields{"repo_url": repoURL, "branch": branch, "depth": depth, "recursive": recursive}
+	s.logger.Info("📥 Clone starting", fields)
+	gitOperationsTotal.WithLabelValues("clone").Inc()
+
+	if branch != "" {
+		if err := git.ValidateRefName(branch); err != nil {
+			return "", fmt.Errorf("invalid branch name: %v", err)
+		}
+	}
 
-	// Add GitHub token to URL if available
-	if s.config.GitHubToken != "" {
-		repoURL = s.addTokenToURL(repoURL)
-		log.Printf("🔐 GitHub token added")
+	// Send the GitHub token if available, without storing it in the clone's origin URL
+	token := githubTokens.Token()
+	if token != "" {
+		s.logger.Info("🔐 GitHub token added", fields)
 	}
 
-	var command string
+	cloneArgs := "git" + githubAuthArgs(token) + " clone"
 	if branch != "" {
-		command = fmt.Sprintf("cd %s && git clone -b %s %s", s.config.WorkingDir, branch, repoURL)
-	} else {
-		command = fmt.Sprintf("cd %s && git clone %s", s.config.WorkingDir, repoURL)
+		cloneArgs += " -b " + shellQuote(branch)
+	}
+	if depth > 0 {
+		cloneArgs += fmt.Sprintf(" --depth %d --no-single-branch", depth)
+	}
+	if recursive {
+		cloneArgs += " --recurse-submodules --remote-submodules"
 	}
+	command := fmt.Sprintf("cd %s && %s -- %s", shellQuote(s.config.WorkingDir), cloneArgs, shellQuote(repoURL))
 
 	result, err := s.ExecuteCommand(command)
 	if err != nil {
-		log.Printf("❌ Clone failed: %v", err)
+		fields["error"] = err
+		s.logger.Error("❌ Clone failed", fields)
 	} else {
-		log.Printf("✅ Clone successful")
+		s.logger.Info("✅ Clone successful", fields)
 	}
 	return result, err
 }
 
-func (s *SSHManager) GitPull(repoPath string) (string, error) {
+// GitSubmoduleUpdate initialises and checks out the submodules of a repository cloned without --recurse-submodules.
+func (s *SSHManager) GitSubmoduleUpdate(repoPath string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🧩 Submodule update: %s", repoPath)
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, "git submodule update --init --recursive"))
+	if err != nil {
+		log.Printf("❌ Submodule update failed: %v", err)
+	} else {
+		log.Printf("✅ Submodule update successful")
+	}
+	return result, err
+}
+
+// SubmoduleStatus parses git submodule status lines of the form "<flag><sha> <path> (<describe>)".
+func (s *SSHManager) SubmoduleStatus(repoPath string) ([]SubmoduleInfo, error) {
 	// Convert to Linux path format
 	repoPath = strings.Replace(repoPath, "\\", "/", -1)
-	log.Printf("⬇️ Pull starting: %s", repoPath)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🧩 Submodule status: %s", repoPath)
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git submodule status --recursive"))
+	if err != nil {
+		log.Printf("❌ Submodule status failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	submodules := make([]SubmoduleInfo, 0)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if len(line) < 2 {
+			continue
+		}
+
+		var info SubmoduleInfo
+		switch line[0] {
+		case ' ':
+			info.Status = "ok"
+		case '-':
+			info.Status = "uninitialized"
+		case '+':
+			info.Status = "modified"
+		case 'U':
+			info.Status = "conflict"
+		default:
+			continue
+		}
+
+		fields := strings.SplitN(line[1:], " ", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		info.Commit = fields[0]
+		info.Path = fields[1]
+		if len(fields) == 3 {
+			info.Describe = strings.TrimSuffix(strings.TrimPrefix(fields[2], "("), ")")
+		}
+		submodules = append(submodules, info)
+	}
 
-	// Update remote URL with GitHub token if available
-	if s.config.GitHubToken != "" {
-		getRemoteCmd := fmt.Sprintf("cd %s && git remote get-url origin", repoPath)
-		remoteURL, err := s.ExecuteCommand(getRemoteCmd)
-		if err == nil && strings.TrimSpace(remoteURL) != "" {
-			tokenURL := s.addTokenToURL(strings.TrimSpace(remoteURL))
-			setURLCmd := fmt.Sprintf("cd %s && git remote set-url origin %s", repoPath, tokenURL)
-			s.ExecuteCommand(setURLCmd)
-			log.Printf("🔐 Remote URL updated with token")
+	log.Printf("✅ Submodule status: %d submodules", len(submodules))
+	return submodules, nil
+}
+
+// gitWithToken returns the git invocation for a command talking to remote. With a GitHub token configured, the token
+// is passed for this command only, and credentials that older versions wrote into the remote's URL are removed
+// first, so they neither stay on the server nor shadow a rotated token.
+func (s *SSHManager) gitWithToken(repoPath, remote string) string {
+	token := githubTokens.Token()
+	if token == "" {
+		return "git"
+	}
+
+	remoteURL, err := s.ExecuteCommand(s.repoCommand(repoPath, "git remote get-url "+shellQuote(remote)))
+	remoteURL = strings.TrimSpace(remoteURL)
+	if stripped := stripURLCredentials(remoteURL); err == nil && stripped != remoteURL {
+		if _, err := s.ExecuteCommand(s.repoCommand(repoPath, "git remote set-url "+shellQuote(remote)+" "+shellQuote(stripped))); err != nil {
+			log.Printf("⚠️ Removing the stored token from %s failed: %v", remote, err)
+		} else {
+			log.Printf("🔐 Stored token removed from %s", remote)
 		}
 	}
+	return "git" + githubAuthArgs(token)
+}
+
+// githubAuthArgs returns the git options that send token to github.com over HTTPS, or "" without a token.
+func githubAuthArgs(token string) string {
+	if token == "" {
+		return ""
+	}
+	return " -c " + shellQuote("http.https://github.com/.extraHeader=Authorization: Basic "+githubBasicAuth(token))
+}
+
+// githubBasicAuth encodes token as the credentials of a Basic Authorization header, which GitHub accepts for git.
+func githubBasicAuth(token string) string {
+	return base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+}
+
+// GitFetch updates the remote tracking branches of remote (origin by default) and prunes deleted ones, without merging.
+func (s *SSHManager) GitFetch(repoPath, remote string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	if remote == "" {
+		remote = "origin"
+	}
+	log.Printf("🔄 Fetch starting: %s (remote: %s)", repoPath, remote)
 
-	command := fmt.Sprintf("cd %s && git pull", repoPath)
+	if err := git.ValidateRefName(remote); err != nil {
+		return "", fmt.Errorf("invalid remote name: %v", err)
+	}
+
+	gitCmd := s.gitWithToken(repoPath, remote)
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, gitCmd+" fetch "+shellQuote(remote)+" --prune"))
+	if err != nil {
+		log.Printf("❌ Fetch failed: %v", err)
+	} else {
+		log.Printf("✅ Fetch successful")
+	}
+	return result, err
+}
+
+func (s *SSHManager) GitPull(repoPath string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	s.logger.Info("⬇️ Pull starting", Fields{"repo_path": repoPath})
+	gitOperationsTotal.WithLabelValues("pull").Inc()
+
+	command := s.repoCommand(repoPath, s.gitWithToken(repoPath, "origin")+" pull")
 	result, err := s.ExecuteCommand(command)
 	if err != nil {
-		log.Printf("❌ Pull failed: %v", err)
+		s.logger.Error("❌ Pull failed", Fields{"repo_path": repoPath, "error": err})
 	} else {
-		log.Printf("✅ Pull successful")
+		s.logger.Info("✅ Pull successful", Fields{"repo_path": repoPath})
 	}
 	return result, err
 }
 
-func (s *SSHManager) GitPush(repoPath, message string) (string, error) {
+// BulkResult is the outcome of pulling one repository in BulkPull.
+type BulkResult struct {
+	Output string
+	Error  error
+}
+
+// BulkPull pulls every repository in paths, running at most concurrency pulls at a time (defaultPullConcurrency if
+// not positive, and never more than maxPullConcurrency).
+func (s *SSHManager) BulkPull(paths []string, concurrency int) map[string]BulkResult {
+	var mu sync.Mutex
+	results := make(map[string]BulkResult, len(paths))
+	s.bulkPull(paths, concurrency, func(repoPath string, result BulkResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[repoPath] = result
+	})
+	return results
+}
+
+// bulkPull is BulkPull reporting each result as soon as its pull finishes. done may be called from several goroutines at once.
+func (s *SSHManager) bulkPull(paths []string, concurrency int, done func(repoPath string, result BulkResult)) {
+	if concurrency <= 0 {
+		concurrency = defaultPullConcurrency
+	}
+	concurrency = min(concurrency, maxPullConcurrency)
+	log.Printf("⬇️ Bulk pull starting: %d repositories (concurrency: %d)", len(paths), concurrency)
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for _, repoPath := range paths {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(repoPath string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			output, err := s.GitPull(repoPath)
+			done(repoPath, BulkResult{Output: output, Error: err})
+		}(repoPath)
+	}
+
+	wg.Wait()
+	log.Printf("✅ Bulk pull finished: %d repositories", len(paths))
+}
+
+type PushOptions struct {
+	Trailers   []string // "Key: value" trailers appended to the commit message
+	AllowEmpty bool     // commit even without changes, e.g. as a deployment marker
+	Branch     string   // push HEAD to this branch on origin instead of the upstream
+
+	CommitDate *time.Time // author and committer date of the new commit, e.g. when importing old work
+}
+
+type DiffStats struct {
+	FilesChanged int        `json:"files_changed"`
+	Insertions   int        `json:"insertions"`
+	Deletions    int        `json:"deletions"`
+	FileStats    []FileStat `json:"file_stats"`
+}
+
+type BranchInfo struct {
+	Name      string `json:"name"`
+	IsCurrent bool   `json:"is_current"`
+	IsRemote  bool   `json:"is_remote"`
+}
+
+func (s *SSHManager) GitPush(repoPath, message string, opts PushOptions) (string, error) {
 	// Convert to Linux path format
 	repoPath = strings.Replace(repoPath, "\\", "/", -1)
-	log.Printf("⬆️ Push starting: %s (message: %s)", repoPath, message)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	s.logger.Info("⬆️ Push starting", Fields{"repo_path": repoPath, "message": message, "branch": opts.Branch})
+	gitOperationsTotal.WithLabelValues("push").Inc()
+
+	commitFlags := ""
+	if opts.AllowEmpty {
+		commitFlags = " --allow-empty"
+	}
 
-	// Update remote URL with GitHub token if available
-	if s.config.GitHubToken != "" {
-		getRemoteCmd := fmt.Sprintf("cd %s && git remote get-url origin", repoPath)
-		remoteURL, err := s.ExecuteCommand(getRemoteCmd)
-		if err == nil && strings.TrimSpace(remoteURL) != "" {
-			tokenURL := s.addTokenToURL(strings.TrimSpace(remoteURL))
-			setURLCmd := fmt.Sprintf("cd %s && git remote set-url origin %s", repoPath, tokenURL)
-			s.ExecuteCommand(setURLCmd)
-			log.Printf("🔐 Remote URL updated with token")
+	pushArgs := " push"
+	if opts.Branch != "" {
+		if err := git.ValidateRefName(opts.Branch); err != nil {
+			return "", fmt.Errorf("invalid branch: %v", err)
 		}
+		pushArgs = " push origin " + shellQuote("HEAD:refs/heads/"+opts.Branch)
+	}
+
+	commitEnv := ""
+	if opts.CommitDate != nil {
+		date := shellQuote(opts.CommitDate.Format(time.RFC3339))
+		commitEnv = "GIT_AUTHOR_DATE=" + date + " GIT_COMMITTER_DATE=" + date + " "
+		s.logger.Info("🕰️ Commit date overridden", Fields{"repo_path": repoPath, "commit_date": opts.CommitDate.Format(time.RFC3339)})
+	}
+
+	commitCmd := commitEnv + "git commit" + commitFlags + " -m " + shellQuote(message)
+	if len(opts.Trailers) > 0 {
+		commitCmd = fmt.Sprintf("printf '%%s\\n' %s | git interpret-trailers%s | %sgit commit%s -F -",
+			shellQuote(message), trailerArgs(opts.Trailers), commitEnv, commitFlags)
 	}
 
 	commands := []string{
-		fmt.Sprintf("cd %s && git add .", repoPath),
-		fmt.Sprintf("cd %s && git commit -m \"%s\"", repoPath, message),
-		fmt.Sprintf("cd %s && git push", repoPath),
+		s.repoCommand(repoPath, "git add ."),
+		s.repoCommand(repoPath, commitCmd),
+		s.repoCommand(repoPath, s.gitWithToken(repoPath, "origin")+pushArgs),
 	}
 
 	var results []string
 	for i, cmd := range commands {
-		log.Printf("📋 Push step %d: %s", i+1, cmd)
+		s.logger.Info("📋 Push step", Fields{"repo_path": repoPath, "step": i + 1, "command": cmd})
 		result, err := s.ExecuteCommand(cmd)
 		if err != nil {
-			log.Printf("❌ Push step %d failed: %v", i+1, err)
+			s.logger.Error("❌ Push step failed", Fields{"repo_path": repoPath, "step": i + 1, "error": err})
 			return fmt.Sprintf("%s\nError: %v", result, err), err
 		}
 		results = append(results, result)
 	}
 
-	log.Printf("✅ Push successful")
+	s.logger.Info("✅ Push successful", Fields{"repo_path": repoPath})
 	return strings.Join(results, "\n"), nil
 }
 
-func (s *SSHManager) GitStatus(repoPath string) (string, error) {
+// AppendTrailer amends the latest commit, adding the trailer to its message.
+func (s *SSHManager) AppendTrailer(repoPath, trailerKey, trailerValue string) (string, error) {
 	// Convert to Linux path format
 	repoPath = strings.Replace(repoPath, "\\", "/", -1)
-	log.Printf("📊 Status checking: %s", repoPath)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🏷️ Trailer: %s (%s: %s)", repoPath, trailerKey, trailerValue)
 
-	command := fmt.Sprintf("cd %s && git status", repoPath)
-	result, err := s.ExecuteCommand(command)
+	if trailerKey == "" || trailerValue == "" {
+		return "", fmt.Errorf("trailer key and value are required")
+	}
+	if strings.ContainsAny(trailerKey, ": \n") {
+		return "", fmt.Errorf("invalid trailer key %q", trailerKey)
+	}
+
+	command := fmt.Sprintf("git log -1 --format=%%B | git interpret-trailers --if-exists addIfDifferent%s | git commit --amend -F -",
+		trailerArgs([]string{trailerKey + ": " + trailerValue}))
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
 	if err != nil {
-		log.Printf("❌ Status failed: %v", err)
+		log.Printf("❌ Trailer failed: %v", err)
 	} else {
-		log.Printf("✅ Status successful")
+		log.Printf("✅ Trailer added")
 	}
 	return result, err
 }
 
-func (s *SSHManager) RemoveProject(repoPath string) (string, error) {
-	// Convert to Linux path format
-	repoPath = strings.Replace(repoPath, "\\", "/", -1)
-	log.Printf("🗑️ Project removing: %s", repoPath)
+// renderCommitTemplate fills the configured commit message template for the repository.
+func (s *SSHManager) renderCommitTemplate(repoPath string) (string, error) {
+	if s.config.CommitMessageTemplate == "" {
+		return "", nil
+	}
 
-	// First check if directory exists
-	checkCmd := fmt.Sprintf("test -d %s && echo 'exists' || echo 'not exists'", repoPath)
-	checkResult, _ := s.ExecuteCommand(checkCmd)
-	log.Printf("📁 Directory existence: %s", strings.TrimSpace(checkResult))
+	tmpl, err := texttemplate.New("commit").Option("missingkey=error").Parse(s.config.CommitMessageTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid commit message template: %v", err)
+	}
 
-	// Remove directory
-	command := fmt.Sprintf("rm -rf %s", repoPath)
-	result, err := s.ExecuteCommand(command)
+	branch, err := s.CurrentBranch(repoPath)
+	if err != nil {
+		return "", err
+	}
 
-	// Confirm deletion
-	confirmCmd := fmt.Sprintf("test -d %s && echo 'still exists' || echo 'deleted'", repoPath)
-	confirmResult, _ := s.ExecuteCommand(confirmCmd)
-	log.Printf("🔍 Removal result: %s", strings.TrimSpace(confirmResult))
+	user := s.config.GitAuthorName
+	if meta := projectMetaStore.Get(repoPath); meta.GitUserName != "" {
+		user = meta.GitUserName
+	}
+	if user == "" {
+		user = s.config.SSHUser
+	}
 
-	if err != nil {
-		log.Printf("❌ Remove failed: %v", err)
-	} else {
-		log.Printf("✅ Remove successful")
+	data := CommitTemplateData{
+		RepoName: filepath.Base(strings.Replace(repoPath, "\\", "/", -1)),
+		Branch:   branch,
+		Date:     time.Now().Format("2006-01-02"),
+		User:     user,
 	}
 
-	return fmt.Sprintf("Command: %s\nResult: %s\nConfirm: %s", command, result, confirmResult), err
+	var message bytes.Buffer
+	if err := tmpl.Execute(&message, data); err != nil {
+		return "", fmt.Errorf("commit message template error: %v", err)
+	}
+	return message.String(), nil
 }
 
-func (s *SSHManager) addTokenToURL(repoURL string) string {
-	// Replace GitHub HTTPS URL with token
-	if strings.Contains(repoURL, "github.com") && strings.HasPrefix(repoURL, "https://") {
-		// https://github.com/user/repo.git -> https://token@github.com/user/repo.git
-		repoURL = strings.Replace(repoURL, "https://github.com", fmt.Sprintf("https://%s@github.com", s.config.GitHubToken), 1)
+// signOffTrailer returns the Signed-off-by trailer for the repository, preferring the project's own identity.
+func (s *SSHManager) signOffTrailer(repoPath string) (string, error) {
+	name, email := s.config.GitAuthorName, s.config.GitAuthorEmail
+	if meta := projectMetaStore.Get(repoPath); meta.GitUserName != "" && meta.GitUserEmail != "" {
+		name, email = meta.GitUserName, meta.GitUserEmail
+	}
+	if name == "" || email == "" {
+		return "", fmt.Errorf("git author name and email are not configured")
 	}
-	return repoURL
+	return fmt.Sprintf("Signed-off-by: %s <%s>", name, email), nil
 }
 
-func (s *SSHManager) Disconnect() {
-	if s.client != nil {
-		s.client.Close()
+func trailerArgs(trailers []string) string {
+	var args strings.Builder
+	for _, trailer := range trailers {
+		args.WriteString(" --trailer " + shellQuote(trailer))
 	}
+	return args.String()
 }
 
-// HTTP Handlers
-var sshManager *SSHManager
-var config *Config
+func (s *SSHManager) GitStatus(repoPath string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	s.logger.Info("📊 Status checking", Fields{"repo_path": repoPath})
+	gitOperationsTotal.WithLabelValues("status").Inc()
 
-func main() {
-	// Load config
-	config = loadConfig()
-	sshManager = NewSSHManager(config)
+	command := s.repoCommand(repoPath, "git status")
+	result, err := s.ExecuteCommand(command)
+	if err != nil {
+		s.logger.Error("❌ Status failed", Fields{"repo_path": repoPath, "error": err})
+	} else {
+		s.logger.Info("✅ Status successful", Fields{"repo_path": repoPath})
+	}
+	return result, err
+}
 
-	// SSH connection (if configured)
-	if config.IsConfigured {
-		if err := sshManager.Connect(); err != nil {
-			log.Printf("SSH connection error: %v", err)
+// DiffStat summarises the changes between two refs. Binary files count as changed without line counts.
+func (s *SSHManager) DiffStat(repoPath, ref1, ref2 string) (*DiffStats, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("📊 Diff stat: %s (%s..%s)", repoPath, ref1, ref2)
+
+	for _, ref := range []string{ref1, ref2} {
+		if ref == "" || strings.HasPrefix(ref, "-") {
+			return nil, fmt.Errorf("invalid ref %q", ref)
 		}
 	}
 
-	// HTTP routes
-	http.HandleFunc("/", indexHandler)
-	http.HandleFunc("/setup", setupHandler)
-	http.HandleFunc("/save-config", saveConfigHandler)
-	http.HandleFunc("/test-connection", testConnectionHandler)
-	http.HandleFunc("/projects", projectsHandler)
-	http.HandleFunc("/git/clone", gitCloneHandler)
-	http.HandleFunc("/git/pull", gitPullHandler)
-	http.HandleFunc("/git/push", gitPushHandler)
-	http.HandleFunc("/git/status", gitStatusHandler)
-	http.HandleFunc("/git/remove", gitRemoveHandler)
-	http.HandleFunc("/config", configHandler)
+	// --numstat is the machine-readable form of --stat, without truncated paths
+	command := fmt.Sprintf("git diff --numstat %s %s --", shellQuote(ref1), shellQuote(ref2))
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Diff stat failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
 
-	// Static files
-	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static/"))))
+	stats := &DiffStats{FileStats: parseNumstat(strings.Split(strings.TrimRight(output, "\n"), "\n"))}
+	for _, file := range stats.FileStats {
+		stats.FilesChanged++
+		stats.Insertions += file.Additions
+		stats.Deletions += file.Deletions
+	}
 
-	log.Println("Server started: http://localhost:8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	log.Printf("✅ Diff stat: %d files, +%d -%d", stats.FilesChanged, stats.Insertions, stats.Deletions)
+	return stats, nil
 }
 
-func loadConfig() *Config {
-	data, err := os.ReadFile("config.json")
+// diffModeFlags maps the diff viewer modes to git diff flags. Word modes use the plain [-removed-]{+added+} markers,
+// except color-words which keeps git's ANSI colours; word-regex treats identifiers and single symbols as words.
+var diffModeFlags = map[string]string{
+	"line":        "",
+	"word":        " --word-diff=plain",
+	"word-regex":  " --word-diff=plain --word-diff-regex='[A-Za-z0-9_]+|[^[:space:]]'",
+	"color-words": " --word-diff=color",
+}
+
+func (s *SSHManager) GitDiff(repoPath string, staged bool, ignoreWhitespace bool, diffMode string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🔍 Diff: %s (staged: %v, ignore whitespace: %v, mode: %s)", repoPath, staged, ignoreWhitespace, diffMode)
+
+	if diffMode == "" {
+		diffMode = "line"
+	}
+	modeFlags, ok := diffModeFlags[diffMode]
+	if !ok {
+		return "", fmt.Errorf("invalid diff mode %q (allowed: line, word, word-regex, color-words)", diffMode)
+	}
+
+	command := "git diff --no-color" + modeFlags
+	if staged {
+		command += " --cached"
+	}
+	if ignoreWhitespace {
+		command += " --ignore-all-space"
+	}
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
 	if err != nil {
-		// Default config
-		return &Config{
-			SSHHost:      "",
-			SSHPort:      "22",
-			SSHUser:      "root",
-			SSHKeyPath:   "",
-			SSHPassword:  "",
-			AuthMethod:   "password",
-			WorkingDir:   "/root/projects",
-			GitHubToken:  "",
-			IsConfigured: false,
-		}
+		log.Printf("❌ Diff failed: %v", err)
+	} else {
+		log.Printf("✅ Diff successful")
 	}
+	return result, err
+}
 
-	var cfg Config
-	json.Unmarshal(data, &cfg)
-	return &cfg
+// GitDiffStaged shows only what is in the index, i.e. what the next commit will contain.
+func (s *SSHManager) GitDiffStaged(repoPath string, ignoreWhitespace bool, diffMode string) (string, error) {
+	return s.GitDiff(repoPath, true, ignoreWhitespace, diffMode)
 }
 
-func saveConfig(cfg *Config) error {
-	data, err := json.MarshalIndent(cfg, "", "  ")
+// BackupToS3 streams a gzipped git archive of ref from the server straight into the configured S3 bucket.
+func (s *SSHManager) BackupToS3(repoPath, ref string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("☁️ S3 backup: %s (ref: %s)", repoPath, ref)
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if s.config.ArchiveS3Bucket == "" {
+		return "", fmt.Errorf("S3 bucket is not configured")
+	}
+	if s.client == nil {
+		return "", fmt.Errorf("SSH connection not established")
+	}
+
+	// Resolve first: a failing git archive would otherwise still upload an empty gzip stream
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git rev-parse --verify "+shellQuote(ref+"^{commit}")))
 	if err != nil {
-		return err
+		return "", fmt.Errorf("invalid ref %s: %s", ref, strings.TrimSpace(output))
 	}
-	return os.WriteFile("config.json", data, 0644)
+	commit := strings.TrimSpace(output)
+
+	session, err := s.client.NewSession()
+	if err != nil {
+		log.Printf("❌ Session creation failed: %v", err)
+		return "", err
+	}
+	defer session.Close()
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+
+	name := filepath.Base(repoPath)
+	command := s.repoCommand(repoPath, fmt.Sprintf("git archive --format=tar --prefix=%s %s | gzip", shellQuote(name+"/"), commit))
+	log.Printf("📋 SSH Command: %s", command)
+	if err := session.Start(command); err != nil {
+		return "", err
+	}
+
+	bucket := s.config.ArchiveS3Bucket
+	key := fmt.Sprintf("%s/%s-%s-%s.tar.gz", name, name, commit[:12], time.Now().UTC().Format("20060102-150405"))
+	client := s.newS3Client()
+
+	_, err = manager.NewUploader(client).Upload(context.Background(), &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        stdout,
+		ContentType: aws.String("application/gzip"),
+	})
+	if err != nil {
+		log.Printf("❌ S3 upload failed: %v", err)
+		return "", fmt.Errorf("S3 upload failed: %v", err)
+	}
+
+	if err := session.Wait(); err != nil {
+		log.Printf("❌ Archive failed: %v", err)
+		// Don't leave a truncated backup behind
+		client.DeleteObject(context.Background(), &s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	location := fmt.Sprintf("s3://%s/%s", bucket, key)
+	log.Printf("✅ S3 backup uploaded: %s", location)
+	return location, nil
 }
 
-func indexHandler(w http.ResponseWriter, r *http.Request) {
-	// Redirect to setup if not configured
-	if !config.IsConfigured {
-		http.Redirect(w, r, "/setup", http.StatusFound)
-		return
+func (s *SSHManager) newS3Client() *s3.Client {
+	region := s.config.ArchiveS3Region
+	if region == "" {
+		region = "us-east-1"
 	}
 
-	tmpl := `<!DOCTYPE html>
+	return s3.New(s3.Options{
+		Region:      region,
+		Credentials: credentials.NewStaticCredentialsProvider(s.config.ArchiveS3KeyID, s.config.ArchiveS3SecretKey, ""),
+	}, func(o *s3.Options) {
+		if s.config.ArchiveS3Endpoint != "" {
+			o.BaseEndpoint = aws.String(s.config.ArchiveS3Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+}
+
+// TestCredential asks the server's git credential helpers for a matching credential.
+// The secret itself never leaves the server; only whether one exists is reported.
+func (s *SSHManager) TestCredential(host, protocol, username string) (*CredentialResult, error) {
+	log.Printf("🔐 Credential test: %s://%s (user: %s)", protocol, host, username)
+
+	if host == "" {
+		return nil, fmt.Errorf("host is required")
+	}
+	if protocol == "" {
+		protocol = "https"
+	}
+	for _, value := range []string{host, protocol, username} {
+		if strings.ContainsAny(value, "\n\x00") {
+			return nil, fmt.Errorf("invalid credential attribute %q", value)
+		}
+	}
+
+	lines := []string{shellQuote("protocol=" + protocol), shellQuote("host=" + host)}
+	if username != "" {
+		lines = append(lines, shellQuote("username="+username))
+	}
+
+	// Never fall back to an interactive prompt on the server
+	command := fmt.Sprintf("printf '%%s\\n' %s '' | GIT_TERMINAL_PROMPT=0 GIT_ASKPASS=true git credential fill", strings.Join(lines, " "))
+	output, err := s.ExecuteCommand(command)
+
+	result := &CredentialResult{Protocol: protocol, Host: host, Username: username}
+	if err != nil {
+		if _, ok := exitStatus(err); ok {
+			log.Printf("🔐 No stored credential for %s", host)
+			return result, nil
+		}
+		return nil, err
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), "=")
+		switch key {
+		case "username":
+			result.Username = value
+		case "password":
+			result.Found = value != ""
+		}
+	}
+
+	log.Printf("✅ Credential test: %s found=%v", host, result.Found)
+	return result, nil
+}
+
+func (s *SSHManager) CurrentBranch(repoPath string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git rev-parse --abbrev-ref HEAD"))
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+	return strings.TrimSpace(output), nil
+}
+
+func (s *SSHManager) ListBranches(repoPath string) ([]BranchInfo, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🌿 Branches: %s", repoPath)
+
+	// Local branches first, then remote-tracking ones; the refname goes last as it may contain the separator
+	branches := []BranchInfo{}
+	for _, namespace := range []string{"refs/heads/", "refs/remotes/"} {
+		refs, err := s.ForEachRef(repoPath, namespace, "%(HEAD)|%(refname)", "refname")
+		if err != nil {
+			log.Printf("❌ Branch listing failed: %v", err)
+			return nil, err
+		}
+
+		for _, ref := range refs {
+			// Skip symbolic refs such as origin/HEAD
+			name := strings.TrimRight(ref["refname"], "\r")
+			if strings.HasSuffix(name, "/HEAD") {
+				continue
+			}
+
+			branches = append(branches, BranchInfo{
+				Name:      strings.TrimPrefix(name, namespace),
+				IsCurrent: ref["HEAD"] == "*",
+				IsRemote:  namespace == "refs/remotes/",
+			})
+		}
+	}
+
+	log.Printf("✅ Total %d branches found", len(branches))
+	return branches, nil
+}
+
+// GitCheckout switches to branch, creating a tracking branch when it only exists on origin.
+func (s *SSHManager) GitCheckout(repoPath, branch string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🔀 Checkout: %s (%s)", repoPath, branch)
+
+	if err := git.ValidateRefName(branch); err != nil {
+		return "", fmt.Errorf("invalid branch name: %v", err)
+	}
+
+	command := "git checkout " + shellQuote(branch) + " --"
+	if _, err := s.ExecuteCommand(s.repoCommand(repoPath, "git show-ref --verify --quiet "+shellQuote("refs/heads/"+branch))); err != nil {
+		if _, err := s.ExecuteCommand(s.repoCommand(repoPath, "git show-ref --verify --quiet "+shellQuote("refs/remotes/origin/"+branch))); err != nil {
+			return "", fmt.Errorf("branch %s exists neither locally nor on origin", branch)
+		}
+		command = fmt.Sprintf("git checkout -b %s --track %s", shellQuote(branch), shellQuote("origin/"+branch))
+	}
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Checkout failed: %v", err)
+	} else {
+		log.Printf("✅ Checkout successful")
+	}
+	return result, err
+}
+
+func (s *SSHManager) CreateBranch(repoPath, name string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🌱 Create branch: %s (%s)", repoPath, name)
+
+	if err := git.ValidateRefName(name); err != nil {
+		return "", fmt.Errorf("invalid branch name: %v", err)
+	}
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, "git branch "+shellQuote(name)))
+	if err != nil {
+		log.Printf("❌ Create branch failed: %v", err)
+	} else {
+		log.Printf("✅ Branch created")
+	}
+	return result, err
+}
+
+func (s *SSHManager) DeleteBranch(repoPath, name string, force bool) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🪓 Delete branch: %s (%s, force: %v)", repoPath, name, force)
+
+	if err := git.ValidateRefName(name); err != nil {
+		return "", fmt.Errorf("invalid branch name: %v", err)
+	}
+
+	current, err := s.CurrentBranch(repoPath)
+	if err != nil {
+		return "", err
+	}
+	if current == name {
+		return "", fmt.Errorf("branch %s is checked out and cannot be deleted", name)
+	}
+
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, fmt.Sprintf("git branch %s %s", flag, shellQuote(name))))
+	if err != nil {
+		log.Printf("❌ Delete branch failed: %v", err)
+	} else {
+		log.Printf("✅ Branch deleted")
+	}
+	return result, err
+}
+
+// maxPackObjects caps how many objects a single pack-objects request may include.
+const maxPackObjects = 1000
+
+// resolvePackOutputPath resolves a relative pack output path against the repository and rejects paths outside the
+// working directory, so packs cannot be written over arbitrary files of the server.
+func (s *SSHManager) resolvePackOutputPath(repoPath, outputPath string) (string, error) {
+	outputPath = strings.Replace(outputPath, "\\", "/", -1)
+	if outputPath == "" {
+		return "", fmt.Errorf("output path is required")
+	}
+	if !path.IsAbs(outputPath) {
+		outputPath = path.Join(repoPath, outputPath)
+	}
+	resolved, err := s.resolveWorkingDirPath(outputPath)
+	if err != nil {
+		return "", fmt.Errorf("output path %s: %w", outputPath, err)
+	}
+	return resolved, nil
+}
+
+// PackObjects writes the given objects into <outputPath>-<pack hash>.pack/.idx and returns the pack hash.
+// A relative outputPath is taken relative to the repository.
+func (s *SSHManager) PackObjects(repoPath string, hashes []string, outputPath string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("📦 Pack objects: %s (%d objects) -> %s", repoPath, len(hashes), outputPath)
+
+	outputPath, err := s.resolvePackOutputPath(repoPath, outputPath)
+	if err != nil {
+		return "", err
+	}
+	if len(hashes) == 0 {
+		return "", fmt.Errorf("at least one object hash is required")
+	}
+	if len(hashes) > maxPackObjects {
+		return "", fmt.Errorf("too many objects: %d (limit %d)", len(hashes), maxPackObjects)
+	}
+	for _, hash := range hashes {
+		if !isObjectHash(hash) {
+			return "", fmt.Errorf("invalid object hash %q", hash)
+		}
+	}
+
+	input := strings.NewReader(strings.Join(hashes, "\n") + "\n")
+	command := s.repoCommand(repoPath, "git pack-objects "+shellQuote(outputPath))
+	output, err := s.ExecuteCommandWithInput(command, input)
+	if err != nil {
+		log.Printf("❌ Pack objects failed: %v", err)
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	// Progress goes to stderr; the pack hash is the last line of output
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	packHash := strings.TrimSpace(lines[len(lines)-1])
+	log.Printf("✅ Pack written: %s-%s.pack", outputPath, packHash)
+	return packHash, nil
+}
+
+// GitListTree lists the entries of a directory at ref, directories first.
+func (s *SSHManager) GitListTree(repoPath, ref, path string) ([]TreeEntry, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	path = strings.Trim(path, "/")
+	log.Printf("🌳 List tree: %s (%s:%s)", repoPath, ref, path)
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+	treeish := ref
+	if path != "" {
+		treeish = ref + ":" + path
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git ls-tree -l -z "+shellQuote(treeish)))
+	if err != nil {
+		log.Printf("❌ List tree failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	entries := []TreeEntry{}
+	for _, record := range strings.Split(output, "\x00") {
+		// <mode> SP <type> SP <object> SP+ <size> TAB <name>
+		meta, name, found := strings.Cut(record, "\t")
+		fields := strings.Fields(meta)
+		if !found || len(fields) != 4 {
+			continue
+		}
+
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			size = -1
+		}
+		entryPath := name
+		if path != "" {
+			entryPath = path + "/" + name
+		}
+		entries = append(entries, TreeEntry{Mode: fields[0], Type: fields[1], Hash: fields[2], Size: size, Name: name, Path: entryPath})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if (entries[i].Type == "tree") != (entries[j].Type == "tree") {
+			return entries[i].Type == "tree"
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+// maxCatFileSize limits how much of a single file CatFile will transfer.
+const maxCatFileSize = 1 << 20
+
+func (s *SSHManager) CatFile(repoPath, ref, path string) ([]byte, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	path = strings.Trim(path, "/")
+	log.Printf("📄 Cat file: %s (%s:%s)", repoPath, ref, path)
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+	object := shellQuote(ref + ":" + path)
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git cat-file -s "+object))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected object size %q", strings.TrimSpace(output))
+	}
+	if size > maxCatFileSize {
+		return nil, fmt.Errorf("file is too large to display (%d bytes, limit %d)", size, maxCatFileSize)
+	}
+
+	output, err = s.ExecuteCommand(s.repoCommand(repoPath, "git cat-file blob "+object))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+	return []byte(output), nil
+}
+
+func (s *SSHManager) ListTrackedFiles(repoPath string, showModified, showDeleted, showOthers bool) ([]TrackedFile, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("📋 List files: %s (modified: %v, deleted: %v, others: %v)", repoPath, showModified, showDeleted, showOthers)
+
+	run := func(command string) ([]string, error) {
+		output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+		if err != nil {
+			return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+		}
+		var fields []string
+		for _, field := range strings.Split(output, "\x00") {
+			if field != "" {
+				fields = append(fields, field)
+			}
+		}
+		return fields, nil
+	}
+
+	// index status per staged path, from name-status pairs
+	stagedFields, err := run("git diff --cached --name-status -z")
+	if err != nil {
+		return nil, err
+	}
+	staged := make(map[string]string)
+	for i := 0; i+1 < len(stagedFields); i += 2 {
+		status := stagedFields[i][:1]
+		if status == "R" || status == "C" {
+			// Renames and copies list the old and the new path
+			if i+2 < len(stagedFields) {
+				i++
+			}
+		}
+		staged[stagedFields[i+1]] = status
+	}
+
+	files := []TrackedFile{}
+	seen := make(map[string]bool)
+	add := func(path, status string) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		_, isStaged := staged[path]
+		files = append(files, TrackedFile{Path: path, Status: status, Staged: isStaged})
+	}
+
+	// Deleted files are also reported by -m, so they are collected first
+	if showDeleted || showModified {
+		deleted, err := run("git ls-files -z -d --exclude-standard")
+		if err != nil {
+			return nil, err
+		}
+		if showDeleted {
+			for _, path := range deleted {
+				add(path, "D")
+			}
+		} else {
+			for _, path := range deleted {
+				seen[path] = true
+			}
+		}
+	}
+
+	if showModified {
+		modified, err := run("git ls-files -z -m --exclude-standard")
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range modified {
+			add(path, "M")
+		}
+
+		// Changes that are fully staged no longer differ from the working tree
+		stagedPaths := make([]string, 0, len(staged))
+		for path := range staged {
+			stagedPaths = append(stagedPaths, path)
+		}
+		sort.Strings(stagedPaths)
+		for _, path := range stagedPaths {
+			if staged[path] != "D" || showDeleted {
+				add(path, staged[path])
+			}
+		}
+	}
+
+	if showOthers {
+		others, err := run("git ls-files -z -o --exclude-standard")
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range others {
+			add(path, "?")
+		}
+	}
+
+	log.Printf("✅ List files: %d entries", len(files))
+	return files, nil
+}
+
+// AssumeUnchanged sets or clears the assume-unchanged bit, which hides local edits of a tracked file from git status.
+func (s *SSHManager) AssumeUnchanged(repoPath, filePath string, assume bool) error {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return err
+	}
+	log.Printf("🙈 Assume unchanged: %s (%s: %v)", repoPath, filePath, assume)
+
+	if filePath == "" {
+		return fmt.Errorf("file path is required")
+	}
+
+	flag := "--no-assume-unchanged"
+	if assume {
+		flag = "--assume-unchanged"
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, fmt.Sprintf("git update-index %s -- %s", flag, shellQuote(filePath))))
+	if err != nil {
+		log.Printf("❌ Assume unchanged failed: %v", err)
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	log.Printf("✅ Assume unchanged updated")
+	return nil
+}
+
+// ListAssumedUnchanged lists files with the assume-unchanged bit, which git ls-files -v reports with a lowercase tag such as "h".
+func (s *SSHManager) ListAssumedUnchanged(repoPath string) ([]string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🙈 List assumed unchanged: %s", repoPath)
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git ls-files -v -z"))
+	if err != nil {
+		log.Printf("❌ List assumed unchanged failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	files := make([]string, 0)
+	for _, entry := range strings.Split(output, "\x00") {
+		if len(entry) > 2 && entry[0] >= 'a' && entry[0] <= 'z' && entry[1] == ' ' {
+			files = append(files, entry[2:])
+		}
+	}
+
+	log.Printf("✅ List assumed unchanged: %d files", len(files))
+	return files, nil
+}
+
+func (s *SSHManager) HasChanges(repoPath string) (bool, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return false, err
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git status --porcelain"))
+	if err != nil {
+		return false, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+	return strings.TrimSpace(output) != "", nil
+}
+
+func (s *SSHManager) RemoveProject(repoPath string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	s.logger.Info("🗑️ Project removing", Fields{"repo_path": repoPath})
+	gitOperationsTotal.WithLabelValues("remove").Inc()
+
+	if path.Clean(repoPath) == path.Clean(strings.Replace(s.config.WorkingDir, "\\", "/", -1)) {
+		return "", &ErrInvalidPath{Path: repoPath, Reason: "the working directory itself cannot be removed"}
+	}
+
+	// First check if directory exists
+	checkCmd := fmt.Sprintf("test -d %s && echo 'exists' || echo 'not exists'", shellQuote(repoPath))
+	checkResult, _ := s.ExecuteCommand(checkCmd)
+	s.logger.Info("📁 Directory existence", Fields{"repo_path": repoPath, "result": strings.TrimSpace(checkResult)})
+
+	// Remove directory
+	command := fmt.Sprintf("rm -rf %s", shellQuote(repoPath))
+	result, err := s.ExecuteCommand(command)
+
+	// Confirm deletion
+	confirmCmd := fmt.Sprintf("test -d %s && echo 'still exists' || echo 'deleted'", shellQuote(repoPath))
+	confirmResult, _ := s.ExecuteCommand(confirmCmd)
+	s.logger.Info("🔍 Removal result", Fields{"repo_path": repoPath, "result": strings.TrimSpace(confirmResult)})
+
+	if err != nil {
+		s.logger.Error("❌ Remove failed", Fields{"repo_path": repoPath, "error": err})
+	} else {
+		s.logger.Info("✅ Remove successful", Fields{"repo_path": repoPath})
+	}
+
+	return fmt.Sprintf("Command: %s\nResult: %s\nConfirm: %s", command, result, confirmResult), err
+}
+
+// stripURLCredentials removes the user info, such as a token, from an HTTPS remote URL.
+func stripURLCredentials(remoteURL string) string {
+	parsed, err := url.Parse(remoteURL)
+	if err != nil || parsed.Scheme != "https" || parsed.User == nil {
+		return remoteURL
+	}
+	parsed.User = nil
+	return parsed.String()
+}
+
+func (s *SSHManager) ListWorktrees(repoPath string) ([]WorktreeInfo, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🌳 Worktrees: %s", repoPath)
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git worktree list --porcelain"))
+	if err != nil {
+		log.Printf("❌ Worktree listing failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	// One block per worktree, separated by blank lines, each line "<attribute> [<value>]"
+	worktrees := []WorktreeInfo{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(strings.TrimRight(line, "\r"), " ")
+		if key == "worktree" {
+			worktrees = append(worktrees, WorktreeInfo{Path: value})
+			continue
+		}
+		if len(worktrees) == 0 {
+			continue
+		}
+
+		current := &worktrees[len(worktrees)-1]
+		switch key {
+		case "HEAD":
+			current.Head = value
+		case "branch":
+			current.Branch = strings.TrimPrefix(value, "refs/heads/")
+		case "bare":
+			current.Bare = true
+		case "detached":
+			current.Detached = true
+		case "locked":
+			current.Locked = true
+			current.LockReason = value
+		case "prunable":
+			current.Prunable = true
+		}
+	}
+
+	log.Printf("✅ Total %d worktrees found", len(worktrees))
+	return worktrees, nil
+}
+
+func (s *SSHManager) LockWorktree(repoPath, worktreePath, reason string) error {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return err
+	}
+	worktreePath = strings.Replace(worktreePath, "\\", "/", -1)
+	log.Printf("🔒 Worktree lock: %s (%s)", worktreePath, reason)
+
+	if worktreePath == "" {
+		return fmt.Errorf("worktree path is required")
+	}
+
+	command := "git worktree lock"
+	if reason != "" {
+		command += " --reason=" + shellQuote(reason)
+	}
+	command += " -- " + shellQuote(worktreePath)
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Worktree lock failed: %v", err)
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	log.Printf("✅ Worktree locked")
+	return nil
+}
+
+func (s *SSHManager) UnlockWorktree(repoPath, worktreePath string) error {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return err
+	}
+	worktreePath = strings.Replace(worktreePath, "\\", "/", -1)
+	log.Printf("🔓 Worktree unlock: %s", worktreePath)
+
+	if worktreePath == "" {
+		return fmt.Errorf("worktree path is required")
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git worktree unlock -- "+shellQuote(worktreePath)))
+	if err != nil {
+		log.Printf("❌ Worktree unlock failed: %v", err)
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	log.Printf("✅ Worktree unlocked")
+	return nil
+}
+
+// redactURL hides credentials embedded in a remote URL, such as a token written by older versions.
+func redactURL(remoteURL string) string {
+	parsed, err := url.Parse(remoteURL)
+	if err != nil || parsed.User == nil {
+		return remoteURL
+	}
+	parsed.User = url.User("***")
+	return parsed.String()
+}
+
+func (s *SSHManager) ListRemotes(repoPath string) ([]RemoteInfo, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🌐 Remotes: %s", repoPath)
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git remote -v"))
+	if err != nil {
+		log.Printf("❌ Remote listing failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	remotes := []RemoteInfo{}
+	index := make(map[string]int)
+	for _, line := range strings.Split(output, "\n") {
+		// <name>\t<url> (fetch|push)
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+
+		i, ok := index[fields[0]]
+		if !ok {
+			i = len(remotes)
+			index[fields[0]] = i
+			remotes = append(remotes, RemoteInfo{Name: fields[0]})
+		}
+		if fields[2] == "(push)" {
+			remotes[i].PushURL = redactURL(fields[1])
+		} else {
+			remotes[i].FetchURL = redactURL(fields[1])
+		}
+	}
+	return remotes, nil
+}
+
+func (s *SSHManager) RenameRemote(repoPath, oldName, newName string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("✏️ Remote rename: %s (%s -> %s)", repoPath, oldName, newName)
+
+	if oldName == "" || newName == "" {
+		return "", fmt.Errorf("old and new remote names are required")
+	}
+	if err := git.ValidateRefName(newName); err != nil {
+		return "", fmt.Errorf("invalid remote name: %v", err)
+	}
+
+	command := fmt.Sprintf("git remote rename %s %s", shellQuote(oldName), shellQuote(newName))
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Remote rename failed: %v", err)
+	} else {
+		log.Printf("✅ Remote rename successful")
+	}
+	return result, err
+}
+
+func (s *SSHManager) ListProcesses() ([]ProcessInfo, error) {
+	log.Printf("⚙️ Listing processes")
+
+	output, err := s.ExecuteCommand("ps aux --no-headers")
+	if err != nil {
+		log.Printf("❌ Process listing failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	processes := []ProcessInfo{}
+	for _, line := range strings.Split(output, "\n") {
+		// USER PID %CPU %MEM VSZ RSS TTY STAT START TIME COMMAND...
+		parts := strings.Fields(line)
+		if len(parts) < 11 {
+			continue
+		}
+
+		pid, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+		cpu, _ := strconv.ParseFloat(parts[2], 64)
+		mem, _ := strconv.ParseFloat(parts[3], 64)
+
+		processes = append(processes, ProcessInfo{
+			PID:     pid,
+			User:    parts[0],
+			CPU:     cpu,
+			Mem:     mem,
+			Command: strings.Join(parts[10:], " "),
+		})
+	}
+
+	log.Printf("✅ Total %d processes found", len(processes))
+	return processes, nil
+}
+
+// allowedSignals are the signal names accepted by KillProcess.
+var allowedSignals = map[string]bool{
+	"TERM": true, "KILL": true, "HUP": true, "INT": true, "QUIT": true,
+	"USR1": true, "USR2": true, "STOP": true, "CONT": true,
+}
+
+func (s *SSHManager) KillProcess(pid int, signal string) error {
+	signal = strings.TrimPrefix(strings.ToUpper(signal), "SIG")
+	log.Printf("🔪 Sending SIG%s to process %d", signal, pid)
+
+	if pid <= 1 {
+		return fmt.Errorf("invalid pid %d", pid)
+	}
+	if !allowedSignals[signal] {
+		return fmt.Errorf("unsupported signal %q", signal)
+	}
+
+	output, err := s.ExecuteCommand(fmt.Sprintf("kill -%s %d", signal, pid))
+	if err != nil {
+		log.Printf("❌ Kill failed: %v", err)
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	log.Printf("✅ Signal sent")
+	return nil
+}
+
+func (s *SSHManager) CreateGist(ctx context.Context, description string, files map[string]string, public bool) (*GistInfo, error) {
+	log.Printf("🔗 Creating gist: %s (%d files, public: %v)", description, len(files), public)
+
+	token := githubTokens.Token()
+	if token == "" {
+		return nil, fmt.Errorf("no usable GitHub token is configured")
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("at least one file is required")
+	}
+
+	gistFiles := make(map[string]map[string]string)
+	for name, content := range files {
+		gistFiles[name] = map[string]string{"content": content}
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"description": description,
+		"public":      public,
+		"files":       gistFiles,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.github.com/gists", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("❌ Gist request failed: %v", err)
+		return nil, fmt.Errorf("gist request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	githubTokens.Observe(token, resp.Header)
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		log.Printf("❌ Gist creation failed: %s", resp.Status)
+		return nil, fmt.Errorf("GitHub API returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var created struct {
+		ID          string    `json:"id"`
+		HTMLURL     string    `json:"html_url"`
+		Description string    `json:"description"`
+		Public      bool      `json:"public"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("gist response parse failed: %v", err)
+	}
+
+	log.Printf("✅ Gist created: %s", created.HTMLURL)
+	return &GistInfo{
+		ID:          created.ID,
+		URL:         created.HTMLURL,
+		Description: created.Description,
+		Public:      created.Public,
+		CreatedAt:   created.CreatedAt,
+	}, nil
+}
+
+func (s *SSHManager) Disconnect() {
+	if s.client != nil {
+		s.client.Close()
+	}
+}
+
+// repoCommand builds a shell command that runs inside the given repository.
+// The project's git identity, when configured, is exported for every command in the chain.
+func (s *SSHManager) repoCommand(repoPath, command string) string {
+	prefix := fmt.Sprintf("cd %s && ", shellQuote(repoPath))
+	if identity := projectMetaStore.Get(repoPath).identityEnv(); identity != "" {
+		prefix += identity + " && "
+	}
+	return prefix + command
+}
+
+func (s *SSHManager) FindOrphanedRepos(ctx context.Context) ([]string, error) {
+	log.Printf("🔍 Searching for orphaned repositories")
+
+	projects, err := s.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []string
+	for _, project := range projects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		orphaned, err := s.isOrphanedRepo(project.Path)
+		if err != nil {
+			return nil, err
+		}
+		if orphaned {
+			log.Printf("👻 Orphaned repository: %s", project.Path)
+			orphans = append(orphans, project.Path)
+		}
+	}
+
+	log.Printf("✅ Total %d orphaned repositories found", len(orphans))
+	return orphans, nil
+}
+
+// isOrphanedRepo reports whether git refuses the repository and its .git/HEAD is gone as well.
+// Transport errors are returned instead, so a dropped connection never marks a repository for removal,
+// and neither does a repository git only refuses for other reasons, such as dubious ownership.
+func (s *SSHManager) isOrphanedRepo(repoPath string) (bool, error) {
+	var exitErr *ssh.ExitError
+
+	_, err := s.ExecuteCommand(s.repoCommand(repoPath, "git rev-parse --git-dir"))
+	if err == nil {
+		return false, nil
+	}
+	if !errors.As(err, &exitErr) {
+		return false, err
+	}
+
+	_, err = s.ExecuteCommand("test -e " + shellQuote(repoPath+"/.git/HEAD"))
+	if err == nil {
+		return false, nil
+	}
+	if !errors.As(err, &exitErr) {
+		return false, err
+	}
+	return true, nil
+}
+
+// ShowCommits returns the given commits with their file stats in the order requested, without walking their history.
+func (s *SSHManager) ShowCommits(repoPath string, hashes []string) ([]CommitInfo, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("📜 Show commits: %s (%d hashes)", repoPath, len(hashes))
+
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("at least one commit hash is required")
+	}
+	args := []string{"git log --no-walk=unsorted --numstat", fmt.Sprintf("--pretty=format:'%s'", commitLogFormat)}
+	for _, hash := range hashes {
+		if !isAbbrevHash(hash) {
+			return nil, fmt.Errorf("invalid commit hash %q", hash)
+		}
+		args = append(args, hash)
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, strings.Join(args, " ")))
+	if err != nil {
+		log.Printf("❌ Show commits failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	commits := parseCommitLog(output)
+	for i := range commits {
+		commits[i].Stats = parseNumstat(commits[i].Files)
+		commits[i].Files = nil
+	}
+
+	log.Printf("✅ Show commits: %d commits", len(commits))
+	return commits, nil
+}
+
+func (s *SSHManager) GitLog(repoPath string, limit int, opts GitLogOptions) ([]CommitInfo, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("📜 Log: %s (limit: %d, options: %+v)", repoPath, limit, opts)
+
+	if limit <= 0 {
+		limit = 25
+	}
+	if opts.IncludePatch && limit > maxPatchCommits {
+		limit = maxPatchCommits
+	}
+
+	// Patches are free-form, so with them every commit starts a NUL separated record
+	format := commitLogFormat
+	if opts.AllBranches {
+		format = "%S%x1f" + format
+	}
+	if opts.IncludePatch {
+		format = "%x00" + format
+	}
+
+	args := []string{"git log", fmt.Sprintf("-n %d", limit), fmt.Sprintf("--pretty=format:'%s'", format)}
+	if opts.AllBranches {
+		args = append(args, "--all --source")
+	}
+	if opts.IncludeStats {
+		args = append(args, "--numstat")
+	}
+	if opts.IncludePatch {
+		args = append(args, "-p")
+	}
+	filterArgs, err := logFilterArgs(opts)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, filterArgs...)
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, strings.Join(args, " ")))
+	if err != nil && strings.Contains(output, "does not have any commits yet") {
+		// A freshly initialised repository has no history rather than a broken one
+		log.Printf("ℹ️ Log: %s has no commits yet", repoPath)
+		return []CommitInfo{}, nil
+	}
+	if err != nil {
+		log.Printf("❌ Log failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	var commits []CommitInfo
+	switch {
+	case opts.IncludePatch:
+		commits = parseCommitRecords(output, opts.AllBranches)
+	case opts.AllBranches:
+		commits = parseSourcedCommitLog(output)
+	default:
+		commits = parseCommitLog(output)
+	}
+	if opts.IncludeStats {
+		for i := range commits {
+			commits[i].Stats = parseNumstat(commits[i].Files)
+			commits[i].Files = nil
+		}
+	}
+	log.Printf("✅ Log: %d commits", len(commits))
+	return commits, nil
+}
+
+// logFilterArgs turns the ordering and filtering options shared by the full and compact log into git log arguments.
+func logFilterArgs(opts GitLogOptions) ([]string, error) {
+	var args []string
+
+	switch opts.SortOrder {
+	case "":
+	case "date":
+		args = append(args, "--date-order")
+	case "topo":
+		args = append(args, "--topo-order")
+	case "author-date":
+		args = append(args, "--author-date-order")
+	default:
+		return nil, fmt.Errorf("invalid sort order %q (allowed: date, topo, author-date)", opts.SortOrder)
+	}
+	if opts.FirstParentOnly {
+		args = append(args, "--first-parent")
+	}
+
+	switch opts.Filter {
+	case "":
+	case "merges":
+		args = append(args, "--merges")
+	case "no-merges":
+		args = append(args, "--no-merges")
+	default:
+		return nil, fmt.Errorf("invalid log filter %q (allowed: merges, no-merges)", opts.Filter)
+	}
+
+	if opts.Grep != "" {
+		args = append(args, "-i --grep="+shellQuote(opts.Grep))
+	}
+	if opts.AuthorFilter != "" {
+		args = append(args, "--author="+shellQuote(opts.AuthorFilter))
+	}
+	if opts.CommitterFilter != "" {
+		args = append(args, "--committer="+shellQuote(opts.CommitterFilter))
+	}
+	if !opts.Before.IsZero() {
+		args = append(args, "--before="+shellQuote(opts.Before.Format(time.RFC3339)))
+	}
+	if !opts.After.IsZero() {
+		args = append(args, "--after="+shellQuote(opts.After.Format(time.RFC3339)))
+	}
+	if opts.Revision != "" {
+		if strings.HasPrefix(opts.Revision, "-") {
+			return nil, fmt.Errorf("invalid revision %q", opts.Revision)
+		}
+		args = append(args, shellQuote(opts.Revision))
+	}
+	if opts.AncestryPath && opts.From == "" {
+		return nil, fmt.Errorf("ancestry path requires a from revision")
+	}
+	if opts.From != "" || opts.To != "" {
+		if opts.Revision != "" {
+			return nil, fmt.Errorf("a revision cannot be combined with from and to")
+		}
+		if opts.From == "" {
+			return nil, fmt.Errorf("to requires a from revision")
+		}
+		to := opts.To
+		if to == "" {
+			to = "HEAD"
+		}
+		if strings.HasPrefix(opts.From, "-") || strings.HasPrefix(to, "-") {
+			return nil, fmt.Errorf("invalid revision range %q", opts.From+".."+to)
+		}
+		if opts.AncestryPath {
+			args = append(args, "--ancestry-path")
+		}
+		args = append(args, shellQuote(opts.From+".."+to))
+	}
+	// The pathspec separator must come after every option
+	if opts.Path != "" {
+		args = append(args, "-- "+shellQuote(opts.Path))
+	}
+	return args, nil
+}
+
+// GitLogCompact lists commits as abbreviated hash and subject only, which keeps very long histories cheap to load.
+func (s *SSHManager) GitLogCompact(repoPath string, limit int, opts GitLogOptions) ([]CompactCommit, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("📜 Compact log: %s (limit: %d, options: %+v)", repoPath, limit, opts)
+
+	if limit <= 0 {
+		limit = 25
+	}
+
+	args := []string{"git log", fmt.Sprintf("-n %d", limit), "--oneline --no-decorate"}
+	if opts.AllBranches {
+		args = append(args, "--all")
+	}
+	filterArgs, err := logFilterArgs(opts)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, filterArgs...)
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, strings.Join(args, " ")))
+	if err != nil && strings.Contains(output, "does not have any commits yet") {
+		log.Printf("ℹ️ Compact log: %s has no commits yet", repoPath)
+		return []CompactCommit{}, nil
+	}
+	if err != nil {
+		log.Printf("❌ Compact log failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	commits := []CompactCommit{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		hash, message, _ := strings.Cut(line, " ")
+		commits = append(commits, CompactCommit{Hash: hash, Message: message})
+	}
+	log.Printf("✅ Compact log: %d commits", len(commits))
+	return commits, nil
+}
+
+// CountCommits counts the commits in a revision range such as origin/main..HEAD without listing them.
+func (s *SSHManager) CountCommits(repoPath, range_ string) (int, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return 0, err
+	}
+	log.Printf("🔢 Count commits: %s (range: %s)", repoPath, range_)
+
+	if range_ == "" {
+		return 0, fmt.Errorf("revision range is required")
+	}
+	if strings.HasPrefix(range_, "-") {
+		return 0, fmt.Errorf("invalid revision range %q", range_)
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git rev-list --count "+shellQuote(range_)))
+	if err != nil {
+		log.Printf("❌ Count commits failed: %v", err)
+		return 0, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(output))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected rev-list output %q", strings.TrimSpace(output))
+	}
+	return count, nil
+}
+
+// LogSinceCommit lists the commits reachable from HEAD but not from sinceHash, e.g. the commits a push would send.
+func (s *SSHManager) LogSinceCommit(repoPath, sinceHash string, limit int) ([]CommitInfo, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("📜 Log since %s: %s (limit: %d)", sinceHash, repoPath, limit)
+
+	if sinceHash == "" || strings.HasPrefix(sinceHash, "-") {
+		return nil, fmt.Errorf("invalid since commit %q", sinceHash)
+	}
+	if limit <= 0 {
+		limit = 25
+	}
+
+	command := fmt.Sprintf("git log %s -n %d --pretty=format:'%s'", shellQuote(sinceHash+"..HEAD"), limit, commitLogFormat)
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Log since failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	commits := parseCommitLog(output)
+	log.Printf("✅ Log since %s: %d commits", sinceHash, len(commits))
+	return commits, nil
+}
+
+// ActivityReport counts non-merge commits and distinct authors per day, week or month since the given time.
+func (s *SSHManager) ActivityReport(repoPath string, period string, since time.Time) ([]ActivityBucket, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("📈 Activity: %s (period: %s, since: %s)", repoPath, period, since.Format("2006-01-02"))
+
+	var bucketKey func(t time.Time) string
+	switch period {
+	case "day":
+		bucketKey = func(t time.Time) string { return t.Format("2006-01-02") }
+	case "", "week":
+		bucketKey = func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}
+	case "month":
+		bucketKey = func(t time.Time) string { return t.Format("2006-01") }
+	default:
+		return nil, fmt.Errorf("invalid period %q (allowed: day, week, month)", period)
+	}
+
+	command := "git log --no-merges --format='%aI|%ae'"
+	if !since.IsZero() {
+		command += " --since=" + shellQuote(since.Format(time.RFC3339))
+	}
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Activity failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	buckets := make(map[string]*ActivityBucket)
+	authors := make(map[string]map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		date, email, found := strings.Cut(strings.TrimRight(line, "\r"), "|")
+		if !found {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, date)
+		if err != nil {
+			continue
+		}
+
+		key := bucketKey(t)
+		if buckets[key] == nil {
+			buckets[key] = &ActivityBucket{Period: key}
+			authors[key] = make(map[string]bool)
+		}
+		buckets[key].CommitCount++
+		authors[key][strings.ToLower(email)] = true
+	}
+
+	report := make([]ActivityBucket, 0, len(buckets))
+	for key, bucket := range buckets {
+		bucket.UniqueAuthors = len(authors[key])
+		report = append(report, *bucket)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Period < report[j].Period
+	})
+
+	log.Printf("✅ Activity: %d buckets", len(report))
+	return report, nil
+}
+
+// WalkReflog lists every position ref has held, newest first, including states left behind by resets and rebases.
+func (s *SSHManager) WalkReflog(repoPath, ref string, limit int) ([]ReflogEntry, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🛟 Reflog: %s (ref: %s, limit: %d)", repoPath, ref, limit)
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid ref %q", ref)
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	// With a date format, %gd renders as <ref>@{<date>}, which gives the time of each move
+	command := fmt.Sprintf("git log --walk-reflogs --date=iso-strict -n %d --pretty=format:'%%H%%x1f%%gd%%x1f%%gs%%x1f%%s' %s", limit, shellQuote(ref))
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Reflog failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	entries := []ReflogEntry{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(strings.TrimRight(line, "\r"), "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+
+		date := fields[1]
+		if start := strings.Index(date, "@{"); start != -1 {
+			date = strings.TrimSuffix(date[start+2:], "}")
+		}
+		entries = append(entries, ReflogEntry{
+			Selector: fmt.Sprintf("%s@{%d}", ref, len(entries)),
+			Hash:     fields[0],
+			Date:     date,
+			Action:   fields[2],
+			Subject:  fields[3],
+		})
+	}
+
+	log.Printf("✅ Reflog: %d entries", len(entries))
+	return entries, nil
+}
+
+// resetModes are the git reset modes accepted by GitReset.
+var resetModes = map[string]bool{"soft": true, "mixed": true, "hard": true}
+
+// GitReset moves the current branch to ref (HEAD by default). soft keeps the index and the working tree,
+// mixed keeps only the working tree and hard discards both.
+func (s *SSHManager) GitReset(repoPath, ref, mode string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("↩️ Reset: %s (ref: %s, mode: %s)", repoPath, ref, mode)
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("invalid ref %q", ref)
+	}
+	if !resetModes[mode] {
+		return "", fmt.Errorf("invalid reset mode %q (allowed: soft, mixed, hard)", mode)
+	}
+
+	// -- keeps a ref that also names a file from being read as a path
+	command := fmt.Sprintf("git reset --%s %s --", mode, shellQuote(ref))
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Reset failed: %v", err)
+	} else {
+		log.Printf("✅ Reset successful")
+	}
+	return result, err
+}
+
+// CherryLog lists the commits of rightBranch whose changes are not in leftBranch yet, ignoring commits already cherry-picked.
+func (s *SSHManager) CherryLog(repoPath, leftBranch, rightBranch string) ([]CommitInfo, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🍒 Cherry log: %s (%s...%s)", repoPath, leftBranch, rightBranch)
+
+	for _, branch := range []string{leftBranch, rightBranch} {
+		if branch == "" || strings.HasPrefix(branch, "-") {
+			return nil, fmt.Errorf("invalid branch %q", branch)
+		}
+	}
+
+	command := fmt.Sprintf("git log --cherry-pick --right-only %s --pretty=format:'%s'", shellQuote(leftBranch+"..."+rightBranch), commitLogFormat)
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Cherry log failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	commits := parseCommitLog(output)
+	log.Printf("✅ Cherry log: %d commits", len(commits))
+	return commits, nil
+}
+
+// CherryPick applies commits onto the current branch in the given order.
+// A failed pick is aborted so the working tree is left as it was.
+func (s *SSHManager) CherryPick(repoPath string, commits []string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🍒 Cherry-pick: %s (%d commits)", repoPath, len(commits))
+
+	if len(commits) == 0 {
+		return "", fmt.Errorf("at least one commit is required")
+	}
+	for _, commit := range commits {
+		if !isObjectHash(commit) {
+			return "", fmt.Errorf("invalid commit hash %q", commit)
+		}
+	}
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, "git cherry-pick "+strings.Join(commits, " ")))
+	if err != nil {
+		log.Printf("❌ Cherry-pick failed, aborting: %v", err)
+		if output, abortErr := s.ExecuteCommand(s.repoCommand(repoPath, "git cherry-pick --abort")); abortErr != nil {
+			result += "\n" + output
+		}
+		return result, err
+	}
+
+	log.Printf("✅ Cherry-pick successful")
+	return result, nil
+}
+
+// FixupCommit stages all changes and commits them as a fixup of targetHash, to be squashed by an autosquash rebase.
+func (s *SSHManager) FixupCommit(repoPath, targetHash string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🩹 Fixup commit: %s (target: %s)", repoPath, targetHash)
+
+	if !isObjectHash(targetHash) {
+		return "", fmt.Errorf("invalid commit hash %q", targetHash)
+	}
+
+	command := s.repoCommand(repoPath, "git add . && git commit --fixup="+targetHash)
+	result, err := s.ExecuteCommand(command)
+	if err != nil {
+		log.Printf("❌ Fixup commit failed: %v", err)
+	} else {
+		log.Printf("✅ Fixup commit created")
+	}
+	return result, err
+}
+
+// AutosquashRebase rebases onto base (the upstream by default), folding fixup! and squash! commits into their targets.
+// The todo list is accepted as generated, and a rebase that stops on a conflict is aborted.
+func (s *SSHManager) AutosquashRebase(repoPath, base string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	if base == "" {
+		base = "@{upstream}"
+	}
+	log.Printf("🧹 Autosquash rebase: %s (base: %s)", repoPath, base)
+
+	if strings.HasPrefix(base, "-") {
+		return "", fmt.Errorf("invalid base %q", base)
+	}
+
+	command := "GIT_SEQUENCE_EDITOR=: git rebase -i --autosquash " + shellQuote(base)
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Autosquash rebase failed, aborting: %v", err)
+		if output, abortErr := s.ExecuteCommand(s.repoCommand(repoPath, "git rebase --abort")); abortErr != nil {
+			result += "\n" + output
+		}
+		return result, err
+	}
+
+	log.Printf("✅ Autosquash rebase successful")
+	return result, nil
+}
+
+// SearchCommits greps commit messages in every project, running at most maxConcurrent searches at a time.
+// The returned channel is closed once all projects have been searched or ctx is cancelled.
+func (s *SSHManager) SearchCommits(ctx context.Context, query string, maxConcurrent int, since, until time.Time) (<-chan CommitSearchHit, error) {
+	log.Printf("🔎 Commit search: %s (concurrency: %d)", query, maxConcurrent)
+
+	if query == "" {
+		return nil, fmt.Errorf("search query is required")
+	}
+	if maxConcurrent <= 0 {
+		maxConcurrent = 4
+	}
+
+	projects, err := s.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"git log -i", "--grep=" + shellQuote(query), "--pretty=format:'%H%x1f%an%x1f%aI%x1f%s'"}
+	if !since.IsZero() {
+		args = append(args, "--since="+shellQuote(since.Format(time.RFC3339)))
+	}
+	if !until.IsZero() {
+		args = append(args, "--until="+shellQuote(until.Format(time.RFC3339)))
+	}
+	command := strings.Join(args, " ")
+
+	hits := make(chan CommitSearchHit)
+	go func() {
+		defer close(hits)
+
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, maxConcurrent)
+
+	projectLoop:
+		for _, project := range projects {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				break projectLoop
+			}
+
+			wg.Add(1)
+			go func(project Project) {
+				defer wg.Done()
+				defer func() { <-slots }()
+
+				output, err := s.ExecuteCommand(s.repoCommand(project.Path, command))
+				if err != nil {
+					log.Printf("❌ Commit search failed in %s: %v", project.Name, err)
+					return
+				}
+
+				for _, commit := range parseCommitLog(output) {
+					date, _ := time.Parse(time.RFC3339, commit.Date)
+					hit := CommitSearchHit{
+						Project: project.Name,
+						Hash:    commit.Hash,
+						Author:  commit.Author,
+						Date:    date,
+						Message: commit.Subject,
+					}
+
+					select {
+					case hits <- hit:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}(project)
+		}
+
+		wg.Wait()
+		log.Printf("✅ Commit search finished: %s", query)
+	}()
+
+	return hits, nil
+}
+
+func (s *SSHManager) PickaxeSearch(repoPath, searchString string, regex bool, limit int) ([]CommitInfo, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("⛏️ Pickaxe search: %s (query: %s, regex: %v)", repoPath, searchString, regex)
+
+	if searchString == "" {
+		return nil, fmt.Errorf("search string is required")
+	}
+	if limit <= 0 {
+		limit = 25
+	}
+
+	// -S finds changes in the number of occurrences, -G any diff line matching the regex
+	flag := "-S"
+	if regex {
+		flag = "-G"
+	}
+
+	command := fmt.Sprintf("git log %s %s -n %d --pretty=format:'%s'", flag, shellQuote(searchString), limit, commitLogFormat)
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Pickaxe search failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	commits := parseCommitLog(output)
+	log.Printf("✅ Pickaxe search: %d commits", len(commits))
+	return commits, nil
+}
+
+func (s *SSHManager) MergeBase(repoPath string, commits ...string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🔀 Merge base: %s (commits: %v)", repoPath, commits)
+
+	if len(commits) < 2 {
+		return "", fmt.Errorf("at least two commits are required")
+	}
+
+	args := []string{"git merge-base"}
+	for _, commit := range commits {
+		args = append(args, shellQuote(commit))
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, strings.Join(args, " ")))
+	if err != nil {
+		// Exit status 1 without output means the commits share no history
+		if code, ok := exitStatus(err); ok && code == 1 && strings.TrimSpace(output) == "" {
+			return "", fmt.Errorf("no common ancestor")
+		}
+		log.Printf("❌ Merge base failed: %v", err)
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	base := strings.TrimSpace(output)
+	log.Printf("✅ Merge base: %s", base)
+	return base, nil
+}
+
+func (s *SSHManager) IsAncestor(repoPath, ancestor, descendant string) (bool, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return false, err
+	}
+	log.Printf("🔀 Is ancestor: %s (%s -> %s)", repoPath, ancestor, descendant)
+
+	command := fmt.Sprintf("git merge-base --is-ancestor %s %s", shellQuote(ancestor), shellQuote(descendant))
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err == nil {
+		return true, nil
+	}
+	if code, ok := exitStatus(err); ok && code == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+}
+
+func (s *SSHManager) RangeDiff(repoPath, range1, range2 string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🔁 Range diff: %s (%s vs %s)", repoPath, range1, range2)
+
+	if range1 == "" || range2 == "" {
+		return "", fmt.Errorf("both ranges are required")
+	}
+	for _, r := range []string{range1, range2} {
+		if strings.HasPrefix(r, "-") {
+			return "", fmt.Errorf("invalid range %q", r)
+		}
+	}
+
+	command := fmt.Sprintf("git range-diff --no-color %s %s", shellQuote(range1), shellQuote(range2))
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Range diff failed: %v", err)
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	log.Printf("✅ Range diff successful")
+	return output, nil
+}
+
+func (s *SSHManager) CheckIgnore(repoPath string, files []string) ([]IgnoreResult, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🙈 Check ignore: %s (files: %v)", repoPath, files)
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("at least one file is required")
+	}
+
+	args := []string{"git check-ignore -v -n --"}
+	for _, file := range files {
+		args = append(args, shellQuote(file))
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, strings.Join(args, " ")))
+	if err != nil {
+		// Exit status 1 only means none of the files are ignored
+		if code, ok := exitStatus(err); !ok || code != 1 {
+			log.Printf("❌ Check ignore failed: %v", err)
+			return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+		}
+	}
+
+	results := []IgnoreResult{}
+	for _, line := range strings.Split(output, "\n") {
+		// <source>:<line>:<pattern><TAB><file>, or "::<TAB><file>" for files without a matching rule
+		rule, file, found := strings.Cut(strings.TrimRight(line, "\r"), "\t")
+		if !found {
+			continue
+		}
+
+		result := IgnoreResult{File: file}
+		parts := strings.SplitN(rule, ":", 3)
+		if len(parts) == 3 && parts[2] != "" {
+			result.Source = parts[0]
+			result.Line, _ = strconv.Atoi(parts[1])
+			result.Pattern = parts[2]
+			// A matching negated pattern re-includes the file
+			result.Ignored = !strings.HasPrefix(parts[2], "!")
+		}
+		results = append(results, result)
+	}
+
+	log.Printf("✅ Check ignore: %d results", len(results))
+	return results, nil
+}
+
+func (s *SSHManager) ShowAllNotes(repoPath, commitHash string) (map[string]string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🗒️ Notes: %s (commit: %s)", repoPath, commitHash)
+
+	if commitHash == "" {
+		return nil, fmt.Errorf("commit hash is required")
+	}
+
+	refs, err := s.ExecuteCommand(s.repoCommand(repoPath, "git for-each-ref refs/notes/ --format='%(refname)'"))
+	if err != nil {
+		log.Printf("❌ Notes ref listing failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(refs))
+	}
+
+	notes := make(map[string]string)
+	for _, ref := range strings.Split(refs, "\n") {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+
+		command := fmt.Sprintf("git log --notes=%s -1 --format=%%N %s --", shellQuote(ref), shellQuote(commitHash))
+		output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+		if err != nil {
+			return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+		}
+
+		if note := strings.TrimSpace(output); note != "" {
+			notes[strings.TrimPrefix(ref, "refs/notes/")] = note
+		}
+	}
+
+	log.Printf("✅ Notes: %d namespaces", len(notes))
+	return notes, nil
+}
+
+func (s *SSHManager) OrphanedNotes(repoPath, namespace string) ([]string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🗒️ Orphaned notes: %s (namespace: %s)", repoPath, namespace)
+
+	if namespace == "" {
+		namespace = "commits"
+	}
+
+	// A verbose dry run lists the objects whose notes prune would remove
+	command := fmt.Sprintf("git notes --ref=%s prune -n -v", shellQuote(namespace))
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Orphaned notes lookup failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	orphaned := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		if hash := strings.TrimSpace(line); isCommitHash(hash) {
+			orphaned = append(orphaned, hash)
+		}
+	}
+
+	log.Printf("✅ Orphaned notes: %d", len(orphaned))
+	return orphaned, nil
+}
+
+func (s *SSHManager) PruneGitNotes(repoPath, namespace string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🧹 Notes prune: %s (namespace: %s)", repoPath, namespace)
+
+	if namespace == "" {
+		namespace = "commits"
+	}
+
+	command := fmt.Sprintf("git notes --ref=%s prune -v", shellQuote(namespace))
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Notes prune failed: %v", err)
+	} else {
+		log.Printf("✅ Notes prune successful")
+	}
+	return result, err
+}
+
+var notesMergeStrategies = map[string]bool{
+	"manual":        true,
+	"ours":          true,
+	"theirs":        true,
+	"union":         true,
+	"cat_sort_uniq": true,
+}
+
+// MergeNotes merges notesRef into the default notes ref (refs/notes/commits).
+func (s *SSHManager) MergeNotes(repoPath, notesRef string, strategy string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🗒️ Notes merge: %s (ref: %s, strategy: %s)", repoPath, notesRef, strategy)
+
+	if notesRef == "" {
+		return "", fmt.Errorf("notes ref is required")
+	}
+	if strategy == "" {
+		strategy = "manual"
+	}
+	if !notesMergeStrategies[strategy] {
+		return "", fmt.Errorf("invalid strategy %q (allowed: manual, ours, theirs, union, cat_sort_uniq)", strategy)
+	}
+
+	command := fmt.Sprintf("git notes merge --strategy=%s %s", strategy, shellQuote(notesRef))
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Notes merge failed: %v", err)
+	} else {
+		log.Printf("✅ Notes merge successful")
+	}
+	return result, err
+}
+
+func (s *SSHManager) FilteredLog(repoPath string, filter string, path string, limit int) ([]CommitInfo, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("📜 Filtered log: %s (filter: %s, path: %s)", repoPath, filter, path)
+
+	if filter == "" || strings.Trim(filter, "ADMR") != "" {
+		return nil, fmt.Errorf("invalid diff filter %q (allowed: A, D, M, R)", filter)
+	}
+	if limit <= 0 {
+		limit = 25
+	}
+
+	command := fmt.Sprintf("git log --diff-filter=%s --name-only -n %d --pretty=format:'%s'", filter, limit, commitRecordFormat)
+	if path != "" {
+		command += " -- " + shellQuote(path)
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Filtered log failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	commits := parseCommitRecords(output, false)
+	log.Printf("✅ Filtered log: %d commits", len(commits))
+	return commits, nil
+}
+
+// SparseCheckoutReapply updates the working tree to match the current sparse-checkout patterns.
+func (s *SSHManager) SparseCheckoutReapply(repoPath string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🪶 Sparse checkout reapply: %s", repoPath)
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, "git sparse-checkout reapply"))
+	if err != nil {
+		log.Printf("❌ Sparse checkout reapply failed: %v", err)
+	} else {
+		log.Printf("✅ Sparse checkout reapply successful")
+	}
+	return result, err
+}
+
+func (s *SSHManager) BisectVisualize(repoPath string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🔎 Bisect visualize: %s", repoPath)
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, "git bisect visualize --oneline"))
+	if err != nil {
+		log.Printf("❌ Bisect visualize failed: %v", err)
+	} else {
+		log.Printf("✅ Bisect visualize successful")
+	}
+	return result, err
+}
+
+// newSFTPClient opens an SFTP session over the existing SSH connection.
+func (s *SSHManager) newSFTPClient() (*sftp.Client, error) {
+	if s.openSFTP != nil {
+		return s.openSFTP()
+	}
+	if s.client == nil {
+		return nil, fmt.Errorf("SSH connection not established")
+	}
+
+	client, err := sftp.NewClient(s.client)
+	if err != nil {
+		log.Printf("❌ SFTP session failed: %v", err)
+		return nil, fmt.Errorf("SFTP session failed: %v", err)
+	}
+	return client, nil
+}
+
+// ReadFile returns the content of a file inside the working directory, with the same confinement as DownloadFile.
+// Files above max_view_bytes are rejected with errFileTooLarge.
+func (s *SSHManager) ReadFile(remotePath string) ([]byte, error) {
+	log.Printf("📄 Reading file: %s", remotePath)
+
+	limit := s.config.MaxViewBytes
+	if limit <= 0 {
+		limit = defaultMaxViewBytes
+	}
+
+	file, info, err := s.DownloadFile(remotePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if info.Size() > limit {
+		return nil, fmt.Errorf("%w (%d bytes, limit %d)", errFileTooLarge, info.Size(), limit)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(file, limit))
+	if err != nil {
+		log.Printf("❌ File read failed: %v", err)
+		return nil, fmt.Errorf("file read failed: %v", err)
+	}
+	return content, nil
+}
+
+// WriteFile replaces a file inside the working directory, copying the current version to <path>.bak first. The new
+// content goes through a temporary file, so a dropped session leaves the current version in place.
+// A zero mode keeps the mode of the existing file, or uses 0644 for a new one.
+func (s *SSHManager) WriteFile(remotePath string, data []byte, mode os.FileMode) error {
+	log.Printf("✏️ Writing file: %s (%d bytes)", remotePath, len(data))
+
+	resolved, err := s.resolveWorkingDirPath(remotePath)
+	if err != nil {
+		return err
+	}
+
+	client, err := s.newSFTPClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// A new file is checked through its parent directory, which must already exist
+	info, err := client.Stat(resolved)
+	switch {
+	case err == nil && info.IsDir():
+		return fmt.Errorf("%s is a directory", resolved)
+	case err == nil:
+		err = s.confineRealPath(client, resolved)
+	case errors.Is(err, os.ErrNotExist):
+		info = nil
+		err = s.confineRealPath(client, path.Dir(resolved))
+	}
+	if err != nil {
+		return err
+	}
+
+	if mode == 0 {
+		mode = 0644
+		if info != nil {
+			mode = info.Mode().Perm()
+		}
+	}
+
+	if info != nil {
+		if err := s.CopyFile(resolved, resolved+".bak"); err != nil {
+			return fmt.Errorf("backup failed: %v", err)
+		}
+	}
+
+	_, err = replaceFile(client, resolved, mode, func(dst io.Writer) (int64, error) {
+		written, err := dst.Write(data)
+		return int64(written), err
+	})
+	if err != nil {
+		log.Printf("❌ File write failed: %v", err)
+		return fmt.Errorf("file write failed: %w", err)
+	}
+
+	log.Printf("✅ File written: %s", resolved)
+	return nil
+}
+
+func (s *SSHManager) UploadFile(localPath, remotePath string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("local file open failed: %v", err)
+	}
+	defer file.Close()
+
+	_, err = s.UploadStream(file, remotePath, 0)
+	return err
+}
+
+// UploadStream copies src to remotePath inside the working directory over SFTP without buffering it, with the same
+// confinement as WriteFile. The data goes to a temporary file next to the target that only replaces it once complete,
+// so a failed upload leaves an existing file untouched. With a limit above zero, a source larger than limit bytes is
+// rejected with errUploadTooLarge.
+func (s *SSHManager) UploadStream(src io.Reader, remotePath string, limit int64) (int64, error) {
+	log.Printf("📤 Uploading file: %s", remotePath)
+
+	resolved, err := s.resolveWorkingDirPath(remotePath)
+	if err != nil {
+		return 0, err
+	}
+
+	client, err := s.newSFTPClient()
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	// A new file is checked through its parent directory, which must already exist
+	info, err := client.Stat(resolved)
+	switch {
+	case err == nil && info.IsDir():
+		return 0, fmt.Errorf("%s is a directory", resolved)
+	case err == nil:
+		err = s.confineRealPath(client, resolved)
+	case errors.Is(err, os.ErrNotExist):
+		info = nil
+		err = s.confineRealPath(client, path.Dir(resolved))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var mode os.FileMode
+	if info != nil {
+		mode = info.Mode().Perm()
+	}
+	if limit > 0 {
+		src = io.LimitReader(src, limit+1)
+	}
+	written, err := replaceFile(client, resolved, mode, func(dst io.Writer) (int64, error) {
+		// Hide the file's ReadFrom: it takes a source failing with io.ErrUnexpectedEOF, as a truncated multipart body
+		// does, for the end of the data
+		written, err := io.CopyBuffer(struct{ io.Writer }{dst}, src, make([]byte, 1<<20))
+		if err == nil && limit > 0 && written > limit {
+			err = errUploadTooLarge
+		}
+		return written, err
+	})
+	if err != nil {
+		log.Printf("❌ Upload failed after %d bytes: %v", written, err)
+		if errors.Is(err, errUploadTooLarge) {
+			return written, err
+		}
+		return written, fmt.Errorf("upload failed: %w", err)
+	}
+
+	log.Printf("✅ Upload successful: %d bytes", written)
+	return written, nil
+}
+
+// replaceFile has write fill a temporary file next to target and renames it over target only once it is complete,
+// so a failed or interrupted write leaves the previous version intact. A zero mode keeps the SFTP server's default.
+func replaceFile(client *sftp.Client, target string, mode os.FileMode, write func(dst io.Writer) (int64, error)) (int64, error) {
+	tempPath := path.Join(path.Dir(target), fmt.Sprintf(".%s.tmp-%d", path.Base(target), time.Now().UnixNano()))
+	dst, err := client.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		return 0, fmt.Errorf("temporary file create failed: %v", err)
+	}
+
+	written, err := write(dst)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && mode != 0 {
+		err = client.Chmod(tempPath, mode)
+	}
+	if err == nil {
+		err = client.PosixRename(tempPath, target)
+	}
+	if err != nil {
+		client.Remove(tempPath)
+	}
+	return written, err
+}
+
+// resolveWorkingDirPath makes a relative path absolute under the working directory and rejects any path that
+// leaves it, e.g. through "..".
+func (s *SSHManager) resolveWorkingDirPath(remotePath string) (string, error) {
+	return resolvePathUnder(s.config.WorkingDir, remotePath)
+}
+
+// resolveDeployPath is resolveWorkingDirPath for deploy targets, which are confined to the deploy root instead when
+// one is configured. The root itself is refused, so a deploy cannot unpack over the whole tree.
+func (s *SSHManager) resolveDeployPath(deployPath string) (string, error) {
+	root := s.config.DeployRoot
+	if root == "" {
+		root = s.config.WorkingDir
+	}
+
+	resolved, err := resolvePathUnder(root, deployPath)
+	if err == nil && resolved == path.Clean(strings.Replace(root, "\\", "/", -1)) {
+		err = errOutsideWorkingDir
+	}
+	if err != nil {
+		return "", fmt.Errorf("deploy path must be a directory inside %s: %w", root, err)
+	}
+	return resolved, nil
+}
+
+// resolvePathUnder makes a relative path absolute under root and rejects any path that leaves it.
+func resolvePathUnder(rootDir, remotePath string) (string, error) {
+	root := path.Clean(strings.Replace(rootDir, "\\", "/", -1))
+	if rootDir == "" || !path.IsAbs(root) {
+		return "", errOutsideWorkingDir
+	}
+
+	remotePath = strings.Replace(remotePath, "\\", "/", -1)
+	if !path.IsAbs(remotePath) {
+		remotePath = path.Join(root, remotePath)
+	}
+	remotePath = path.Clean(remotePath)
+
+	if !isWithinDir(root, remotePath) {
+		return "", errOutsideWorkingDir
+	}
+	return remotePath, nil
+}
+
+// validateRepoPath accepts only absolute repository paths inside the working directory, without ".." components
+// or shell metacharacters.
+func (s *SSHManager) validateRepoPath(repoPath string) error {
+	root := path.Clean(strings.Replace(s.config.WorkingDir, "\\", "/", -1))
+	if s.config.WorkingDir == "" || !path.IsAbs(root) {
+		return &ErrInvalidPath{Path: repoPath, Reason: "the working directory must be an absolute path"}
+	}
+
+	if strings.ContainsAny(repoPath, repoPathMetacharacters) {
+		return &ErrInvalidPath{Path: repoPath, Reason: "shell metacharacters are not allowed"}
+	}
+	for _, part := range strings.Split(repoPath, "/") {
+		if part == ".." {
+			return &ErrInvalidPath{Path: repoPath, Reason: "\"..\" is not allowed"}
+		}
+	}
+	if !path.IsAbs(repoPath) || !isWithinDir(root, path.Clean(repoPath)) {
+		return &ErrInvalidPath{Path: repoPath, Reason: "not inside the working directory " + root}
+	}
+	return nil
+}
+
+func isWithinDir(root, target string) bool {
+	return root == "/" || target == root || strings.HasPrefix(target, root+"/")
+}
+
+// confineRealPath resolves symlinks in target on the server and rejects it if it ends up outside the working directory.
+func (s *SSHManager) confineRealPath(client *sftp.Client, target string) error {
+	realRoot, err := client.RealPath(path.Clean(s.config.WorkingDir))
+	if err != nil {
+		return err
+	}
+	realPath, err := client.RealPath(target)
+	if err != nil {
+		return err
+	}
+	if !isWithinDir(realRoot, realPath) {
+		return errOutsideWorkingDir
+	}
+	return nil
+}
+
+// sftpFileReader closes the SFTP session together with the file it was opened for.
+type sftpFileReader struct {
+	*sftp.File
+	client *sftp.Client
+}
+
+func (r *sftpFileReader) Close() error {
+	err := r.File.Close()
+	r.client.Close()
+	return err
+}
+
+// DownloadFile opens a file inside the working directory for streaming. Symlinks are resolved on the server before
+// the working directory check, so a link cannot point the download elsewhere. The caller must close the reader.
+func (s *SSHManager) DownloadFile(remotePath string) (io.ReadCloser, os.FileInfo, error) {
+	log.Printf("📥 Downloading file: %s", remotePath)
+
+	resolved, err := s.resolveWorkingDirPath(remotePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := s.newSFTPClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Stat first: it reports a missing file as os.ErrNotExist
+	_, err = client.Stat(resolved)
+	if err == nil {
+		err = s.confineRealPath(client, resolved)
+	}
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+
+	file, err := client.Open(resolved)
+	if err != nil {
+		client.Close()
+		log.Printf("❌ File open failed: %v", err)
+		return nil, nil, err
+	}
+
+	info, err := file.Stat()
+	if err == nil && info.IsDir() {
+		err = fmt.Errorf("%s is a directory", resolved)
+	}
+	if err != nil {
+		file.Close()
+		client.Close()
+		return nil, nil, err
+	}
+
+	return &sftpFileReader{File: file, client: client}, info, nil
+}
+
+// CopyFile copies a file within the working directory. Both paths are confined like DownloadFile, with symlinks
+// resolved on the server, and copying a file onto itself is refused rather than truncating it.
+func (s *SSHManager) CopyFile(srcPath, dstPath string) error {
+	log.Printf("📄 Copying file: %s -> %s", srcPath, dstPath)
+
+	srcPath, err := s.resolveWorkingDirPath(srcPath)
+	if err != nil {
+		return err
+	}
+	dstPath, err = s.resolveWorkingDirPath(dstPath)
+	if err != nil {
+		return err
+	}
+	if srcPath == dstPath {
+		return errSameFile
+	}
+
+	client, err := s.newSFTPClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Stat first: it reports a missing file as os.ErrNotExist
+	if _, err := client.Stat(srcPath); err != nil {
+		return err
+	}
+	if err := s.confineRealPath(client, srcPath); err != nil {
+		return err
+	}
+
+	// The destination may not exist yet, so its directory is confined instead, and the file itself when it does
+	if err := s.confineRealPath(client, path.Dir(dstPath)); err != nil {
+		return err
+	}
+	if _, err := client.Lstat(dstPath); err == nil {
+		if err := s.confineRealPath(client, dstPath); err != nil {
+			return err
+		}
+		realSrc, err := client.RealPath(srcPath)
+		if err != nil {
+			return err
+		}
+		realDst, err := client.RealPath(dstPath)
+		if err != nil {
+			return err
+		}
+		if realSrc == realDst {
+			return errSameFile
+		}
+	}
+
+	src, err := client.Open(srcPath)
+	if err != nil {
+		log.Printf("❌ Source open failed: %v", err)
+		return fmt.Errorf("source open failed: %v", err)
+	}
+	defer src.Close()
+
+	dst, err := client.Create(dstPath)
+	if err != nil {
+		log.Printf("❌ Destination create failed: %v", err)
+		return fmt.Errorf("destination create failed: %v", err)
+	}
+	defer dst.Close()
+
+	written, err := io.Copy(dst, src)
+	if err != nil {
+		log.Printf("❌ Copy failed: %v", err)
+		return fmt.Errorf("copy failed: %v", err)
+	}
+
+	// Keep the permissions of the original file
+	if info, err := src.Stat(); err == nil {
+		client.Chmod(dstPath, info.Mode())
+	}
+
+	log.Printf("✅ Copy successful: %d bytes", written)
+	return nil
+}
+
+func (s *SSHManager) FilterRepo(repoPath string, args []string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🧽 Filter-repo starting: %s (args: %v)", repoPath, args)
+
+	if len(args) == 0 {
+		return "", fmt.Errorf("filter-repo arguments are required")
+	}
+
+	// git-filter-repo is not part of core git, check it is installed first
+	if output, err := s.ExecuteCommand("git filter-repo --version"); err != nil {
+		log.Printf("❌ git-filter-repo not installed: %v", err)
+		return output, fmt.Errorf("git-filter-repo is not installed on the server")
+	}
+
+	// Always keep a full backup bundle next to the repository before rewriting history
+	bundlePath := fmt.Sprintf("%s-backup-%s.bundle", repoPath, time.Now().Format("20060102-150405"))
+	backupCmd := s.repoCommand(repoPath, fmt.Sprintf("git bundle create %s --all", shellQuote(bundlePath)))
+	if output, err := s.ExecuteCommand(backupCmd); err != nil {
+		log.Printf("❌ Backup bundle failed: %v", err)
+		return output, fmt.Errorf("backup bundle failed: %v", err)
+	}
+	log.Printf("💾 Backup bundle created: %s", bundlePath)
+
+	var quoted []string
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, "git filter-repo "+strings.Join(quoted, " ")))
+	result = fmt.Sprintf("Backup: %s\n%s", bundlePath, result)
+	if err != nil {
+		log.Printf("❌ Filter-repo failed: %v", err)
+	} else {
+		log.Printf("✅ Filter-repo successful")
+	}
+	return result, err
+}
+
+func (s *SSHManager) ForEachRef(repoPath string, pattern string, format string, sortBy string) ([]map[string]string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🏷️ Listing refs: %s (pattern: %s)", repoPath, pattern)
+
+	if format == "" {
+		format = "%(refname:short)|%(objectname:short)"
+	}
+	if sortBy == "" {
+		sortBy = "refname"
+	}
+
+	command := fmt.Sprintf("git for-each-ref --format=%s --sort=%s", shellQuote(format), shellQuote(sortBy))
+	if pattern != "" {
+		command += " " + shellQuote(pattern)
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Ref listing failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	// Each "|" separated field is keyed by its atom, e.g. %(refname:short) -> refname:short
+	fields := strings.Split(format, "|")
+	keys := make([]string, len(fields))
+	for i, field := range fields {
+		if strings.HasPrefix(field, "%(") && strings.HasSuffix(field, ")") {
+			keys[i] = field[2 : len(field)-1]
+		} else {
+			keys[i] = fmt.Sprintf("field%d", i)
+		}
+	}
+
+	refs := []map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		values := strings.SplitN(line, "|", len(keys))
+		ref := make(map[string]string)
+		for i, value := range values {
+			ref[keys[i]] = value
+		}
+		refs = append(refs, ref)
+	}
+
+	log.Printf("✅ Total %d refs found", len(refs))
+	return refs, nil
+}
+
+// ListTags lists tags newest first. For annotated tags the hash is the peeled commit, not the tag object.
+func (s *SSHManager) ListTags(repoPath string) ([]TagInfo, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🏷️ List tags: %s", repoPath)
+
+	// The refname goes last as it may contain the separator
+	format := "%(objecttype)|%(objectname)|%(*objectname)|%(creatordate:iso-strict)|%(refname)"
+	refs, err := s.ForEachRef(repoPath, "refs/tags/", format, "-creatordate")
+	if err != nil {
+		log.Printf("❌ List tags failed: %v", err)
+		return nil, err
+	}
+
+	tags := make([]TagInfo, 0)
+	for _, ref := range refs {
+		tag := TagInfo{
+			Name:        strings.TrimPrefix(strings.TrimRight(ref["refname"], "\r"), "refs/tags/"),
+			Hash:        ref["objectname"],
+			Date:        ref["creatordate:iso-strict"],
+			IsAnnotated: ref["objecttype"] == "tag",
+		}
+		if tag.IsAnnotated && ref["*objectname"] != "" {
+			tag.Hash = ref["*objectname"]
+		}
+		tags = append(tags, tag)
+	}
+
+	log.Printf("✅ List tags: %d tags", len(tags))
+	return tags, nil
+}
+
+// CreateTag tags HEAD. Annotated tags without a message use the tag name as message.
+func (s *SSHManager) CreateTag(repoPath, name, message string, annotated bool) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🏷️ Create tag: %s (%s, annotated: %v)", repoPath, name, annotated)
+
+	if err := git.ValidateRefName(name); err != nil {
+		return "", fmt.Errorf("invalid tag name: %v", err)
+	}
+
+	command := "git tag " + shellQuote(name)
+	if annotated {
+		if message == "" {
+			message = name
+		}
+		command = fmt.Sprintf("git tag -a %s -m %s", shellQuote(name), shellQuote(message))
+	}
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Create tag failed: %v", err)
+	} else {
+		log.Printf("✅ Tag created")
+	}
+	return result, err
+}
+
+// DeleteTag deletes a local tag and, with remote set, the same tag on origin.
+func (s *SSHManager) DeleteTag(repoPath, name string, remote bool) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🏷️ Delete tag: %s (%s, remote: %v)", repoPath, name, remote)
+
+	if err := git.ValidateRefName(name); err != nil {
+		return "", fmt.Errorf("invalid tag name: %v", err)
+	}
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, "git tag -d "+shellQuote(name)))
+	if err != nil {
+		log.Printf("❌ Delete tag failed: %v", err)
+		return result, err
+	}
+
+	if remote {
+		gitCmd := s.gitWithToken(repoPath, "origin")
+
+		pushResult, err := s.ExecuteCommand(s.repoCommand(repoPath, gitCmd+" push origin "+shellQuote(":refs/tags/"+name)))
+		result += pushResult
+		if err != nil {
+			log.Printf("❌ Delete remote tag failed: %v", err)
+			return result, err
+		}
+	}
+
+	log.Printf("✅ Tag deleted")
+	return result, nil
+}
+
+func (s *SSHManager) VerifyTag(repoPath, tagName string) (*TagVerifyResult, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🛡️ Tag verify: %s (%s)", repoPath, tagName)
+
+	if tagName == "" {
+		return nil, fmt.Errorf("tag name is required")
+	}
+
+	// Force the C locale so the gpg messages below can be matched
+	command := fmt.Sprintf("LC_ALL=C git tag -v %s", shellQuote(tagName))
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+
+	result := &TagVerifyResult{Tag: tagName, Validity: "unknown", Output: strings.TrimSpace(output)}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "gpg:"))
+		switch {
+		case strings.HasPrefix(line, "Signature made "):
+			result.Signed = true
+			result.Timestamp = strings.TrimSpace(strings.TrimPrefix(line, "Signature made "))
+		case strings.HasPrefix(line, "using "):
+			// using RSA key 0123ABCD...
+			fields := strings.Fields(line)
+			result.KeyFingerprint = fields[len(fields)-1]
+		case strings.HasPrefix(line, "Good signature from "):
+			result.Validity = "good"
+			result.SignerID = signerFromLine(line)
+		case strings.HasPrefix(line, "BAD signature from "):
+			result.Validity = "bad"
+			result.SignerID = signerFromLine(line)
+		}
+	}
+
+	if err != nil && !result.Signed {
+		if strings.Contains(output, "no signature found") {
+			log.Printf("ℹ️ Tag %s is not signed", tagName)
+			return result, nil
+		}
+		log.Printf("❌ Tag verify failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	log.Printf("✅ Tag %s signature: %s", tagName, result.Validity)
+	return result, nil
+}
+
+// signerFromLine extracts the quoted user ID from a gpg "... signature from" line.
+func signerFromLine(line string) string {
+	start := strings.Index(line, "\"")
+	end := strings.LastIndex(line, "\"")
+	if start == -1 || end <= start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+func (s *SSHManager) GetSymbolicRef(repoPath, ref string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🎯 Reading symbolic ref: %s (%s)", repoPath, ref)
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git symbolic-ref "+shellQuote(ref)))
+	if err != nil {
+		log.Printf("❌ Symbolic ref read failed: %v", err)
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+	return strings.TrimSpace(output), nil
+}
+
+func (s *SSHManager) SetSymbolicRef(repoPath, name, target string) error {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return err
+	}
+	log.Printf("🎯 Setting symbolic ref: %s (%s -> %s)", repoPath, name, target)
+
+	if name == "" || target == "" {
+		return fmt.Errorf("symbolic ref name and target are required")
+	}
+
+	command := fmt.Sprintf("git symbolic-ref %s %s", shellQuote(name), shellQuote(target))
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Symbolic ref update failed: %v", err)
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	log.Printf("✅ Symbolic ref updated")
+	return nil
+}
+
+func (s *SSHManager) DeployRef(repoPath, ref, deployPath string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🚚 Deploy starting: %s (ref: %s) -> %s", repoPath, ref, deployPath)
+
+	if ref == "" || deployPath == "" {
+		return "", fmt.Errorf("ref and deploy path are required")
+	}
+	if strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("invalid ref %q", ref)
+	}
+	deployPath, err := s.resolveDeployPath(deployPath)
+	if err != nil {
+		return "", err
+	}
+
+	// Export a clean snapshot of the ref, the working tree is left untouched
+	command := s.repoCommand(repoPath, fmt.Sprintf("mkdir -p %s && git archive %s | tar -x -C %s",
+		shellQuote(deployPath), shellQuote(ref), shellQuote(deployPath)))
+	result, err := s.ExecuteCommand(command)
+	if err != nil {
+		log.Printf("❌ Deploy failed: %v", err)
+	} else {
+		log.Printf("✅ Deploy successful")
+	}
+	return result, err
+}
+
+func (s *SSHManager) GitBlameIgnoreRev(repoPath, filePath string, ignoreRevs []string) ([]BlameEntry, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("🕵️ Blame: %s (file: %s, ignoring %d revs)", repoPath, filePath, len(ignoreRevs))
+
+	if filePath == "" {
+		return nil, fmt.Errorf("file path is required")
+	}
+
+	command := "git blame --line-porcelain"
+	if len(ignoreRevs) > 0 {
+		for _, rev := range ignoreRevs {
+			if strings.Trim(strings.ToLower(rev), "0123456789abcdef") != "" {
+				return nil, fmt.Errorf("invalid commit hash %q", rev)
+			}
+		}
+
+		client, err := s.newSFTPClient()
+		if err != nil {
+			return nil, err
+		}
+		defer client.Close()
+
+		// Write the revisions to a temporary ignore file on the server
+		ignoreFile := fmt.Sprintf("/tmp/git-blame-ignore-revs-%d", time.Now().UnixNano())
+		file, err := client.Create(ignoreFile)
+		if err != nil {
+			log.Printf("❌ Ignore file create failed: %v", err)
+			return nil, fmt.Errorf("ignore file create failed: %v", err)
+		}
+		_, err = file.Write([]byte(strings.Join(ignoreRevs, "\n") + "\n"))
+		file.Close()
+		defer client.Remove(ignoreFile)
+		if err != nil {
+			return nil, fmt.Errorf("ignore file write failed: %v", err)
+		}
+
+		command += " --ignore-revs-file=" + shellQuote(ignoreFile)
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command+" -- "+shellQuote(filePath)))
+	if err != nil {
+		log.Printf("❌ Blame failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	entries := parseBlamePorcelain(output)
+	log.Printf("✅ Blame successful: %d lines", len(entries))
+	return entries, nil
+}
+
+// parseBlamePorcelain parses the output of git blame --line-porcelain.
+func parseBlamePorcelain(output string) []BlameEntry {
+	entries := []BlameEntry{}
+	var current *BlameEntry
+
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			if current != nil {
+				current.Content = line[1:]
+				entries = append(entries, *current)
+				current = nil
+			}
+		case current == nil:
+			// Header line: <hash> <original line> <final line> [<group size>]
+			parts := strings.Fields(line)
+			if len(parts) >= 3 && isCommitHash(parts[0]) {
+				lineNumber, _ := strconv.Atoi(parts[2])
+				current = &BlameEntry{Hash: parts[0], Line: lineNumber}
+			}
+		case strings.HasPrefix(line, "author "):
+			current.Author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			if seconds, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64); err == nil {
+				current.Date = time.Unix(seconds, 0).Format("2006-01-02 15:04:05")
+			}
+		case strings.HasPrefix(line, "summary "):
+			current.Summary = strings.TrimPrefix(line, "summary ")
+		}
+	}
+
+	return entries
+}
+
+func (s *SSHManager) MaintenanceRegister(repoPath string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🛠️ Maintenance register: %s", repoPath)
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, "git maintenance register"))
+	if err != nil {
+		log.Printf("❌ Maintenance register failed: %v", err)
+	} else {
+		log.Printf("✅ Maintenance register successful")
+	}
+	return result, err
+}
+
+func (s *SSHManager) MaintenanceUnregister(repoPath string) error {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return err
+	}
+	log.Printf("🛠️ Maintenance unregister: %s", repoPath)
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git maintenance unregister"))
+	if err != nil {
+		log.Printf("❌ Maintenance unregister failed: %v", err)
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	log.Printf("✅ Maintenance unregister successful")
+	return nil
+}
+
+// MaintenanceRegistered reports whether the repository is listed in the global maintenance.repo config.
+func (s *SSHManager) MaintenanceRegistered(repoPath string) (bool, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return false, err
+	}
+
+	topLevel, err := s.ExecuteCommand(s.repoCommand(repoPath, "git rev-parse --show-toplevel"))
+	if err != nil {
+		return false, fmt.Errorf("%v: %s", err, strings.TrimSpace(topLevel))
+	}
+
+	// git config exits with 1 when the key is missing, which just means nothing is registered
+	repos, _ := s.ExecuteCommand("git config --global --get-all maintenance.repo || true")
+	for _, repo := range strings.Split(repos, "\n") {
+		if strings.TrimSpace(repo) == strings.TrimSpace(topLevel) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *SSHManager) GitRepack(repoPath string, aggressive bool, windowSize int, depth int) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("📦 Repack starting: %s (aggressive: %v, window: %d, depth: %d)", repoPath, aggressive, windowSize, depth)
+
+	// -d drops the packs made redundant by the new one
+	command := "git repack -a -d"
+	if aggressive {
+		command += " -f"
+	}
+	if windowSize > 0 {
+		command += fmt.Sprintf(" --window=%d", windowSize)
+	}
+	if depth > 0 {
+		command += fmt.Sprintf(" --depth=%d", depth)
+	}
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Repack failed: %v", err)
+	} else {
+		log.Printf("✅ Repack successful")
+	}
+	return result, err
+}
+
+// CountObjects returns the statistics reported by git count-objects -v.
+func (s *SSHManager) CountObjects(repoPath string) (map[string]string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git count-objects -v"))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	stats := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			stats[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return stats, nil
+}
+
+// LastRepack returns the modification time of the newest packfile, or a zero time when there is none.
+func (s *SSHManager) LastRepack(repoPath string) (time.Time, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return time.Time{}, err
+	}
+
+	command := "ls -t \"$(git rev-parse --git-dir)\"/objects/pack/*.pack 2>/dev/null | head -1 | xargs -r stat -c %Y"
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	seconds, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return time.Time{}, nil
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// ReflogSize returns the disk usage of the reflogs in KiB; git count-objects does not include them.
+func (s *SSHManager) ReflogSize(repoPath string) (int64, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return 0, err
+	}
+
+	command := "du -sk \"$(git rev-parse --git-dir)\"/logs 2>/dev/null | cut -f1"
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		return 0, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	size, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		// No logs directory yet
+		return 0, nil
+	}
+	return size, nil
+}
+
+// ReflogExpire drops reflog entries older than expireBefore, or older than git's gc.reflogExpire default when it is zero.
+// Only the HEAD reflog is expired unless all is set.
+func (s *SSHManager) ReflogExpire(repoPath string, expireBefore time.Time, all bool) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🧹 Reflog expire: %s (before: %v, all: %v)", repoPath, expireBefore, all)
+
+	command := "git reflog expire"
+	if !expireBefore.IsZero() {
+		// Unreachable entries are otherwise kept for gc.reflogExpireUnreachable (30 days) no matter how recent the date is
+		date := shellQuote(expireBefore.UTC().Format("2006-01-02 15:04:05 +0000"))
+		command += " --expire=" + date + " --expire-unreachable=" + date
+	}
+	if all {
+		command += " --all"
+	} else {
+		command += " HEAD"
+	}
+	command += " --verbose"
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Reflog expire failed: %v", err)
+	} else {
+		log.Printf("✅ Reflog expire successful")
+	}
+	return result, err
+}
+
+func (s *SSHManager) GitStash(repoPath, message string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("📦 Stash: %s (message: %s)", repoPath, message)
+
+	command := "git stash push"
+	if message != "" {
+		command += " -m " + shellQuote(message)
+	}
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Stash failed: %v", err)
+	} else {
+		log.Printf("✅ Stash successful")
+	}
+	return result, err
+}
+
+func (s *SSHManager) GitStashPop(repoPath string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("📤 Stash pop: %s", repoPath)
+
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, "git stash pop"))
+	if err != nil {
+		log.Printf("❌ Stash pop failed: %v", err)
+	} else {
+		log.Printf("✅ Stash pop successful")
+	}
+	return result, err
+}
+
+// GitStashList parses git stash list, newest first. Subjects look like "On main: message" or "WIP on main: abc1234 subject".
+func (s *SSHManager) GitStashList(repoPath string) ([]StashEntry, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	log.Printf("📦 Stash list: %s", repoPath)
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, "git stash list --format='%gd%x1f%gs'"))
+	if err != nil {
+		log.Printf("❌ Stash list failed: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+
+	stashes := make([]StashEntry, 0)
+	for _, line := range strings.Split(output, "\n") {
+		ref, subject, found := strings.Cut(strings.TrimRight(line, "\r"), "\x1f")
+		if !found {
+			continue
+		}
+
+		var entry StashEntry
+		if _, err := fmt.Sscanf(ref, "stash@{%d}", &entry.Index); err != nil {
+			continue
+		}
+		rest, ok := strings.CutPrefix(subject, "On ")
+		if !ok {
+			rest, ok = strings.CutPrefix(subject, "WIP on ")
+		}
+		if ok {
+			entry.Branch, entry.Message, _ = strings.Cut(rest, ": ")
+		} else {
+			entry.Message = subject
+		}
+		stashes = append(stashes, entry)
+	}
+
+	log.Printf("✅ Stash list: %d entries", len(stashes))
+	return stashes, nil
+}
+
+func (s *SSHManager) StashBranch(repoPath, branchName string, stashIndex int) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("🌿 Stash branch: %s (branch: %s, stash: %d)", repoPath, branchName, stashIndex)
+
+	if branchName == "" {
+		return "", fmt.Errorf("branch name is required")
+	}
+	if stashIndex < 0 {
+		return "", fmt.Errorf("invalid stash index %d", stashIndex)
+	}
+
+	command := fmt.Sprintf("git stash branch %s 'stash@{%d}'", shellQuote(branchName), stashIndex)
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Stash branch failed: %v", err)
+	} else {
+		log.Printf("✅ Stash branch successful")
+	}
+	return result, err
+}
+
+func (s *SSHManager) FetchFromBundle(repoPath, bundlePath string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	bundlePath = strings.Replace(bundlePath, "\\", "/", -1)
+	log.Printf("🎁 Bundle fetch: %s (bundle: %s)", repoPath, bundlePath)
+
+	if bundlePath == "" {
+		return "", fmt.Errorf("bundle path is required")
+	}
+
+	// Verify first so a truncated or unrelated bundle never touches the refs
+	command := fmt.Sprintf("git bundle verify %s && git fetch %s '+refs/heads/*:refs/remotes/bundle/*' 2>&1",
+		shellQuote(bundlePath), shellQuote(bundlePath))
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Bundle fetch failed: %v", err)
+	} else {
+		log.Printf("✅ Bundle fetch successful")
+	}
+	return result, err
+}
+
+func (s *SSHManager) SubtreeSplit(repoPath, prefix, annotate string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("✂️ Subtree split: %s (prefix: %s)", repoPath, prefix)
+
+	if prefix == "" {
+		return "", fmt.Errorf("prefix is required")
+	}
+
+	command := "git subtree split --prefix=" + shellQuote(prefix)
+	if annotate != "" {
+		command += " --annotate=" + shellQuote(annotate)
+	}
+
+	output, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Subtree split failed: %v", err)
+		return output, err
+	}
+
+	// The resulting commit hash is printed on the last line
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	sha := strings.TrimSpace(lines[len(lines)-1])
+	if !isCommitHash(sha) {
+		return output, fmt.Errorf("unexpected subtree split output")
+	}
+
+	log.Printf("✅ Subtree split successful: %s", sha)
+	return sha, nil
+}
+
+// PushCommit pushes a single commit to a branch of the given remote name or URL.
+func (s *SSHManager) PushCommit(repoPath, remote, sha, branch string) (string, error) {
+	// Convert to Linux path format
+	repoPath = strings.Replace(repoPath, "\\", "/", -1)
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return "", err
+	}
+	log.Printf("⬆️ Push commit: %s (%s -> %s %s)", repoPath, sha, remote, branch)
+
+	command := fmt.Sprintf("git%s push %s %s", githubAuthArgs(githubTokens.Token()), shellQuote(remote), shellQuote(sha+":refs/heads/"+branch))
+	result, err := s.ExecuteCommand(s.repoCommand(repoPath, command))
+	if err != nil {
+		log.Printf("❌ Push commit failed: %v", err)
+	} else {
+		log.Printf("✅ Push commit successful")
+	}
+	return result, err
+}
+
+// parseCommitLog parses git log output produced with commitLogFormat.
+// Any other non-empty lines (e.g. from --name-only) are attached to the preceding commit as files.
+func parseCommitLog(output string) []CommitInfo {
+	commits := []CommitInfo{}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if commit, ok := parseCommitLine(line); ok {
+			commits = append(commits, commit)
+			continue
+		}
+
+		if len(commits) > 0 {
+			last := &commits[len(commits)-1]
+			last.Files = append(last.Files, strings.TrimSpace(line))
+		}
+	}
+
+	return commits
+}
+
+// parseCommitRecords parses git log output produced with commitRecordFormat, or with the %S source ref in front of
+// commitLogFormat when sourced is set. A patch (from -p) becomes the commit's Patch, and the other lines following
+// each header (e.g. from --name-only or --numstat) become its files.
+func parseCommitRecords(output string, sourced bool) []CommitInfo {
+	commits := []CommitInfo{}
+
+	for _, record := range strings.Split(output, "\x00") {
+		header, body, _ := strings.Cut(record, "\n")
+		parse := parseCommitLine
+		if sourced {
+			parse = parseSourcedCommitLine
+		}
+		commit, ok := parse(strings.TrimRight(header, "\r"))
+		if !ok {
+			continue
+		}
+
+		if start := strings.Index("\n"+body, "\ndiff --git "); start >= 0 {
+			commit.Patch = strings.TrimRight(body[start:], "\n") + "\n"
+			body = body[:start]
+		}
+		for _, line := range strings.Split(body, "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" {
+				commit.Files = append(commit.Files, line)
+			}
+		}
+		commits = append(commits, commit)
+	}
+
+	return commits
+}
+
+// parseSourcedCommitLog parses commitLogFormat lines prefixed with the %S source ref.
+func parseSourcedCommitLog(output string) []CommitInfo {
+	commits := []CommitInfo{}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if commit, ok := parseSourcedCommitLine(line); ok {
+			commits = append(commits, commit)
+			continue
+		}
+
+		if len(commits) > 0 {
+			last := &commits[len(commits)-1]
+			last.Files = append(last.Files, strings.TrimSpace(line))
+		}
+	}
+
+	return commits
+}
+
+// parseNumstat parses "<added>\t<deleted>\t<path>" lines; binary files report "-" and count as zero.
+func parseNumstat(lines []string) []FileStat {
+	var stats []FileStat
+	for _, line := range lines {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		additions, _ := strconv.Atoi(parts[0])
+		deletions, _ := strconv.Atoi(parts[1])
+		stats = append(stats, FileStat{Path: parts[2], Additions: additions, Deletions: deletions})
+	}
+	return stats
+}
+
+// parseSourcedCommitLine parses a commitLogFormat line prefixed with the %S source ref, which becomes the Branch.
+func parseSourcedCommitLine(line string) (CommitInfo, bool) {
+	source, rest, found := strings.Cut(line, "\x1f")
+	if !found {
+		return CommitInfo{}, false
+	}
+	commit, ok := parseCommitLine(rest)
+	if ok {
+		commit.Branch = strings.TrimPrefix(strings.TrimPrefix(source, "refs/heads/"), "refs/")
+	}
+	return commit, ok
+}
+
+func parseCommitLine(line string) (CommitInfo, bool) {
+	parts := strings.SplitN(line, "\x1f", 4)
+	if len(parts) != 4 || !isCommitHash(parts[0]) {
+		return CommitInfo{}, false
+	}
+
+	return CommitInfo{
+		Hash:    parts[0],
+		Author:  parts[1],
+		Date:    parts[2],
+		Subject: parts[3],
+	}, true
+}
+
+// parsePrivateKey parses a PEM or OpenSSH private key, using passphrase only when the key turns out to be encrypted.
+func parsePrivateKey(keyBytes []byte, passphrase string) (ssh.Signer, error) {
+	signer, err := ssh.ParsePrivateKey(keyBytes)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		if passphrase == "" {
+			return nil, fmt.Errorf("SSH key is protected by a passphrase, enter it on the setup page")
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(keyBytes, []byte(passphrase))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("SSH key parse failed: %v", err)
+	}
+	return signer, nil
+}
+
+// exitStatus extracts the remote exit code from an ExecuteCommand error.
+func exitStatus(err error) (int, bool) {
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), true
+	}
+	return 0, false
+}
+
+// isObjectHash accepts full SHA-1 and SHA-256 object names.
+func isObjectHash(value string) bool {
+	if len(value) != 40 && len(value) != 64 {
+		return false
+	}
+	for _, c := range value {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// isAbbrevHash accepts full or abbreviated (at least 4 characters) object names.
+func isAbbrevHash(value string) bool {
+	if len(value) < 4 || len(value) > 64 {
+		return false
+	}
+	for _, c := range value {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+func isCommitHash(value string) bool {
+	if len(value) != 40 {
+		return false
+	}
+	for _, c := range value {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// shellQuote wraps a value in single quotes so it is passed to the remote shell verbatim.
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+// ProjectListCache keeps the last known project list on the app host
+// so the dashboard can still show projects while SSH is unreachable.
+type ProjectListCache struct {
+	path string
+}
+
+type cachedProjectList struct {
+	Projects  []Project `json:"projects"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewProjectListCache(path string) *ProjectListCache {
+	return &ProjectListCache{path: path}
+}
+
+func (c *ProjectListCache) Save(projects []Project) error {
+	data, err := json.MarshalIndent(cachedProjectList{Projects: projects, UpdatedAt: time.Now()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0644)
+}
+
+func (c *ProjectListCache) Load() ([]Project, time.Time, error) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var cached cachedProjectList
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, time.Time{}, err
+	}
+	return cached.Projects, cached.UpdatedAt, nil
+}
+
+func (c *ProjectListCache) Invalidate() {
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		log.Printf("⚠️ Project cache removal failed: %v", err)
+	}
+}
+
+// ProjectMeta holds per-project settings that are kept on the app host.
+type ProjectMeta struct {
+	GitUserName  string `json:"git_user_name"`
+	GitUserEmail string `json:"git_user_email"`
+
+	BackupCron string `json:"backup_cron,omitempty"` // standard 5-field cron spec for scheduled S3 backups
+}
+
+// identityEnv returns an export statement overriding the server's git identity.
+func (m ProjectMeta) identityEnv() string {
+	var vars []string
+	if m.GitUserName != "" {
+		vars = append(vars, "GIT_AUTHOR_NAME="+shellQuote(m.GitUserName), "GIT_COMMITTER_NAME="+shellQuote(m.GitUserName))
+	}
+	if m.GitUserEmail != "" {
+		vars = append(vars, "GIT_AUTHOR_EMAIL="+shellQuote(m.GitUserEmail), "GIT_COMMITTER_EMAIL="+shellQuote(m.GitUserEmail))
+	}
+	if len(vars) == 0 {
+		return ""
+	}
+	return "export " + strings.Join(vars, " ")
+}
+
+// ProjectMetaStore persists ProjectMeta per repository path in a JSON file.
+type ProjectMetaStore struct {
+	path string
+	mu   sync.RWMutex
+	meta map[string]ProjectMeta
+}
+
+func NewProjectMetaStore(path string) *ProjectMetaStore {
+	store := &ProjectMetaStore{path: path, meta: make(map[string]ProjectMeta)}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, &store.meta); err != nil {
+			log.Printf("⚠️ Project metadata parse failed: %v", err)
+		}
+	}
+	return store
+}
+
+func projectMetaKey(repoPath string) string {
+	return strings.TrimRight(strings.Replace(repoPath, "\\", "/", -1), "/")
+}
+
+func (m *ProjectMetaStore) Get(repoPath string) ProjectMeta {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.meta[projectMetaKey(repoPath)]
+}
+
+func (m *ProjectMetaStore) All() map[string]ProjectMeta {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	all := make(map[string]ProjectMeta, len(m.meta))
+	for repoPath, meta := range m.meta {
+		all[repoPath] = meta
+	}
+	return all
+}
+
+func (m *ProjectMetaStore) Set(repoPath string, meta ProjectMeta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.meta[projectMetaKey(repoPath)] = meta
+	data, err := json.MarshalIndent(m.meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, data, 0644)
+}
+
+// ProfileStore persists the saved server profiles in a JSON file keyed by profile ID.
+// Like config.json, SSH key passphrases are stored encrypted.
+type ProfileStore struct {
+	path     string
+	mu       sync.RWMutex
+	profiles map[string]Profile
+}
+
+func NewProfileStore(path string) *ProfileStore {
+	store := &ProfileStore{path: path, profiles: make(map[string]Profile)}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, &store.profiles); err != nil {
+			log.Printf("⚠️ Profiles parse failed: %v", err)
+		}
+	}
+	for id, profile := range store.profiles {
+		if profile.SSHKeyPassphrase != "" {
+			passphrase, err := decryptSecret(profile.SSHKeyPassphrase)
+			if err != nil {
+				log.Printf("❌ SSH key passphrase of profile %s could not be decrypted: %v", id, err)
+			}
+			profile.SSHKeyPassphrase = passphrase
+			store.profiles[id] = profile
+		}
+	}
+	return store
+}
+
+// All returns the profiles sorted by name.
+func (p *ProfileStore) All() []Profile {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	all := make([]Profile, 0, len(p.profiles))
+	for _, profile := range p.profiles {
+		all = append(all, profile)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+func (p *ProfileStore) Get(id string) (Profile, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	profile, ok := p.profiles[id]
+	return profile, ok
+}
+
+func (p *ProfileStore) Set(profile Profile) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.profiles[profile.ID] = profile
+	return p.save()
+}
+
+func (p *ProfileStore) Delete(id string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.profiles, id)
+	return p.save()
+}
+
+// save writes the profiles with encrypted passphrases; the caller must hold the lock.
+func (p *ProfileStore) save() error {
+	stored := make(map[string]Profile, len(p.profiles))
+	for id, profile := range p.profiles {
+		if profile.SSHKeyPassphrase != "" {
+			encrypted, err := encryptSecret(profile.SSHKeyPassphrase)
+			if err != nil {
+				return fmt.Errorf("passphrase encryption failed: %v", err)
+			}
+			profile.SSHKeyPassphrase = encrypted
+		}
+		stored[id] = profile
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p.path, data, 0600)
+}
+
+func newProfileID() (string, error) {
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// BackupScheduler runs the per-project S3 backups configured via ProjectMeta.BackupCron.
+type BackupScheduler struct {
+	cron    *cron.Cron
+	mu      sync.Mutex
+	entries map[string]cron.EntryID
+}
+
+func NewBackupScheduler() *BackupScheduler {
+	return &BackupScheduler{cron: cron.New(), entries: make(map[string]cron.EntryID)}
+}
+
+func (b *BackupScheduler) Start() {
+	for repoPath, meta := range projectMetaStore.All() {
+		if err := b.Schedule(repoPath, meta.BackupCron); err != nil {
+			log.Printf("⚠️ Backup schedule for %s ignored: %v", repoPath, err)
+		}
+	}
+	b.cron.Start()
+}
+
+// Stop stops scheduling backups; the returned context is done once the running ones have finished.
+func (b *BackupScheduler) Stop() context.Context {
+	return b.cron.Stop()
+}
+
+// Schedule replaces the repository's backup job; an empty spec only removes it.
+func (b *BackupScheduler) Schedule(repoPath, spec string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := projectMetaKey(repoPath)
+	if id, ok := b.entries[key]; ok {
+		b.cron.Remove(id)
+		delete(b.entries, key)
+	}
+	if spec == "" {
+		return nil
+	}
+
+	id, err := b.cron.AddFunc(spec, func() {
+		log.Printf("⏰ Scheduled S3 backup: %s", key)
+		if err := ensureSSHConnection(); err != nil {
+			log.Printf("❌ Scheduled backup skipped, SSH connection error: %v", err)
+			return
+		}
+		if _, err := sshManager.BackupToS3(key, "HEAD"); err != nil {
+			log.Printf("❌ Scheduled backup failed for %s: %v", key, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	b.entries[key] = id
+	log.Printf("⏰ Backup scheduled for %s: %s", key, spec)
+	return nil
+}
+
+// HTTP Handlers
+// rateLimitPollInterval is how often TokenPool checks the remaining API requests of every token.
+const rateLimitPollInterval = 5 * time.Minute
+
+// TokenPool hands out the GitHub token with the most API requests left. Expired and exhausted tokens are skipped,
+// and switching away from one is logged as an alert that the main page shows until the next rotation.
+type TokenPool struct {
+	mu        sync.Mutex
+	tokens    []TokenEntry
+	active    string
+	lastAlert string
+}
+
+func NewTokenPool() *TokenPool {
+	return &TokenPool{}
+}
+
+// SetTokens replaces the configured tokens, keeping what is known about the request counts of tokens that stay.
+func (p *TokenPool) SetTokens(entries []TokenEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	known := make(map[string]int, len(p.tokens))
+	for _, entry := range p.tokens {
+		known[entry.Token] = entry.RemainingRequests
+	}
+
+	p.tokens = make([]TokenEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Token == "" {
+			continue
+		}
+		remaining, ok := known[entry.Token]
+		if !ok {
+			remaining = -1
+		}
+		entry.RemainingRequests = remaining
+		p.tokens = append(p.tokens, entry)
+	}
+}
+
+// tokenProblem says why a token cannot be used right now, or returns "" when it can.
+func tokenProblem(entry TokenEntry, now time.Time) string {
+	if !entry.ExpiresAt.IsZero() && !now.Before(entry.ExpiresAt) {
+		return "expired"
+	}
+	if entry.RemainingRequests == 0 {
+		return "out of API requests"
+	}
+	return ""
+}
+
+// Token returns the usable token with the most remaining requests, or "" when there is none.
+func (p *TokenPool) Token() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	best := -1
+	for i, entry := range p.tokens {
+		if tokenProblem(entry, now) != "" {
+			continue
+		}
+		if best < 0 || entry.RemainingRequests > p.tokens[best].RemainingRequests {
+			best = i
+		}
+	}
+
+	previous := p.active
+	if best < 0 {
+		if previous != "" {
+			p.alert("🚨 No usable GitHub token left: every configured token is expired or out of API requests")
+		}
+		p.active = ""
+		return ""
+	}
+
+	chosen := p.tokens[best]
+	if previous != "" && previous != chosen.Token {
+		for _, entry := range p.tokens {
+			if entry.Token == previous {
+				if problem := tokenProblem(entry, now); problem != "" {
+					p.alert(fmt.Sprintf("🔁 GitHub token %q is %s, switched to %q", entry.Label, problem, chosen.Label))
+				}
+			}
+		}
+	}
+	p.active = chosen.Token
+	return chosen.Token
+}
+
+func (p *TokenPool) alert(message string) {
+	log.Print(message)
+	p.lastAlert = message
+}
+
+// LastAlert returns the most recent rotation alert.
+func (p *TokenPool) LastAlert() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastAlert
+}
+
+// Redact replaces every configured token in text with "***", also where it is encoded by githubBasicAuth.
+func (p *TokenPool) Redact(text string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, entry := range p.tokens {
+		text = strings.ReplaceAll(text, githubBasicAuth(entry.Token), "***")
+		text = strings.ReplaceAll(text, entry.Token, "***")
+	}
+	return text
+}
+
+// Observe records the X-RateLimit-Remaining header of a GitHub API response made with token.
+func (p *TokenPool) Observe(token string, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	p.update(token, remaining, time.Time{})
+}
+
+func (p *TokenPool) update(token string, remaining int, expiresAt time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := range p.tokens {
+		if p.tokens[i].Token != token {
+			continue
+		}
+		p.tokens[i].RemainingRequests = remaining
+		if p.tokens[i].ExpiresAt.IsZero() && !expiresAt.IsZero() {
+			p.tokens[i].ExpiresAt = expiresAt
+		}
+	}
+}
+
+// Start polls the rate limit of every token in the background.
+func (p *TokenPool) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			p.Refresh()
+			<-ticker.C
+		}
+	}()
+}
+
+// Refresh asks GitHub for the remaining requests of every token, then rotates if the active one ran out.
+func (p *TokenPool) Refresh() {
+	p.mu.Lock()
+	tokens := make([]TokenEntry, len(p.tokens))
+	copy(tokens, p.tokens)
+	p.mu.Unlock()
+
+	for _, entry := range tokens {
+		remaining, expiresAt, err := fetchRateLimit(entry.Token)
+		if err != nil {
+			log.Printf("⚠️ Rate limit check failed for GitHub token %q: %v", entry.Label, err)
+			continue
+		}
+		p.update(entry.Token, remaining, expiresAt)
+	}
+	if len(tokens) > 0 {
+		p.Token()
+	}
+}
+
+// fetchRateLimit returns the core API requests left for token and, when GitHub reports one, its expiry time.
+// A token GitHub rejects is reported as having no requests left.
+func fetchRateLimit(token string) (int, time.Time, error) {
+	req, err := http.NewRequest("GET", "https://api.github.com/rate_limit", nil)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return 0, time.Time{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, time.Time{}, fmt.Errorf("GitHub API returned %s", resp.Status)
+	}
+
+	var limits struct {
+		Rate struct {
+			Remaining int `json:"remaining"`
+		} `json:"rate"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&limits); err != nil {
+		return 0, time.Time{}, fmt.Errorf("rate limit response parse failed: %v", err)
+	}
+
+	// e.g. "2026-11-30 12:00:00 UTC"; only sent for tokens that have an expiry date
+	var expiresAt time.Time
+	if value := resp.Header.Get("GitHub-Authentication-Token-Expiration"); value != "" {
+		for _, layout := range []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"} {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				expiresAt = parsed
+				break
+			}
+		}
+	}
+	return limits.Rate.Remaining, expiresAt, nil
+}
+
+// maxOperationOutput caps the stdout and stderr kept per record, so listing files or logs does not bloat the operation log.
+const maxOperationOutput = 64 << 10
+
+// maxOperationLogBytes is the size at which the operation log is rotated to <path>.1, replacing the previous one.
+const maxOperationLogBytes = 16 << 20
+
+// OperationRecord is one command run on the server.
+type OperationRecord struct {
+	ID         string    `json:"id"`
+	ProfileID  string    `json:"profile_id"`
+	Command    string    `json:"command"`
+	ExitCode   int       `json:"exit_code"`
+	DurationMs int64     `json:"duration_ms"`
+	Stdout     string    `json:"stdout"`
+	Stderr     string    `json:"stderr"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// OperationLog appends every command run over SSH to a file as one JSON record per line.
+// GitHub tokens are redacted from the command and its output before they are written.
+// Once the file reaches maxBytes it is renamed to <path>.1, so at most two files' worth of records are kept.
+type OperationLog struct {
+	path     string
+	maxBytes int64
+	mu       sync.Mutex
+	seq      int64
+}
+
+func NewOperationLog(path string) *OperationLog {
+	return &OperationLog{path: path, maxBytes: maxOperationLogBytes}
+}
+
+// files returns the log files, newest first.
+func (l *OperationLog) files() []string {
+	return []string{l.path, l.path + ".1"}
+}
+
+func truncateOperationOutput(output string) string {
+	if len(output) <= maxOperationOutput {
+		return output
+	}
+	return output[:maxOperationOutput] + "\n... (truncated)"
+}
+
+func (l *OperationLog) Append(record OperationRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	record.ID = fmt.Sprintf("%d-%d", record.Timestamp.UnixNano(), l.seq)
+	record.Command = githubTokens.Redact(record.Command)
+	record.Stdout = truncateOperationOutput(githubTokens.Redact(record.Stdout))
+	record.Stderr = truncateOperationOutput(githubTokens.Redact(record.Stderr))
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(l.path); err == nil && info.Size()+int64(len(data))+1 > l.maxBytes {
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// List returns up to limit records, newest first, after skipping offset of them, along with the total number of records.
+// The files are read backwards from their end, and only until the page is complete.
+func (l *OperationLog) List(limit, offset int) ([]OperationRecord, int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records := []OperationRecord{}
+	total := 0
+	for _, path := range l.files() {
+		count, err := countLines(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		total += count
+
+		if len(records) >= limit {
+			continue
+		}
+		err = readLinesBackward(path, func(line []byte) bool {
+			if offset > 0 {
+				offset--
+				return true
+			}
+
+			var record OperationRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				log.Printf("⚠️ Operation log record in %s parse failed: %v", path, err)
+				return true
+			}
+			records = append(records, record)
+			return len(records) < limit
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return records, total, nil
+}
+
+// Purge deletes all records.
+func (l *OperationLog) Purge() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, path := range l.files() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// countLines counts the newline terminated lines of a file without loading it.
+func countLines(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	count := 0
+	buf := make([]byte, 64<<10)
+	for {
+		n, err := file.Read(buf)
+		count += bytes.Count(buf[:n], []byte{'\n'})
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// readLinesBackward calls fn with the non-empty lines of a file from last to first, until fn returns false.
+// The line passed to fn is only valid during the call.
+func readLinesBackward(path string, fn func(line []byte) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	chunk := make([]byte, 64<<10)
+	var partial []byte // the start of the last line seen, which continues in the chunk read before
+	for pos := info.Size(); pos > 0; {
+		n := min(int64(len(chunk)), pos)
+		pos -= n
+		if _, err := file.ReadAt(chunk[:n], pos); err != nil {
+			return err
+		}
+
+		data := append(chunk[:n:n], partial...)
+		for {
+			i := bytes.LastIndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			if line := data[i+1:]; len(line) > 0 && !fn(line) {
+				return nil
+			}
+			data = data[:i]
+		}
+		partial = append([]byte(nil), data...)
+	}
+
+	if len(partial) > 0 {
+		fn(partial)
+	}
+	return nil
+}
+
+var sshManager *SSHManager
+var config *Config
+
+// configMu serializes the handlers that replace config and sshManager, so none of them starts from settings that
+// another one is replacing and drops its change.
+var configMu sync.Mutex
+
+var projectCache = NewProjectListCache("project-cache.json")
+var projectMetaStore = NewProjectMetaStore("project-meta.json")
+var profileStore = NewProfileStore("profiles.json")
+var backupScheduler = NewBackupScheduler()
+var githubTokens = NewTokenPool()
+var operationLog = NewOperationLog("operations.log")
+var serverStarted = time.Now()
+
+// backgroundJobs tracks work that outlives its request, such as pulls started from Slack, so shutdown can wait for it.
+var backgroundJobs sync.WaitGroup
+var listenAddr string // resolved in main from --addr, the config and defaultListenAddr
+
+func main() {
+	logFormat := flag.String("log-format", "text", "format of the SSH connection, command and clone/pull/push/status/remove log messages, text or json; other messages are always text")
+	metricsAuthToken := flag.String("metrics-auth-token", "", "bearer token required to read /metrics (default: no token)")
+	shutdownTimeout := flag.Duration("shutdown-timeout", defaultShutdownTimeout, "how long running requests may finish after SIGINT/SIGTERM")
+	addr := flag.String("addr", "", "address to listen on, e.g. 127.0.0.1:9000 (overrides listen_addr in config.json)")
+	flag.Parse()
+
+	switch *logFormat {
+	case "text":
+	case "json":
+		defaultLogger = NewJSONLogger(os.Stderr)
+	default:
+		log.Fatalf("❌ Unknown log format %q (allowed: text, json)", *logFormat)
+	}
+
+	// Load config
+	config = loadConfig()
+	sshManager = NewSSHManager(config)
+
+	listenAddr = config.ListenAddr
+	if *addr != "" {
+		listenAddr = *addr
+	}
+	if listenAddr == "" {
+		listenAddr = defaultListenAddr
+	}
+	if err := validateListenAddr(listenAddr); err != nil {
+		log.Fatalf("❌ Invalid listen address: %v", err)
+	}
+
+	if config.APIKey == "" {
+		apiKey, err := newAPIKey()
+		if err != nil {
+			log.Fatalf("❌ API key generation failed: %v", err)
+		}
+		config.APIKey = apiKey
+		if err := saveConfig(config); err != nil {
+			log.Printf("⚠️ API key not saved: %v", err)
+		}
+		fmt.Printf("🔑 Generated API key: %s\nSend it as \"Authorization: Bearer <key>\" or \"X-API-Key: <key>\".\n", apiKey)
+	}
+	if !webUILoginConfigured() {
+		log.Printf("🔑 No web UI login is configured: sign in to the web UI with the API key as password")
+	}
+	githubTokens.SetTokens(config.GitHubTokens)
+
+	// SSH connection (if configured)
+	if config.IsConfigured {
+		if err := sshManager.Connect(); err != nil {
+			log.Printf("SSH connection error: %v", err)
+		} else {
+			projectCache.Invalidate()
+		}
+	}
+
+	backupScheduler.Start()
+	githubTokens.Start(rateLimitPollInterval)
+
+	// HTTP routes
+	http.HandleFunc("/", indexHandler)
+	http.HandleFunc("/setup", setupHandler)
+	http.HandleFunc("/save-config", saveConfigHandler)
+	http.HandleFunc("/test-connection", testConnectionHandler)
+	http.HandleFunc("/ws/test-connection", testConnectionProgressHandler)
+	http.HandleFunc("/profiles", profilesHandler)
+	http.HandleFunc("/profiles/{id}", profileHandler)
+	http.HandleFunc("/profiles/{id}/activate", profileActivateHandler)
+	http.HandleFunc("/operations", operationsHandler)
+	http.HandleFunc("/test-connection/trust", trustHostHandler)
+	http.HandleFunc("/projects", projectsHandler)
+	http.HandleFunc("/git/clone", gitCloneHandler)
+	http.HandleFunc("/git/pull", gitPullHandler)
+	http.HandleFunc("/git/push", gitPushHandler)
+	http.HandleFunc("/git/status", gitStatusHandler)
+	http.HandleFunc("/git/diff", gitDiffHandler)
+	http.HandleFunc("/git/diff/staged", gitDiffStagedHandler)
+	http.HandleFunc("/git/diff/stat", gitDiffStatHandler)
+	http.HandleFunc("/git/fetch", gitFetchHandler)
+	http.HandleFunc("/git/status/changes", gitStatusChangesHandler)
+	http.HandleFunc("/git/ls-files", gitLsFilesHandler)
+	http.HandleFunc("/git/assume-unchanged", gitAssumeUnchangedHandler)
+	http.HandleFunc("/git/remove", gitRemoveHandler)
+	http.HandleFunc("/config", configHandler)
+	http.HandleFunc("/config/commit-template", commitTemplateHandler)
+	http.HandleFunc("/admin/orphans", adminOrphansHandler)
+	http.HandleFunc("/git/log/filtered", gitFilteredLogHandler)
+	http.HandleFunc("/git/bisect/visualize", gitBisectVisualizeHandler)
+	http.HandleFunc("/git/sparse-checkout/reapply", gitSparseCheckoutReapplyHandler)
+	http.HandleFunc("/files/copy", filesCopyHandler)
+	http.HandleFunc("/files/upload", filesUploadHandler)
+	http.HandleFunc("/files/download", filesDownloadHandler)
+	http.HandleFunc("/files/content", filesContentHandler)
+	http.HandleFunc("/files/list", filesListHandler)
+	http.HandleFunc("/git/filter-repo", gitFilterRepoHandler)
+	http.HandleFunc("/git/refs", gitRefsHandler)
+	http.HandleFunc("/git/symbolic-ref", gitSymbolicRefHandler)
+	http.HandleFunc("/git/deploy-ref", gitDeployRefHandler)
+	http.HandleFunc("/git/blame/ignore", gitBlameIgnoreHandler)
+	http.HandleFunc("/git/maintenance/register", gitMaintenanceRegisterHandler)
+	http.HandleFunc("/slack/command", slackCommandHandler)
+	http.HandleFunc("/git/repack", gitRepackHandler)
+	http.HandleFunc("/git/count-objects", gitCountObjectsHandler)
+	http.HandleFunc("/git/reflog/expire", gitReflogExpireHandler)
+	http.HandleFunc("/git/reset", gitResetHandler)
+	http.HandleFunc("/git/pull-all", gitPullAllHandler)
+	http.HandleFunc("/github/gists", githubGistsHandler)
+	http.HandleFunc("/git/stash/branch", gitStashBranchHandler)
+	http.HandleFunc("/git/stash", gitStashHandler)
+	http.HandleFunc("/git/stash/pop", gitStashPopHandler)
+	http.HandleFunc("/git/stash/list", gitStashListHandler)
+	http.HandleFunc("/git/bundle/fetch", gitBundleFetchHandler)
+	http.HandleFunc("/git/subtree/split", gitSubtreeSplitHandler)
+	http.HandleFunc("/projects/meta", projectMetaHandler)
+	http.HandleFunc("/git/log", gitLogHandler)
+	http.HandleFunc("/git/commits", gitShowCommitsHandler)
+	http.HandleFunc("/git/log/pickaxe", gitPickaxeHandler)
+	http.HandleFunc("/git/log/since", gitLogSinceHandler)
+	http.HandleFunc("/git/commit-count", gitCommitCountHandler)
+	http.HandleFunc("/git/log/cherry", gitCherryLogHandler)
+	http.HandleFunc("/git/reflog/walk", gitReflogWalkHandler)
+	http.HandleFunc("/git/branches", gitBranchesHandler)
+	http.HandleFunc("/git/branches/create", gitCreateBranchHandler)
+	http.HandleFunc("/git/checkout", gitCheckoutHandler)
+	http.HandleFunc("/git/submodule/update", gitSubmoduleUpdateHandler)
+	http.HandleFunc("/git/submodule/status", gitSubmoduleStatusHandler)
+	http.HandleFunc("/git/activity", gitActivityHandler)
+	http.HandleFunc("/git/cherry-pick", gitCherryPickHandler)
+	http.HandleFunc("/git/fixup", gitFixupHandler)
+	http.HandleFunc("/git/rebase/autosquash", gitAutosquashRebaseHandler)
+	http.HandleFunc("/git/notes/all", gitNotesAllHandler)
+	http.HandleFunc("/git/notes/orphaned", gitNotesOrphanedHandler)
+	http.HandleFunc("/git/notes/prune", gitNotesPruneHandler)
+	http.HandleFunc("/git/notes/merge", gitNotesMergeHandler)
+	http.HandleFunc("/git/search/commits", gitSearchCommitsHandler)
+	http.HandleFunc("/git/merge-base", gitMergeBaseHandler)
+	http.HandleFunc("/git/trailers", gitTrailersHandler)
+	http.HandleFunc("/git/check-ignore", gitCheckIgnoreHandler)
+	http.HandleFunc("/git/backup/s3", gitBackupS3Handler)
+	http.HandleFunc("/git/credentials/test", gitCredentialsTestHandler)
+	http.HandleFunc("/git/pack-objects", gitPackObjectsHandler)
+	http.HandleFunc("/git/remotes", gitRemotesHandler)
+	http.HandleFunc("/git/remotes/rename", gitRemoteRenameHandler)
+	http.HandleFunc("/git/tags", gitTagsHandler)
+	http.HandleFunc("/git/tags/verify", gitTagVerifyHandler)
+	http.HandleFunc("/git/range-diff", gitRangeDiffHandler)
+	http.HandleFunc("/git/worktrees", gitWorktreesHandler)
+	http.HandleFunc("/git/worktrees/lock", gitWorktreeLockHandler)
+	http.HandleFunc("/git/worktrees/unlock", gitWorktreeUnlockHandler)
+	http.HandleFunc("/browse/{project}", browseTreeHandler)
+	http.HandleFunc("/browse/{project}/tree/{ref}/{path...}", browseTreeHandler)
+	http.HandleFunc("/browse/{project}/blob/{ref}/{path...}", browseBlobHandler)
+	http.HandleFunc("/system/processes", systemProcessesHandler)
+	http.HandleFunc("/system/processes/{pid}/signal", systemProcessSignalHandler)
+	http.HandleFunc("/health", healthHandler)
+	http.HandleFunc("/ready", readyHandler)
+	http.HandleFunc("/auth/rotate-key", authRotateKeyHandler)
+	registerMetrics(http.DefaultServeMux, *metricsAuthToken)
+
+	// Static files
+	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static/"))))
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if errors.Is(err, syscall.EADDRINUSE) {
+		_, port, _ := net.SplitHostPort(listenAddr)
+		log.Fatalf("❌ Port %s is already in use by another process; stop it or choose another address with --addr or listen_addr in config.json", port)
+	}
+	if err != nil {
+		log.Fatalf("❌ Listening on %s failed: %v", listenAddr, err)
+	}
+
+	host, port, _ := net.SplitHostPort(listenAddr)
+	if host == "" {
+		host = "localhost"
+	}
+	log.Printf("Server started: http://%s", net.JoinHostPort(host, port))
+	handler := ipFilterMiddleware(config.IPAllowlist, config.IPDenylist)(
+		apiKeyMiddleware(http.DefaultServeMux, basicAuthMiddleware(http.DefaultServeMux)))
+	server := &http.Server{Handler: handler}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	err = serveUntilSignal(server, listener, signals, *shutdownTimeout, func(ctx context.Context) {
+		select {
+		case <-backupScheduler.Stop().Done():
+		case <-ctx.Done():
+			log.Printf("⚠️ Backups still running at shutdown")
+		}
+		if err := waitForBackgroundJobs(ctx); err != nil {
+			log.Printf("⚠️ Background jobs still running at shutdown")
+		}
+		sshManager.Disconnect()
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("👋 Server stopped")
+}
+
+// serveUntilSignal serves on listener until a signal arrives, then stops accepting requests but lets running git
+// operations finish, so a container stop does not interrupt a commit or push halfway through. Running requests and
+// cleanup share timeout; serveUntilSignal returns once both are done.
+func serveUntilSignal(server *http.Server, listener net.Listener, signals <-chan os.Signal, timeout time.Duration, cleanup func(ctx context.Context)) error {
+	stopped := make(chan struct{})
+	go func() {
+		sig := <-signals
+		log.Printf("🛑 %v received, waiting up to %v for running requests", sig, timeout)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("⚠️ Requests still running at shutdown: %v", err)
+		}
+		cleanup(ctx)
+		close(stopped)
+	}()
+
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	<-stopped
+	return nil
+}
+
+// waitForBackgroundJobs waits until backgroundJobs are done, or returns ctx's error once ctx ends first.
+func waitForBackgroundJobs(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		backgroundJobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// validateListenAddr checks that addr is a host:port pair with a numeric port, e.g. ":8080" or "127.0.0.1:9000".
+func validateListenAddr(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+		return fmt.Errorf("port %q must be a number between 1 and 65535", port)
+	}
+	return nil
+}
+
+func loadConfig() *Config {
+	data, err := os.ReadFile("config.json")
+	if err != nil {
+		// Default config
+		return &Config{
+			SSHHost:      "",
+			SSHPort:      "22",
+			SSHUser:      "root",
+			SSHKeyPath:   "",
+			SSHPassword:  "",
+			AuthMethod:   "password",
+			WorkingDir:   "/root/projects",
+			IsConfigured: false,
+		}
+	}
+
+	var cfg Config
+	json.Unmarshal(data, &cfg)
+
+	// Older configurations stored a single github_token
+	var legacy struct {
+		GitHubToken string `json:"github_token"`
+	}
+	json.Unmarshal(data, &legacy)
+	if len(cfg.GitHubTokens) == 0 && legacy.GitHubToken != "" {
+		cfg.GitHubTokens = []TokenEntry{{Token: legacy.GitHubToken, Label: "default"}}
+	}
+
+	if cfg.SSHKeyPassphrase != "" {
+		passphrase, err := decryptSecret(cfg.SSHKeyPassphrase)
+		if err != nil {
+			log.Printf("❌ SSH key passphrase could not be decrypted: %v", err)
+		}
+		cfg.SSHKeyPassphrase = passphrase
+	}
+
+	if cfg.APIKey != "" {
+		apiKey, err := decryptSecret(cfg.APIKey)
+		if err != nil {
+			log.Printf("❌ API key could not be decrypted: %v", err)
+		}
+		cfg.APIKey = apiKey
+	}
+
+	if cfg.WebUIPassword != "" && !isPasswordHash(cfg.WebUIPassword) {
+		hash, err := bcrypt.GenerateFromPassword([]byte(cfg.WebUIPassword), bcrypt.DefaultCost)
+		if err != nil {
+			log.Fatalf("❌ Web UI password hashing failed: %v", err)
+		}
+		cfg.WebUIPassword = string(hash)
+		if err := saveConfig(&cfg); err != nil {
+			log.Printf("⚠️ Hashed web UI password not saved: %v", err)
+		} else {
+			log.Printf("🔐 Web UI password in config.json replaced by its hash")
+		}
+	}
+	return &cfg
+}
+
+func isPasswordHash(password string) bool {
+	_, err := bcrypt.Cost([]byte(password))
+	return err == nil
+}
+
+func saveConfig(cfg *Config) error {
+	// Encrypt secrets in a copy so the running configuration keeps the plain values
+	stored := *cfg
+	if stored.SSHKeyPassphrase != "" {
+		encrypted, err := encryptSecret(stored.SSHKeyPassphrase)
+		if err != nil {
+			return fmt.Errorf("passphrase encryption failed: %v", err)
+		}
+		stored.SSHKeyPassphrase = encrypted
+	}
+	if stored.APIKey != "" {
+		encrypted, err := encryptSecret(stored.APIKey)
+		if err != nil {
+			return fmt.Errorf("API key encryption failed: %v", err)
+		}
+		stored.APIKey = encrypted
+	}
+
+	data, err := json.MarshalIndent(&stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile("config.json", data, 0644)
+}
+
+// configKeyFile holds the random master secret that config encryption keys are derived from.
+const configKeyFile = "config.key"
+
+// encryptedPrefix marks config values written by encryptSecret.
+const encryptedPrefix = "enc:"
+
+// secretCipher derives an AES-256-GCM cipher from the master secret, creating the secret on first use.
+func secretCipher() (cipher.AEAD, error) {
+	master, err := os.ReadFile(configKeyFile)
+	if os.IsNotExist(err) {
+		master = make([]byte, 32)
+		if _, err := rand.Read(master); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(configKeyFile, master, 0600); err != nil {
+			return nil, err
+		}
+		log.Printf("🔐 Created %s", configKeyFile)
+	} else if err != nil {
+		return nil, err
+	}
+
+	key, err := hkdf.Key(sha256.New, master, nil, "remote-git-manager config secrets", 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encryptSecret(plain string) (string, error) {
+	aead, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret reverses encryptSecret; values without the prefix were saved in plain text and are returned as is.
+func decryptSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+	aead, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("encrypted value is too short")
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func indexHandler(w http.ResponseWriter, r *http.Request) {
+	// Redirect to setup if not configured
+	if !config.IsConfigured {
+		http.Redirect(w, r, "/setup", http.StatusFound)
+		return
+	}
+
+	tmpl := `<!DOCTYPE html>
+<html>
+<head>
+    <title>SSH GitHub Manager</title>
+    <meta charset="UTF-8">
+    <style>
+        body { font-family: Arial, sans-serif; margin: 20px; background: #f5f5f5; }
+        .container { max-width: 1200px; margin: 0 auto; background: white; padding: 20px; border-radius: 10px; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
+        .header { text-align: center; margin-bottom: 30px; color: #333; }
+        .config-info { background: #e8f4f8; padding: 15px; border-radius: 5px; margin-bottom: 20px; }
+        .section { margin: 20px 0; padding: 20px; border: 1px solid #ddd; border-radius: 5px; }
+        .git-actions { display: flex; gap: 10px; flex-wrap: wrap; }
+        .btn { padding: 10px 20px; background: #007bff; color: white; border: none; border-radius: 5px; cursor: pointer; }
+        .btn:hover { background: #0056b3; }
+        .btn-success { background: #28a745; }
+        .btn-success:hover { background: #1e7e34; }
+        .btn-warning { background: #ffc107; color: #212529; }
+        .btn-warning:hover { background: #e0a800; }
+        .btn-danger { background: #dc3545; }
+        .btn-danger:hover { background: #c82333; }
+        .btn-secondary { background: #6c757d; }
+        .btn-secondary:hover { background: #5a6268; }
+        .form-group { margin: 10px 0; }
+        .form-group label { display: block; margin-bottom: 5px; font-weight: bold; }
+        .form-group input, .form-group select, .form-group textarea { width: 100%; padding: 8px; border: 1px solid #ddd; border-radius: 4px; box-sizing: border-box; font-family: inherit; }
+        .form-group input[type="checkbox"] { width: auto; }
+        .projects-list { border: 1px solid #ddd; border-radius: 5px; max-height: 500px; overflow-y: auto; }
+        .project-item { padding: 15px; border-bottom: 1px solid #eee; display: flex; align-items: center; justify-content: space-between; }
+        .project-item:hover { background: #f8f9fa; }
+        .project-item:last-child { border-bottom: none; }
+        .project-info { flex: 1; }
+        .project-name { font-weight: bold; color: #333; margin-bottom: 5px; }
+        .project-path { font-size: 0.9em; color: #666; }
+        .project-actions { display: flex; gap: 8px; flex-wrap: wrap; }
+        .btn-sm { padding: 8px 12px; font-size: 0.85em; }
+        .loading-text { text-align: center; padding: 20px; color: #666; }
+        .modal { display: none; position: fixed; top: 0; left: 0; width: 100%; height: 100%; background: rgba(0,0,0,0.5); z-index: 1000; }
+        .modal-content { position: absolute; top: 50%; left: 50%; transform: translate(-50%, -50%); background: white; padding: 30px; border-radius: 10px; min-width: 400px; }
+        .modal-header { margin-bottom: 20px; }
+        .modal-footer { margin-top: 20px; text-align: right; }
+        .modal-wide { width: 800px; max-width: 95%; max-height: 90vh; overflow-y: auto; }
+        .tool-section { margin: 15px 0; padding: 15px; border: 1px solid #eee; border-radius: 5px; }
+        .tool-section h4 { margin: 0 0 10px 0; }
+        .tool-row { display: flex; gap: 8px; flex-wrap: wrap; align-items: center; }
+        .log-table { width: 100%; border-collapse: collapse; font-size: 0.9em; }
+        .log-table th, .log-table td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #eee; vertical-align: top; }
+        .log-table td.hash { font-family: monospace; white-space: nowrap; }
+        .log-table tbody tr { cursor: pointer; }
+        .log-table tbody tr:hover { background: #f8f9fa; }
+        .word-add { background: #d4edda; color: #155724; }
+        .word-del { background: #f8d7da; color: #721c24; text-decoration: line-through; }
+        .activity-row { display: flex; align-items: center; gap: 8px; font-size: 0.85em; margin: 2px 0; }
+        .activity-row .period { width: 90px; font-family: monospace; }
+        .activity-row .bar { background: #007bff; height: 12px; border-radius: 2px; min-width: 2px; }
+        .stat-bar { margin-left: 8px; font-family: monospace; font-size: 0.85em; white-space: nowrap; }
+        .stat-add { color: #28a745; }
+        .stat-del { color: #dc3545; }
+        .log-table td.author:hover { text-decoration: underline; }
+        .log-table tr.log-group td { background: #e9ecef; font-weight: bold; cursor: default; }
+        .log-table tr.log-patch td { cursor: default; }
+        .log-table tr.log-patch pre { max-height: 400px; overflow: auto; margin: 0; }
+        .log-table.compact thead { display: none; }
+        .file-viewer { max-height: 500px; overflow: auto; font-size: 13px; }
+        .file-viewer pre { margin: 0; }
+        .file-editor { width: 100%; height: 500px; font-family: monospace; font-size: 13px; box-sizing: border-box; }
+        .log-table.compact td { padding: 2px 8px; border-bottom: none; font-family: monospace; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+        .diff-toggle { margin-left: 8px; }
+        .badge { display: inline-block; padding: 3px 8px; border-radius: 10px; font-size: 0.8em; background: #e9ecef; color: #495057; }
+        .badge.success { background: #d4edda; color: #155724; }
+        .badge.danger { background: #f8d7da; color: #721c24; }
+        .output { background: #f8f9fa; padding: 15px; border-radius: 5px; font-family: monospace; white-space: pre-wrap; max-height: 300px; overflow-y: auto; }
+        .status { padding: 10px; border-radius: 5px; margin: 10px 0; }
+        .status.success { background: #d4edda; color: #155724; border: 1px solid #c3e6cb; }
+        .status.error { background: #f8d7da; color: #721c24; border: 1px solid #f5c6cb; }
+        .status.warning { background: #fff3cd; color: #856404; border: 1px solid #ffeeba; }
+        .help-text { font-size: 12px; color: #666; margin-top: 5px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>🚀 SSH GitHub Manager</h1>
+            <div class="config-info">
+                <strong>🗂️ Profile:</strong>
+                <select id="profileSelect" style="width: auto;" onchange="activateProfile(this.value)">
+                    {{if not .ActiveProfile}}<option value="" selected>(current settings)</option>{{end}}
+                    {{range .Profiles}}<option value="{{.ID}}"{{if eq .ID $.ActiveProfile}} selected{{end}}>{{.Name}}</option>{{end}}
+                </select>
+                <button class="btn btn-secondary btn-sm" onclick="saveAsProfile()">➕ Save as profile</button> |
+                <strong>📡 Server:</strong> {{.Host}} | 
+                <strong>👤 User:</strong> {{.User}} | 
+                <strong>🔐 Auth:</strong> {{.AuthMethod}} | 
+                <strong>📁 Dir:</strong> {{.WorkingDir}} |
+                <strong>🐙 Tokens:</strong> {{if .GitHubTokens}}✅ {{.GitHubTokens}} configured{{else}}❌ Missing{{end}}
+                {{if .TokenAlert}}<div class="status warning">{{.TokenAlert}}</div>{{end}}
+                {{if .PublicUI}}<div class="status warning">⚠️ No web UI login is configured, so the browser signs in with the API key as password. Set a user and password in <a href="/setup">Settings</a>.</div>{{end}}
+                <br>
+                <button class="btn btn-secondary" onclick="window.location.href='/setup'">⚙️ Settings</button>
+                {{if not .GitHubTokens}}
+                <span style="color: #dc3545; font-weight: bold;">⚠️ GitHub Token required!</span>
+                {{end}}
+            </div>
+        </div>
+
+        <div class="section">
+            <h3>📁 Projects <button class="btn btn-sm" id="pullAllButton" onclick="pullAll()" title="Pull every project on the server">⬇️ Pull All</button></h3>
+            <div class="status warning" id="cacheBanner" style="display: none;"></div>
+            <div class="projects-list" id="projectsList">
+                <div class="loading-text">Loading...</div>
+            </div>
+            <button class="btn" onclick="refreshProjects()">🔄 Refresh</button>
+            <button class="btn btn-secondary" onclick="cleanOrphans()">🧹 Clean Orphans</button>
+        </div>
+
+        <div class="section">
+            <h3>📥 Clone Repository</h3>
+            <div class="form-group">
+                <label>Repository URL:</label>
+                <input type="text" id="repoUrl" placeholder="https://github.com/username/repository.git">
+            </div>
+            <div class="form-group">
+                <label>Branch (optional):</label>
+                <input type="text" id="branch" placeholder="main, master, develop...">
+            </div>
+            <div class="form-group">
+                <label>Shallow clone depth (0 = full history):</label>
+                <input type="number" id="cloneDepth" value="0" min="0">
+            </div>
+            <div class="form-group">
+                <label><input type="checkbox" id="cloneRecursive" style="width: auto;"> Clone submodules recursively</label>
+            </div>
+            <button class="btn btn-success" onclick="gitClone()">📥 Clone Repository</button>
+        </div>
+
+        <div class="section">
+            <h3>🔎 Commit Search</h3>
+            <div class="tool-row">
+                <input type="text" id="commitSearchQuery" placeholder="Search commit messages in all projects..." style="flex: 1;">
+                <input type="date" id="commitSearchSince" title="Since">
+                <input type="date" id="commitSearchUntil" title="Until">
+                <button class="btn" onclick="searchCommits()">🔎 Search</button>
+            </div>
+            <div class="loading-text" id="commitSearchStatus" style="display: none;"></div>
+            <table class="log-table" id="commitSearchTable" style="margin-top: 10px; display: none;">
+                <thead><tr><th>Project</th><th>Hash</th><th>Author</th><th>Date</th><th>Message</th></tr></thead>
+                <tbody id="commitSearchBody"></tbody>
+            </table>
+        </div>
+
+        <div class="section">
+            <h3>⚙️ Processes</h3>
+            <div class="tool-row">
+                <input type="text" id="processFilter" placeholder="Filter by command or user..." style="flex: 1;">
+                <button class="btn" onclick="loadProcesses()">🔄 Load Processes</button>
+            </div>
+            <div class="projects-list" id="processList" style="margin-top: 10px; display: none;"></div>
+        </div>
+
+        <div class="section">
+            <h3>🗂️ Files</h3>
+            <div class="tool-row">
+                <button class="btn btn-secondary btn-sm" id="fileBrowserUp" onclick="browseParentDir()" disabled>⬆️ Up</button>
+                <code id="fileBrowserPath" style="flex: 1;"></code>
+                <button class="btn" onclick="browseFiles(fileBrowserState.path)">🔄 Load Files</button>
+            </div>
+            <div class="loading-text" id="fileBrowserStatus" style="display: none;"></div>
+            <table class="log-table" id="fileBrowserTable" style="margin-top: 10px; display: none;">
+                <thead><tr><th>Name</th><th>Size</th><th>Modified</th><th></th></tr></thead>
+                <tbody id="fileBrowserBody"></tbody>
+            </table>
+            <div class="tool-section" id="fileViewer" style="display: none;">
+                <h4 id="fileViewerTitle"></h4>
+                <div class="tool-row">
+                    <button class="btn btn-secondary btn-sm" id="fileEditToggle" onclick="toggleFileEdit()" style="display: none;">✏️ Edit</button>
+                    <button class="btn btn-success btn-sm" id="fileSaveBtn" onclick="saveFileEdit()" style="display: none;">💾 Save</button>
+                    <span class="loading-text" id="fileEditStatus"></span>
+                </div>
+                <div class="file-viewer" id="fileViewerContent"></div>
+                <textarea class="file-editor" id="fileEditor" style="display: none;" oninput="fileViewerState.dirty = true"></textarea>
+            </div>
+        </div>
+
+        <div class="section">
+            <h3>🕘 History</h3>
+            <div class="tool-row">
+                <button class="btn" onclick="loadOperations(0)">🔄 Load History</button>
+                <button class="btn btn-danger btn-sm" onclick="purgeOperations()">🗑️ Clear</button>
+                <span class="loading-text" id="operationsStatus"></span>
+            </div>
+            <table class="log-table" id="operationsTable" style="margin-top: 10px; display: none;">
+                <thead><tr><th></th><th>Time</th><th>Command</th><th>Exit</th><th>Duration</th></tr></thead>
+                <tbody id="operationsBody"></tbody>
+            </table>
+            <div class="tool-row" style="margin-top: 10px;">
+                <button class="btn btn-secondary btn-sm" id="operationsMore" onclick="loadOperations(operationsState.offset)" style="display: none;">⬇️ Load more</button>
+            </div>
+        </div>
+
+        <div class="section">
+            <h3>📝 Output</h3>
+            <div class="output" id="output">Operation results will be shown here...</div>
+            <div class="tool-row" style="margin-top: 10px;">
+                <label><input type="checkbox" id="gistPublic"> Public</label>
+                <button class="btn btn-secondary btn-sm" onclick="shareAsGist()">🔗 Share as Gist</button>
+                <span id="gistLink"></span>
+            </div>
+        </div>
+    </div>
+
+    <!-- Commit Modal -->
+    <div id="commitModal" class="modal">
+        <div class="modal-content">
+            <div class="modal-header">
+                <h3>💾 Commit Message</h3>
+            </div>
+            <div class="form-group">
+                <label>Commit Message:</label>
+                <textarea id="modalCommitMessage" rows="4" placeholder="Update files">Update files</textarea>
+            </div>
+            <div class="form-group">
+                <label>Push to:</label>
+                <select id="modalBranch">
+                    <option value="">Current branch</option>
+                </select>
+            </div>
+            <div class="form-group">
+                <label><input type="checkbox" id="modalSignOff"> Add Signed-off-by</label>
+            </div>
+            <details class="form-group" id="modalDateOverride">
+                <summary>🕰️ Override commit date</summary>
+                <input type="datetime-local" id="modalCommitDate" style="margin-top: 5px;">
+                <div class="help-text">Sets the author and committer date, e.g. when importing older work. Leave empty to use the current time.</div>
+            </details>
+            <div class="status warning" id="modalEmptyWarning" style="display: none;">
+                ⚠️ This repository has no uncommitted changes.
+                <label><input type="checkbox" id="modalAllowEmpty"> Push empty commit (e.g. to trigger a deployment)</label>
+            </div>
+            <div class="form-group" id="modalPendingCommits" style="display: none;">
+                <label id="modalPendingLabel">📤 Unpushed commits that will be pushed too:</label>
+                <ul id="modalPendingList"></ul>
+            </div>
+            <div class="modal-footer">
+                <button class="btn btn-secondary" onclick="closeCommitModal()">❌ Cancel</button>
+                <button class="btn btn-success" onclick="confirmPush()">✅ Commit & Push</button>
+            </div>
+        </div>
+    </div>
+
+    <!-- Pull Modal -->
+    <div id="pullModal" class="modal">
+        <div class="modal-content">
+            <div class="modal-header">
+                <h3>⬇️ Pull</h3>
+            </div>
+            <div id="pullSummary" class="loading-text"></div>
+            <pre class="output" id="pullPreview" style="max-height: 300px; overflow: auto;"></pre>
+            <div class="modal-footer">
+                <button class="btn btn-secondary" onclick="closePullModal()">❌ Cancel</button>
+                <button class="btn btn-warning" id="pullConfirm" onclick="confirmPull()">⬇️ Merge incoming changes</button>
+            </div>
+        </div>
+    </div>
+
+    <!-- Diff Modal -->
+    <div id="diffModal" class="modal">
+        <div class="modal-content modal-wide">
+            <div class="modal-header">
+                <h3 id="diffTitle">🔍 Diff</h3>
+            </div>
+
+            <div class="tool-row">
+                <button class="btn btn-sm diff-mode" data-staged="false" onclick="loadDiff(false)" title="Working tree changes that are not staged yet">📝 Unstaged changes</button>
+                <button class="btn btn-sm btn-secondary diff-mode" data-staged="true" onclick="loadDiff(true)" title="Changes in the index that the next commit will contain">📦 Staged changes</button>
+                <select id="diffMode" style="width: auto;" onchange="loadDiff(currentDiffStaged)" title="Diff granularity">
+                    <option value="line">Line diff</option>
+                    <option value="word">Word diff</option>
+                    <option value="word-regex">Word diff (code tokens)</option>
+                    <option value="color-words">Color words</option>
+                </select>
+                <button class="btn btn-sm btn-secondary" id="diffIgnoreWs" onclick="toggleDiffIgnoreWhitespace()" title="Hide changes that only touch whitespace, e.g. after re-formatting">␣ Ignore whitespace</button>
+            </div>
+
+            <pre class="output" id="diffOutput" style="margin-top: 10px; max-height: 500px; overflow: auto;"></pre>
+
+            <div class="modal-footer">
+                <button class="btn btn-secondary" onclick="closeDiffModal()">❌ Close</button>
+            </div>
+        </div>
+    </div>
+
+    <!-- Log Modal -->
+    <div id="logModal" class="modal">
+        <div class="modal-content modal-wide">
+            <div class="modal-header">
+                <h3 id="logTitle">📜 History</h3>
+            </div>
+
+            <div class="tool-row">
+                <button class="btn btn-sm log-filter" data-filter="" onclick="setLogFilter('')">All commits</button>
+                <button class="btn btn-sm btn-secondary log-filter" data-filter="merges" onclick="setLogFilter('merges')">Merges only</button>
+                <button class="btn btn-sm btn-secondary log-filter" data-filter="no-merges" onclick="setLogFilter('no-merges')">Commits only</button>
+                <span style="margin-left: 10px;">Order:</span>
+                <button class="btn btn-sm log-order" data-order="" onclick="setLogOrder('')">Default</button>
+                <button class="btn btn-sm btn-secondary log-order" data-order="date" onclick="setLogOrder('date')">📅 Date</button>
+                <button class="btn btn-sm btn-secondary log-order" data-order="topo" onclick="setLogOrder('topo')">🌳 Topological</button>
+                <button class="btn btn-sm btn-secondary log-order" data-order="author-date" onclick="setLogOrder('author-date')">✍️ Author date</button>
+                <label style="margin-left: auto;"><input type="checkbox" id="logCompact" onchange="setLogCompact(this.checked)"> Compact</label>
+                <label><input type="checkbox" id="logStats" onchange="setLogStats(this.checked)"> Stats</label>
+                <select id="logHistoryMode" style="width: auto;" onchange="setLogFirstParent(this.value === 'main')">
+                    <option value="full">Full history</option>
+                    <option value="main">Main branch</option>
+                </select>
+                <label><input type="checkbox" id="logRelativeDates" onchange="setRelativeDates(this.checked)"> Relative dates</label>
+                <label>Branch:</label>
+                <select id="logBranch" style="width: auto;" onchange="setLogBranch(this.value)">
+                    <option value="">Current branch</option>
+                    <option value="all">All branches</option>
+                </select>
+            </div>
+
+            <div class="tool-row" style="margin-top: 10px;">
+                <select id="logSearchMode" style="width: auto;">
+                    <option value="message">💬 Message search</option>
+                    <option value="code">⛏️ Code search</option>
+                </select>
+                <input type="text" id="logSearch" placeholder="Search..." style="flex: 1;">
+                <label><input type="checkbox" id="logSearchRegex"> Regex</label>
+                <button class="btn btn-sm" onclick="searchLog()">🔍 Search</button>
+                <button class="btn btn-sm btn-secondary" onclick="clearLogSearch()">✖️ Clear</button>
+            </div>
+
+            <div class="tool-row" style="margin-top: 10px;">
+                <label>From:</label>
+                <input type="date" id="logAfter" style="width: auto;" onchange="setLogDateRange()">
+                <label>To:</label>
+                <input type="date" id="logBefore" style="width: auto;" onchange="setLogDateRange()">
+                <button class="btn btn-sm btn-secondary" onclick="clearLogDateRange()">✖️ Clear dates</button>
+                <button class="btn btn-sm btn-secondary" style="margin-left: auto;" onclick="autosquashRebase()" title="Fold fixup! commits into the commits they fix">🧹 Autosquash rebase</button>
+            </div>
+
+            <pre class="output" id="logActionOutput" style="display: none; margin-top: 10px;"></pre>
+
+            <div class="status warning" id="logAuthorBanner" style="display: none;">
+                👤 Showing commits by <strong id="logAuthorName"></strong>
+                <button class="btn btn-sm btn-secondary" onclick="setLogAuthor('')">✖️ Clear</button>
+            </div>
+
+            <div class="status warning" id="logFileBanner" style="display: none;">
+                📄 Showing commits touching <strong id="logFileName"></strong>
+                <button class="btn btn-sm btn-secondary" onclick="setLogFile('')">✖️ Clear</button>
+            </div>
+
+            <div id="logStatus" class="loading-text"></div>
+            <table class="log-table" id="logTable">
+                <thead>
+                    <tr><th>Commit</th><th>Date</th><th>Author</th><th>Subject</th></tr>
+                </thead>
+                <tbody id="logBody"></tbody>
+            </table>
+
+            <div class="tool-section" id="commitDetail" style="display: none;">
+                <h4 id="commitDetailTitle"></h4>
+                <div class="tool-row" id="commitNoteTabs"></div>
+                <div class="output" id="commitDetailOutput" style="margin-top: 10px;"></div>
+            </div>
+
+            <div class="modal-footer">
+                <button class="btn btn-secondary" onclick="closeLogModal()">❌ Close</button>
+            </div>
+        </div>
+    </div>
+
+    <!-- Tools Modal -->
+    <div id="toolsModal" class="modal">
+        <div class="modal-content modal-wide">
+            <div class="modal-header">
+                <h3 id="toolsTitle">🧰 Repository Tools</h3>
+            </div>
+
+            <div class="tool-section">
+                <h4>🩺 Health</h4>
+                <div class="tool-row">
+                    <span>Scheduled maintenance:</span>
+                    <span class="badge" id="maintenanceBadge">unknown</span>
+                    <button class="btn btn-success btn-sm" onclick="setMaintenance(true)">🛠️ Enroll</button>
+                    <button class="btn btn-secondary btn-sm" onclick="setMaintenance(false)">🚫 Unenroll</button>
+                </div>
+                <div class="tool-row" style="margin-top: 10px;">
+                    <span>Storage:</span>
+                    <span class="badge" id="storageStats">unknown</span>
+                    <label><input type="checkbox" id="repackAggressive"> Aggressive</label>
+                    <button class="btn btn-success btn-sm" onclick="optimizeStorage()">⚡ Optimize storage</button>
+                </div>
+                <div class="tool-row" style="margin-top: 10px;">
+                    <span>Reflog:</span>
+                    <span class="badge" id="reflogSize">unknown</span>
+                    <label>Before:</label>
+                    <input type="date" id="reflogExpireBefore" style="width: auto;" title="Leave empty for git's default (90 days)">
+                    <label><input type="checkbox" id="reflogExpireAll"> All refs</label>
+                    <button class="btn btn-warning btn-sm" onclick="expireReflog()">🧹 Expire old reflog</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>📈 Activity</h4>
+                <div class="tool-row">
+                    <select id="activityPeriod" style="width: auto;">
+                        <option value="day">Daily</option>
+                        <option value="week" selected>Weekly</option>
+                        <option value="month">Monthly</option>
+                    </select>
+                    <label>Since:</label>
+                    <input type="date" id="activitySince" style="width: auto;">
+                    <button class="btn btn-secondary btn-sm" onclick="loadActivity()">📈 Show activity</button>
+                </div>
+                <div id="activityChart" style="margin-top: 10px;"></div>
+            </div>
+
+            <div class="tool-section">
+                <h4>👤 Git Identity</h4>
+                <div class="tool-row">
+                    <input type="text" id="metaUserName" placeholder="Author name (server default)" style="flex: 1;">
+                    <input type="text" id="metaUserEmail" placeholder="Author email (server default)" style="flex: 1;">
+                    <button class="btn btn-success btn-sm" onclick="saveProjectIdentity()">💾 Save</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>☁️ S3 Backup</h4>
+                <div class="tool-row">
+                    <input type="text" id="backupRef" value="HEAD" style="width: 160px;" title="Ref to archive">
+                    <button class="btn btn-secondary btn-sm" onclick="backupToS3()">☁️ Back up now</button>
+                </div>
+                <div class="tool-row" style="margin-top: 10px;">
+                    <input type="text" id="backupCron" placeholder="Schedule, e.g. 0 3 * * * (empty = off)" style="flex: 1;">
+                    <button class="btn btn-success btn-sm" onclick="saveBackupSchedule()">⏰ Save schedule</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>📜 File History</h4>
+                <div class="form-group">
+                    <label>File path (optional):</label>
+                    <input type="text" id="historyPath" placeholder="config.json">
+                </div>
+                <div class="tool-row">
+                    <button class="btn btn-success btn-sm" onclick="loadFilteredLog('A')">➕ Added</button>
+                    <button class="btn btn-warning btn-sm" onclick="loadFilteredLog('M')">✏️ Modified</button>
+                    <button class="btn btn-danger btn-sm" onclick="loadFilteredLog('D')">➖ Deleted</button>
+                    <button class="btn btn-secondary btn-sm" onclick="loadFilteredLog('R')">🔀 Renamed</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>📦 Stash</h4>
+                <div class="tool-row">
+                    <input type="number" id="stashIndex" value="0" min="0" style="width: 80px;" title="Stash index">
+                    <input type="text" id="stashBranchName" placeholder="new-branch-name" style="flex: 1;">
+                    <button class="btn btn-secondary btn-sm" onclick="stashBranch()">🌿 Create branch from stash</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>🎁 Bundle</h4>
+                <div class="tool-row">
+                    <input type="text" id="bundlePath" placeholder="/tmp/backup.bundle" style="flex: 1;">
+                    <button class="btn btn-secondary btn-sm" onclick="applyBundle()">📥 Apply bundle</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>🏷️ Refs</h4>
+                <div class="tool-row">
+                    <button class="btn btn-secondary btn-sm" onclick="loadRefs('refs/heads')">🌿 Branches</button>
+                    <button class="btn btn-secondary btn-sm" onclick="loadRefs('refs/remotes')">🌐 Remote Branches</button>
+                    <button class="btn btn-secondary btn-sm" onclick="loadTags(false)">🏷️ Tags</button>
+                </div>
+                <div class="tool-row">
+                    <input type="text" id="newTagName" placeholder="v1.2.0" style="width: 120px;">
+                    <input type="text" id="newTagMessage" placeholder="Tag message (annotated only)" style="flex: 1;">
+                    <label><input type="checkbox" id="newTagAnnotated" checked style="width: auto;"> Annotated</label>
+                    <button class="btn btn-secondary btn-sm" onclick="createTag()">➕ Tag HEAD</button>
+                </div>
+                <table class="log-table" id="tagsTable" style="margin-top: 10px; display: none;">
+                    <thead><tr><th>Tag</th><th>Commit</th><th>Date</th><th></th></tr></thead>
+                    <tbody id="tagsBody"></tbody>
+                </table>
+            </div>
+
+            <div class="tool-section">
+                <h4>🙈 Assume Unchanged</h4>
+                <div class="tool-row">
+                    <input type="text" id="assumePath" placeholder="config/local.yml" style="flex: 1;">
+                    <button class="btn btn-secondary btn-sm" onclick="setAssumeUnchanged(document.getElementById('assumePath').value.trim(), true)">🙈 Hide local changes</button>
+                    <button class="btn btn-secondary btn-sm" onclick="loadAssumedUnchanged(false)">🔄 List</button>
+                </div>
+                <table class="log-table" id="assumedTable" style="margin-top: 10px; display: none;">
+                    <thead><tr><th>File</th><th></th></tr></thead>
+                    <tbody id="assumedBody"></tbody>
+                </table>
+            </div>
+
+            <div class="tool-section">
+                <h4>🌳 Worktrees</h4>
+                <button class="btn btn-secondary btn-sm" onclick="loadWorktrees(false)">🔄 Load worktrees</button>
+                <table class="log-table" id="worktreesTable" style="margin-top: 10px; display: none;">
+                    <thead><tr><th>Path</th><th>Branch</th><th></th></tr></thead>
+                    <tbody id="worktreesBody"></tbody>
+                </table>
+            </div>
+
+            <div class="tool-section">
+                <h4>🌐 Remotes</h4>
+                <button class="btn btn-secondary btn-sm" onclick="loadRemotes(false)">🔄 Load remotes</button>
+                <table class="log-table" id="remotesTable" style="margin-top: 10px; display: none;">
+                    <thead><tr><th>Name</th><th>URL</th></tr></thead>
+                    <tbody id="remotesBody"></tbody>
+                </table>
+            </div>
+
+            <div class="tool-section">
+                <h4>🎯 HEAD</h4>
+                <div class="form-group">
+                    <label>Target branch ref:</label>
+                    <input type="text" id="symbolicRefTarget" placeholder="refs/heads/main">
+                </div>
+                <div class="tool-row">
+                    <button class="btn btn-secondary btn-sm" onclick="loadSymbolicRef()">🔍 Show HEAD</button>
+                    <button class="btn btn-warning btn-sm" onclick="setSymbolicRef()">🎯 Point HEAD</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>🔀 Compare Branches</h4>
+                <div class="tool-row">
+                    <input type="text" id="compareA" value="HEAD" style="flex: 1;" title="First commit or branch">
+                    <input type="text" id="compareB" placeholder="origin/main" style="flex: 1;" title="Second commit or branch">
+                    <button class="btn btn-secondary btn-sm" onclick="loadMergeBase()">🔀 Find common base</button>
+                    <button class="btn btn-secondary btn-sm" onclick="loadCherryLog()">🍒 Unique commits</button>
+                </div>
+                <div id="cherrySection" style="display: none; margin-top: 10px;">
+                    <strong id="cherryTitle"></strong>
+                    <div id="cherryList"></div>
+                    <button class="btn btn-sm" onclick="cherryPickSelected()">🍒 Cherry-pick selected onto the current branch</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>🛟 Recovery</h4>
+                <div class="tool-row">
+                    <input type="text" id="reflogRef" value="HEAD" style="flex: 1;" title="Ref whose history to show">
+                    <button class="btn btn-secondary btn-sm" onclick="loadReflog()" title="Every position the ref has held, including commits lost by resets and rebases">🕰️ Show timeline</button>
+                </div>
+                <div class="tool-row" style="margin-top: 10px;">
+                    <input type="text" id="resetRef" placeholder="HEAD" style="flex: 1;" title="Commit to move the current branch to, e.g. HEAD~1">
+                    <label title="Keep the changes staged"><input type="radio" name="resetMode" value="soft"> Soft</label>
+                    <label title="Keep the changes in the working tree, unstaged"><input type="radio" name="resetMode" value="mixed" checked> Mixed</label>
+                    <label title="Discard all changes"><input type="radio" name="resetMode" value="hard"> Hard</label>
+                    <button class="btn btn-danger btn-sm" onclick="resetRepository()">↩️ Reset</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>🔁 Compare Rebase</h4>
+                <div class="tool-row">
+                    <input type="text" id="rangeDiffOld" placeholder="main..feature@{1}" style="flex: 1;" title="Commits before the rebase">
+                    <input type="text" id="rangeDiffNew" placeholder="main..feature" style="flex: 1;" title="Commits after the rebase">
+                    <button class="btn btn-secondary btn-sm" onclick="loadRangeDiff()">🔁 Compare</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>🚚 Deploy Ref</h4>
+                <div class="form-group">
+                    <label>Ref:</label>
+                    <input type="text" id="deployRef" placeholder="v1.2.3">
+                </div>
+                <div class="form-group">
+                    <label>Deploy path (relative to the deploy root):</label>
+                    <input type="text" id="deployPath" placeholder="releases/current">
+                </div>
+                <button class="btn btn-warning btn-sm" onclick="deployRef()">🚚 Deploy</button>
+            </div>
+
+            <div class="tool-section">
+                <h4>🕵️ Blame</h4>
+                <div class="form-group">
+                    <label>File:</label>
+                    <input type="text" id="blameFile" placeholder="main.go">
+                </div>
+                <div class="form-group">
+                    <label>Ignore formatting commits (space separated hashes):</label>
+                    <input type="text" id="blameIgnore" placeholder="a1b2c3d4...">
+                </div>
+                <button class="btn btn-secondary btn-sm" onclick="loadBlame()">🕵️ Blame</button>
+            </div>
+
+            <div class="tool-section">
+                <h4>🙈 Check Ignore</h4>
+                <div class="tool-row">
+                    <input type="text" id="checkIgnoreFiles" placeholder="build/out vendor (space separated)" style="flex: 1;">
+                    <button class="btn btn-secondary btn-sm" onclick="checkIgnore()">🙈 Why ignored?</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>🗒️ Notes</h4>
+                <div class="tool-row">
+                    <input type="text" id="notesNamespace" value="commits" style="width: 160px;" title="Notes namespace">
+                    <button class="btn btn-secondary btn-sm" onclick="loadOrphanedNotes()">🔍 Find orphaned</button>
+                </div>
+                <div class="tool-row" style="margin-top: 10px;">
+                    <input type="text" id="notesMergeRef" placeholder="refs/notes/review" style="flex: 1;" title="Notes ref to merge into refs/notes/commits">
+                    <select id="notesMergeStrategy" style="width: auto;">
+                        <option value="manual">manual</option>
+                        <option value="ours">ours</option>
+                        <option value="theirs">theirs</option>
+                        <option value="union">union</option>
+                        <option value="cat_sort_uniq">cat_sort_uniq</option>
+                    </select>
+                    <button class="btn btn-secondary btn-sm" onclick="mergeNotes()">🔀 Merge notes</button>
+                </div>
+                <div id="notesOrphanedSection" style="display: none; margin-top: 10px;">
+                    <h4>👻 Orphaned <span class="badge" id="notesOrphanedCount">0</span></h4>
+                    <div class="output" id="notesOrphaned"></div>
+                    <button class="btn btn-danger btn-sm" id="notesPruneButton" onclick="pruneNotes()">🧹 Prune all</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>🪶 Sparse Checkout</h4>
+                <div class="tool-row">
+                    <button class="btn btn-secondary btn-sm" onclick="toolsPost('/git/sparse-checkout/reapply', {})" title="Update the working tree after the sparse-checkout patterns changed">🔄 Reapply patterns</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>🔎 Bisect</h4>
+                <div class="tool-row">
+                    <button class="btn btn-secondary btn-sm" onclick="loadBisectVisualize()">📋 Remaining Commits</button>
+                </div>
+            </div>
+
+            <div class="tool-section">
+                <h4>✂️ Subtree Split</h4>
+                <div class="status error">⚠️ This creates a rewritten history containing only the given directory.</div>
+                <div class="form-group">
+                    <label>Directory prefix:</label>
+                    <input type="text" id="subtreePrefix" placeholder="libs/shared">
+                </div>
+                <div class="form-group">
+                    <label>Annotate commit messages (optional):</label>
+                    <input type="text" id="subtreeAnnotate" placeholder="(split) ">
+                </div>
+                <div class="form-group">
+                    <label>Push to new remote (optional):</label>
+                    <div class="tool-row">
+                        <input type="text" id="subtreeRemote" placeholder="https://github.com/username/new-repo.git" style="flex: 2;">
+                        <input type="text" id="subtreeBranch" placeholder="main" style="flex: 1;">
+                    </div>
+                </div>
+                <button class="btn btn-warning btn-sm" onclick="subtreeSplit()">✂️ Split</button>
+            </div>
+
+            <div class="tool-section">
+                <h4>🧽 Strip Sensitive Data (filter-repo)</h4>
+                <div class="status error">⚠️ This rewrites the whole history. A backup bundle is created first, but every clone must be re-cloned afterwards.</div>
+                <div class="form-group">
+                    <label>filter-repo arguments:</label>
+                    <input type="text" id="filterRepoArgs" placeholder="--path secrets.txt --invert-paths">
+                </div>
+                <button class="btn btn-danger btn-sm" onclick="runFilterRepo()">🧽 Run filter-repo</button>
+            </div>
+
+            <div class="output" id="toolsOutput">Tool results will be shown here...</div>
+
+            <div class="modal-footer">
+                <button class="btn btn-secondary" onclick="closeToolsModal()">❌ Close</button>
+            </div>
+        </div>
+    </div>
+
+    <script>
+        var currentPushPath = '';
+        var currentToolsPath = '';
+        var logState = {path: '', filter: '', order: '', branch: '', author: '', file: '', stats: false, compact: false, firstParent: false, search: '', searchMode: 'message', regex: false, after: '', before: ''};
+
+        // pullAll shows each project's pull result as soon as the server reports it
+        function pullAll() {
+            if (!confirm('Pull all projects?')) return;
+
+            var button = document.getElementById('pullAllButton');
+            button.disabled = true;
+            var lines = [];
+            var failed = 0;
+            showOutput('🔄 Pulling all projects...');
+
+            fetch('/git/pull-all', {method: 'POST'})
+                .then(function(response) {
+                    if ((response.headers.get('Content-Type') || '').indexOf('application/x-ndjson') !== 0) {
+                        return response.json().then(function(data) { showOutput('❌ ' + data.error, true); });
+                    }
+
+                    var reader = response.body.getReader();
+                    var decoder = new TextDecoder();
+                    var buffer = '';
+                    var read = function() {
+                        return reader.read().then(function(chunk) {
+                            if (chunk.done) {
+                                return;
+                            }
+                            buffer += decoder.decode(chunk.value, {stream: true});
+                            var records = buffer.split('\n');
+                            buffer = records.pop();
+                            for (var i = 0; i < records.length; i++) {
+                                if (!records[i]) continue;
+                                var record = JSON.parse(records[i]);
+                                if (record.finished) {
+                                    lines.unshift((record.failed ? '⚠️ ' + record.failed + ' of ' : '✅ All ') + record.total + ' projects ' + (record.failed ? 'failed to pull' : 'pulled') + '\n');
+                                    showOutput(lines.join('\n'), record.failed > 0);
+                                    continue;
+                                }
+                                if (record.error) {
+                                    failed++;
+                                    lines.push('❌ ' + record.name + ': ' + record.error + '\n' + record.output);
+                                } else {
+                                    lines.push('✅ ' + record.name + '\n' + record.output);
+                                }
+                                showOutput('🔄 Pulling all projects... (' + record.completed + '/' + record.total + ')\n\n' + lines.join('\n'), failed > 0);
+                            }
+                            return read();
+                        });
+                    };
+                    return read();
+                })
+                .catch(function(error) {
+                    showOutput('❌ Pull all error: ' + error.message, true);
+                })
+                .then(function() {
+                    button.disabled = false;
+                });
+        }
+
+        function showOutput(text, isError) {
+            var output = document.getElementById('output');
+            if (output) {
+                output.textContent = text;
+                output.className = 'output ' + (isError ? 'error' : 'success');
+            } else {
+                alert(text);
+            }
+        }
+
+        function shareAsGist() {
+            var content = document.getElementById('output').textContent;
+            var link = document.getElementById('gistLink');
+            link.textContent = '🔄 Creating gist...';
+
+            fetch('/github/gists', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({
+                    description: 'Remote Git Manager output',
+                    files: {'output.txt': content},
+                    public: document.getElementById('gistPublic').checked
+                })
+            })
+            .then(function(response) { return response.json(); })
+            .then(function(data) {
+                if (data.error) {
+                    link.textContent = '❌ ' + data.error;
+                    return;
+                }
+
+                link.innerHTML = '';
+                var anchor = document.createElement('a');
+                anchor.href = data.gist.url;
+                anchor.target = '_blank';
+                anchor.textContent = data.gist.url;
+
+                var copyBtn = document.createElement('button');
+                copyBtn.className = 'btn btn-secondary btn-sm';
+                copyBtn.textContent = '📋 Copy';
+                copyBtn.onclick = function() {
+                    navigator.clipboard.writeText(data.gist.url).then(function() {
+                        copyBtn.textContent = '✅ Copied';
+                    });
+                };
+
+                link.appendChild(anchor);
+                link.appendChild(document.createTextNode(' '));
+                link.appendChild(copyBtn);
+            })
+            .catch(function(error) {
+                link.textContent = '❌ Gist error: ' + error.message;
+            });
+        }
+
+        function refreshProjects() {
+            var projectsList = document.getElementById('projectsList');
+            if (!projectsList) return;
+            
+            projectsList.innerHTML = '<div class="loading-text">Loading...</div>';
+            
+            fetch('/projects')
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    var cacheBanner = document.getElementById('cacheBanner');
+                    if (data.cached) {
+                        cacheBanner.textContent = '⚠️ Offline: showing the project list cached ' + data.cache_age + ' seconds ago (' + data.warning + ')';
+                        cacheBanner.style.display = 'block';
+                    } else {
+                        cacheBanner.style.display = 'none';
+                    }
+
+                    if (data.error) {
+                        projectsList.innerHTML = '<div class="loading-text">❌ ' + data.error + '</div>';
+                        return;
+                    }
+                    displayProjects(data.projects || []);
+                })
+                .catch(function(error) {
+                    projectsList.innerHTML = '<div class="loading-text">❌ Error: ' + error.message + '</div>';
+                });
+        }
+
+        function displayProjects(projects) {
+            var projectsList = document.getElementById('projectsList');
+            if (!projectsList) return;
+            
+            if (projects.length === 0) {
+                projectsList.innerHTML = '<div class="loading-text">📁 No Git repositories found</div>';
+                return;
+            }
+            
+            projectsList.innerHTML = '';
+            
+            for (var i = 0; i < projects.length; i++) {
+                var project = projects[i];
+                var item = document.createElement('div');
+                item.className = 'project-item';
+                
+                var info = document.createElement('div');
+                info.className = 'project-info';
+                
+                var name = document.createElement('div');
+                name.className = 'project-name';
+                name.textContent = '📁 ' + project.name;
+                
+                var path = document.createElement('div');
+                path.className = 'project-path';
+                path.textContent = project.path;
+                
+                var stashBadge = document.createElement('span');
+                stashBadge.className = 'badge';
+                stashBadge.style.display = 'none';
+                stashBadge.style.marginLeft = '8px';
+                name.appendChild(stashBadge);
+                loadStashCount(project.path, stashBadge);
+
+                info.appendChild(name);
+                info.appendChild(path);
+                
+                var actions = document.createElement('div');
+                actions.className = 'project-actions';
+                
+                var pullBtn = document.createElement('button');
+                pullBtn.className = 'btn btn-warning btn-sm';
+                pullBtn.textContent = '⬇️ Pull';
+                var branchSelect = document.createElement('select');
+                branchSelect.style.width = 'auto';
+                branchSelect.title = 'Branch to pull';
+                branchSelect.innerHTML = '<option value="">Current branch</option>';
+                branchSelect.onfocus = (function(projectPath, select) {
+                    return function() { loadProjectBranches(projectPath, select); };
+                })(project.path, branchSelect);
+
+                pullBtn.onclick = (function(projectPath, select) {
+                    return function() { pullBranch(projectPath, select); };
+                })(project.path, branchSelect);
+                
+                var pushBtn = document.createElement('button');
+                pushBtn.className = 'btn btn-success btn-sm';
+                pushBtn.textContent = '⬆️ Push';
+                pushBtn.onclick = (function(projectPath) {
+                    return function() { openCommitModal(projectPath); };
+                })(project.path);
+
+                var statusBtn = document.createElement('button');
+                statusBtn.className = 'btn btn-secondary btn-sm';
+                statusBtn.textContent = '📊 Status';
+                statusBtn.onclick = (function(projectPath) {
+                    return function() { gitStatus(projectPath); };
+                })(project.path);
+
+                var removeBtn = document.createElement('button');
+                removeBtn.className = 'btn btn-danger btn-sm';
+                removeBtn.textContent = '🗑️ Remove';
+                removeBtn.onclick = (function(projectPath, projectName) {
+                    return function() { 
+                        if (confirm('Are you sure you want to delete this project?\\n\\n' + projectName + '\\n' + projectPath)) {
+                            removeProject(projectPath);
+                        }
+                    };
+                })(project.path, project.name);
+                
+                var toolsBtn = document.createElement('button');
+                toolsBtn.className = 'btn btn-sm';
+                toolsBtn.textContent = '🧰 Tools';
+                toolsBtn.onclick = (function(projectPath, projectName) {
+                    return function() { openToolsModal(projectPath, projectName); };
+                })(project.path, project.name);
+
+                var diffBtn = document.createElement('button');
+                diffBtn.className = 'btn btn-secondary btn-sm';
+                diffBtn.textContent = '🔍 Diff';
+                diffBtn.onclick = (function(projectPath, projectName) {
+                    return function() { openDiffModal(projectPath, projectName); };
+                })(project.path, project.name);
+
+                var stashBtn = document.createElement('button');
+                stashBtn.className = 'btn btn-secondary btn-sm';
+                stashBtn.textContent = '📦 Stash';
+                stashBtn.onclick = (function(projectPath, badge) {
+                    return function() { gitStash(projectPath, badge); };
+                })(project.path, stashBadge);
+
+                var popBtn = document.createElement('button');
+                popBtn.className = 'btn btn-secondary btn-sm';
+                popBtn.textContent = '📤 Pop';
+                popBtn.onclick = (function(projectPath, badge) {
+                    return function() { gitStashPop(projectPath, badge); };
+                })(project.path, stashBadge);
+
+                var submoduleBtn = document.createElement('button');
+                submoduleBtn.className = 'btn btn-secondary btn-sm';
+                submoduleBtn.textContent = '🧩 Submodules';
+                submoduleBtn.title = 'Initialise and update submodules';
+                submoduleBtn.onclick = (function(projectPath) {
+                    return function() { gitSubmoduleUpdate(projectPath); };
+                })(project.path);
+
+                var historyBtn = document.createElement('button');
+                historyBtn.className = 'btn btn-secondary btn-sm';
+                historyBtn.textContent = '📜 History';
+                historyBtn.onclick = (function(projectPath, projectName) {
+                    return function() { openLogModal(projectPath, projectName); };
+                })(project.path, project.name);
+
+                actions.appendChild(branchSelect);
+                actions.appendChild(pullBtn);
+                actions.appendChild(pushBtn);
+                actions.appendChild(statusBtn);
+                actions.appendChild(diffBtn);
+                actions.appendChild(stashBtn);
+                actions.appendChild(popBtn);
+                actions.appendChild(submoduleBtn);
+                var browseBtn = document.createElement('button');
+                browseBtn.className = 'btn btn-secondary btn-sm';
+                browseBtn.textContent = '🌐 Browse';
+                browseBtn.onclick = (function(projectName) {
+                    return function() { window.open('/browse/' + encodeURIComponent(projectName), '_blank'); };
+                })(project.name);
+
+                actions.appendChild(historyBtn);
+                actions.appendChild(browseBtn);
+                actions.appendChild(toolsBtn);
+                actions.appendChild(removeBtn);
+                
+                item.appendChild(info);
+                item.appendChild(actions);
+                projectsList.appendChild(item);
+            }
+        }
+
+        var commitSearchSource = null;
+
+        function searchCommits() {
+            var query = document.getElementById('commitSearchQuery').value.trim();
+            if (!query) {
+                alert('Please enter a search query!');
+                return;
+            }
+
+            if (commitSearchSource) {
+                commitSearchSource.close();
+            }
+
+            var status = document.getElementById('commitSearchStatus');
+            var body = document.getElementById('commitSearchBody');
+            status.style.display = 'block';
+            status.textContent = '🔄 Searching...';
+            body.innerHTML = '';
+            document.getElementById('commitSearchTable').style.display = 'table';
+
+            var params = {
+                q: query,
+                since: document.getElementById('commitSearchSince').value,
+                until: document.getElementById('commitSearchUntil').value
+            };
+            var count = 0;
+            var source = new EventSource('/git/search/commits?' + buildQuery(params));
+            commitSearchSource = source;
+
+            source.addEventListener('hit', function(event) {
+                var hit = JSON.parse(event.data);
+                var row = document.createElement('tr');
+                var cells = [hit.project, hit.hash.substring(0, 7), hit.author, new Date(hit.date).toLocaleString(), hit.message];
+                for (var i = 0; i < cells.length; i++) {
+                    var cell = document.createElement('td');
+                    cell.textContent = cells[i];
+                    if (i === 1) {
+                        cell.className = 'hash';
+                        cell.title = hit.hash;
+                    }
+                    row.appendChild(cell);
+                }
+                body.appendChild(row);
+                count++;
+                status.textContent = '🔄 Searching... ' + count + ' commits found';
+            });
+
+            source.addEventListener('done', function() {
+                source.close();
+                status.textContent = '✅ ' + count + ' commits found';
+            });
+
+            source.onerror = function() {
+                source.close();
+                status.textContent = '❌ Search failed or connection lost (' + count + ' commits found)';
+            };
+        }
+
+        var fileBrowserState = {path: '', root: ''};
+
+        function browseFiles(dir) {
+            var status = document.getElementById('fileBrowserStatus');
+            var table = document.getElementById('fileBrowserTable');
+            var body = document.getElementById('fileBrowserBody');
+            status.style.display = 'block';
+            status.textContent = '🔄 Loading...';
+
+            fetch('/files/list?' + buildQuery({path: dir}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        status.textContent = '❌ ' + data.error;
+                        return;
+                    }
+
+                    fileBrowserState.path = data.path;
+                    fileBrowserState.root = data.root;
+                    document.getElementById('fileBrowserPath').textContent = data.path;
+                    document.getElementById('fileBrowserUp').disabled = data.path === data.root;
+
+                    var files = data.files || [];
+                    status.style.display = files.length === 0 ? 'block' : 'none';
+                    status.textContent = 'Empty directory';
+                    table.style.display = files.length === 0 ? 'none' : 'table';
+                    body.innerHTML = '';
+                    for (var i = 0; i < files.length; i++) {
+                        var file = files[i];
+                        var row = document.createElement('tr');
+                        var cells = [(file.is_dir ? '📁 ' : '📄 ') + file.name, file.is_dir ? '' : formatFileSize(file.size), file.mod_time, ''];
+                        for (var j = 0; j < cells.length; j++) {
+                            var cell = document.createElement('td');
+                            cell.textContent = cells[j];
+                            row.appendChild(cell);
+                        }
+                        if (!file.is_dir) {
+                            var duplicateBtn = document.createElement('button');
+                            duplicateBtn.className = 'btn btn-secondary btn-sm';
+                            duplicateBtn.textContent = '📑 Duplicate';
+                            duplicateBtn.onclick = (function(file) {
+                                return function(event) {
+                                    event.stopPropagation();
+                                    duplicateFile(file);
+                                };
+                            })(file);
+                            row.lastChild.appendChild(duplicateBtn);
+                        }
+                        row.style.cursor = 'pointer';
+                        row.onclick = (function(file) {
+                            return function() {
+                                if (file.is_dir) {
+                                    browseFiles(file.path);
+                                } else {
+                                    viewFile(file);
+                                }
+                            };
+                        })(file);
+                        body.appendChild(row);
+                    }
+                })
+                .catch(function(error) {
+                    status.textContent = '❌ Error: ' + error.message;
+                });
+        }
+
+        // duplicateFile copies a file next to itself as <name>_copy.<ext>
+        function duplicateFile(file) {
+            var dot = file.name.lastIndexOf('.');
+            var copyName = dot > 0 ? file.name.substring(0, dot) + '_copy' + file.name.substring(dot) : file.name + '_copy';
+            var dir = file.path.substring(0, file.path.lastIndexOf('/'));
+            var status = document.getElementById('fileBrowserStatus');
+            status.style.display = 'block';
+            status.textContent = '🔄 Duplicating ' + file.name + '...';
+
+            fetch('/files/copy', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({src: file.path, dst: dir + '/' + copyName})
+            })
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        status.textContent = '❌ ' + data.error;
+                        return;
+                    }
+                    browseFiles(fileBrowserState.path);
+                })
+                .catch(function(error) {
+                    status.textContent = '❌ Error: ' + error.message;
+                });
+        }
+
+        function browseParentDir() {
+            var path = fileBrowserState.path;
+            if (!path || path === fileBrowserState.root) {
+                return;
+            }
+            browseFiles(path.substring(0, path.lastIndexOf('/')) || '/');
+        }
+
+        var fileViewerState = {file: null, editing: false, dirty: false, sha256: ''};
+
+        window.addEventListener('beforeunload', function(event) {
+            if (fileViewerState.dirty) {
+                event.preventDefault();
+                event.returnValue = '';
+            }
+        });
+
+        // viewFile asks for the HTML rendering, which the server highlights; binary files come back raw instead
+        function viewFile(file) {
+            if (fileViewerState.dirty && !confirm('Discard unsaved changes to ' + fileViewerState.file.path + '?')) {
+                return;
+            }
+            var viewer = document.getElementById('fileViewer');
+            var content = document.getElementById('fileViewerContent');
+            viewer.style.display = 'block';
+            document.getElementById('fileViewerTitle').textContent = '📄 ' + file.path;
+            content.textContent = '🔄 Loading...';
+            fileViewerState = {file: file, editing: false, dirty: false, sha256: ''};
+            setFileEditMode(false);
+            document.getElementById('fileEditToggle').style.display = 'none';
+
+            fetch('/files/content?' + buildQuery({path: file.path}), {headers: {'Accept': 'text/html'}})
+                .then(function(response) {
+                    var type = response.headers.get('Content-Type') || '';
+                    if (type.indexOf('application/json') === 0) {
+                        return response.json().then(function(data) {
+                            showFileViewerMessage(file, '❌ ' + data.error);
+                        });
+                    }
+                    if (!response.ok || type.indexOf('text/html') !== 0) {
+                        showFileViewerMessage(file, response.ok ? 'Binary file, not shown' : '❌ ' + response.statusText);
+                        return;
+                    }
+                    return response.text().then(function(html) {
+                        content.innerHTML = html;
+                        document.getElementById('fileEditToggle').style.display = '';
+                    });
+                })
+                .catch(function(error) {
+                    content.textContent = '❌ Error: ' + error.message;
+                });
+        }
+
+        function setFileEditMode(editing) {
+            fileViewerState.editing = editing;
+            document.getElementById('fileViewerContent').style.display = editing ? 'none' : 'block';
+            document.getElementById('fileEditor').style.display = editing ? 'block' : 'none';
+            document.getElementById('fileSaveBtn').style.display = editing ? '' : 'none';
+            document.getElementById('fileEditToggle').textContent = editing ? '👁️ View' : '✏️ Edit';
+            document.getElementById('fileEditStatus').textContent = '';
+        }
+
+        // The editor loads the plain text together with its hash, which the save sends back to detect concurrent edits
+        function toggleFileEdit() {
+            var file = fileViewerState.file;
+            if (fileViewerState.editing) {
+                if (fileViewerState.dirty && !confirm('Discard unsaved changes?')) {
+                    return;
+                }
+                fileViewerState.dirty = false;
+                setFileEditMode(false);
+                return;
+            }
+
+            var status = document.getElementById('fileEditStatus');
+            status.textContent = '🔄 Loading...';
+            fetch('/files/content?' + buildQuery({path: file.path, format: 'json'}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        status.textContent = '❌ ' + data.error;
+                        return;
+                    }
+                    document.getElementById('fileEditor').value = data.content;
+                    fileViewerState.sha256 = data.sha256;
+                    setFileEditMode(true);
+                })
+                .catch(function(error) {
+                    status.textContent = '❌ Error: ' + error.message;
+                });
+        }
+
+        function saveFileEdit(force) {
+            var file = fileViewerState.file;
+            var status = document.getElementById('fileEditStatus');
+            var body = {path: file.path, content: document.getElementById('fileEditor').value};
+            if (!force) {
+                body.expected_sha256 = fileViewerState.sha256;
+            }
+            status.textContent = '💾 Saving...';
+
+            fetch('/files/content', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify(body)
+            })
+                .then(function(response) {
+                    return response.json().then(function(data) {
+                        if (response.status === 409) {
+                            status.textContent = '⚠️ ' + data.error;
+                            if (confirm(data.error + '. Overwrite it anyway? The server version is kept as ' + file.path + '.bak')) {
+                                saveFileEdit(true);
+                            }
+                            return;
+                        }
+                        if (data.error) {
+                            status.textContent = '❌ ' + data.error;
+                            return;
+                        }
+                        fileViewerState.dirty = false;
+                        viewFile(file);
+                        document.getElementById('fileEditStatus').textContent = data.backup_path ? '✅ Saved, previous version in ' + data.backup_path : '✅ Saved';
+                    });
+                })
+                .catch(function(error) {
+                    status.textContent = '❌ Error: ' + error.message;
+                });
+        }
+
+        function showFileViewerMessage(file, message) {
+            var content = document.getElementById('fileViewerContent');
+            content.textContent = message + ' ';
+            var link = document.createElement('a');
+            link.href = '/files/download?' + buildQuery({path: file.path});
+            link.textContent = '📥 Download';
+            content.appendChild(link);
+        }
+
+        function formatFileSize(size) {
+            if (size < 1024) {
+                return size + ' B';
+            }
+            if (size < 1024 * 1024) {
+                return (size / 1024).toFixed(1) + ' KiB';
+            }
+            return (size / (1024 * 1024)).toFixed(1) + ' MiB';
+        }
+
+        function activateProfile(id) {
+            if (!id) {
+                return;
+            }
+            showOutput('🔄 Switching profile...');
+            fetch('/profiles/' + encodeURIComponent(id) + '/activate', {method: 'POST'})
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        showOutput('❌ Profile error: ' + data.error, true);
+                        return;
+                    }
+                    window.location.reload();
+                })
+                .catch(function(error) {
+                    showOutput('❌ Error: ' + error.message, true);
+                });
+        }
+
+        // saveAsProfile stores the current settings under a new name and makes them the active profile
+        function saveAsProfile() {
+            var name = prompt('Profile name:');
+            if (!name || !name.trim()) {
+                return;
+            }
+            fetch('/profiles', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({name: name.trim()})
+            })
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        showOutput('❌ Profile error: ' + data.error, true);
+                        return;
+                    }
+                    activateProfile(data.profile.id);
+                })
+                .catch(function(error) {
+                    showOutput('❌ Error: ' + error.message, true);
+                });
+        }
+
+        function loadProcesses() {
+            var processList = document.getElementById('processList');
+            var filter = document.getElementById('processFilter').value.trim().toLowerCase();
+            processList.style.display = 'block';
+            processList.innerHTML = '<div class="loading-text">Loading...</div>';
+
+            fetch('/system/processes')
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        processList.innerHTML = '<div class="loading-text">❌ ' + data.error + '</div>';
+                        return;
+                    }
+
+                    var table = document.createElement('table');
+                    table.className = 'log-table';
+                    table.innerHTML = '<thead><tr><th>PID</th><th>User</th><th>CPU %</th><th>Mem %</th><th>Command</th><th></th></tr></thead>';
+                    var body = document.createElement('tbody');
+
+                    var processes = data.processes || [];
+                    for (var i = 0; i < processes.length; i++) {
+                        var process = processes[i];
+                        if (filter && process.command.toLowerCase().indexOf(filter) === -1 && process.user.toLowerCase().indexOf(filter) === -1) {
+                            continue;
+                        }
+
+                        var row = document.createElement('tr');
+                        var cells = [process.pid, process.user, process.cpu, process.mem, process.command];
+                        for (var j = 0; j < cells.length; j++) {
+                            var cell = document.createElement('td');
+                            cell.textContent = cells[j];
+                            row.appendChild(cell);
+                        }
+
+                        var killCell = document.createElement('td');
+                        var killBtn = document.createElement('button');
+                        killBtn.className = 'btn btn-danger btn-sm';
+                        killBtn.textContent = '🔪 Kill';
+                        killBtn.onclick = (function(pid, command) {
+                            return function() { signalProcess(pid, command); };
+                        })(process.pid, process.command);
+                        killCell.appendChild(killBtn);
+                        row.appendChild(killCell);
+                        body.appendChild(row);
+                    }
+
+                    table.appendChild(body);
+                    processList.innerHTML = '';
+                    processList.appendChild(table);
+                })
+                .catch(function(error) {
+                    processList.innerHTML = '<div class="loading-text">❌ Error: ' + error.message + '</div>';
+                });
+        }
+
+        var operationsState = { offset: 0 };
+        var operationsPageSize = 50;
+
+        function loadOperations(offset) {
+            var body = document.getElementById('operationsBody');
+            var status = document.getElementById('operationsStatus');
+            status.textContent = 'Loading...';
+
+            fetch('/operations?limit=' + operationsPageSize + '&offset=' + offset)
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        status.textContent = '❌ ' + data.error;
+                        return;
+                    }
+                    if (offset === 0) {
+                        body.innerHTML = '';
+                    }
+
+                    var operations = data.operations || [];
+                    for (var i = 0; i < operations.length; i++) {
+                        appendOperationRows(body, operations[i]);
+                    }
+
+                    operationsState.offset = offset + operations.length;
+                    document.getElementById('operationsTable').style.display = data.total > 0 ? 'table' : 'none';
+                    document.getElementById('operationsMore').style.display = operationsState.offset < data.total ? 'inline-block' : 'none';
+                    status.textContent = data.total > 0 ? operationsState.offset + ' of ' + data.total + ' commands' : 'No commands recorded yet';
+                })
+                .catch(function(error) {
+                    status.textContent = '❌ Error: ' + error.message;
+                });
+        }
+
+        function appendOperationRows(body, operation) {
+            var row = document.createElement('tr');
+            var ok = operation.exit_code === 0;
+
+            var badgeCell = document.createElement('td');
+            var badge = document.createElement('span');
+            badge.className = 'badge ' + (ok ? 'success' : 'danger');
+            badge.textContent = ok ? '✅' : '❌';
+            badgeCell.appendChild(badge);
+            row.appendChild(badgeCell);
+
+            var cells = [new Date(operation.timestamp).toLocaleString(), operation.command, operation.exit_code, operation.duration_ms + ' ms'];
+            for (var i = 0; i < cells.length; i++) {
+                var cell = document.createElement('td');
+                cell.textContent = cells[i];
+                if (i === 1) cell.className = 'hash';
+                row.appendChild(cell);
+            }
+            body.appendChild(row);
+
+            var outputRow = document.createElement('tr');
+            outputRow.className = 'log-patch';
+            outputRow.style.display = 'none';
+            var outputCell = document.createElement('td');
+            outputCell.colSpan = 5;
+            var pre = document.createElement('pre');
+            var output = operation.stdout || '';
+            if (operation.stderr) {
+                output += (output ? '\n' : '') + '--- stderr ---\n' + operation.stderr;
+            }
+            pre.textContent = output || '(no output)';
+            outputCell.appendChild(pre);
+            outputRow.appendChild(outputCell);
+            body.appendChild(outputRow);
+
+            row.onclick = function() {
+                outputRow.style.display = outputRow.style.display === 'none' ? '' : 'none';
+            };
+        }
+
+        function purgeOperations() {
+            if (!confirm('Delete the whole command history?')) return;
+
+            fetch('/operations', { method: 'DELETE' })
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        showOutput('❌ ' + data.error, true);
+                        return;
+                    }
+                    loadOperations(0);
+                })
+                .catch(function(error) {
+                    showOutput('❌ Error: ' + error.message, true);
+                });
+        }
+
+        function signalProcess(pid, command) {
+            var signal = prompt('Send signal to process ' + pid + '?\n' + command + '\n\nSignal (TERM, KILL, HUP, INT...):', 'TERM');
+            if (!signal) return;
+
+            fetch('/system/processes/' + pid + '/signal', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({signal: signal})
+            })
+            .then(function(response) { return response.json(); })
+            .then(function(data) {
+                if (data.error) {
+                    showOutput('❌ ' + data.error, true);
+                } else {
+                    showOutput('✅ Sent SIG' + data.signal + ' to process ' + data.pid);
+                    loadProcesses();
+                }
+            })
+            .catch(function(error) {
+                showOutput('❌ Signal error: ' + error.message, true);
+            });
+        }
+
+        function gitClone() {
+            var repoUrlInput = document.getElementById('repoUrl');
+            var branchInput = document.getElementById('branch');
+            
+            if (!repoUrlInput) {
+                showOutput('Repository URL input not found!', true);
+                return;
+            }
+            
+            var repoUrl = repoUrlInput.value.trim();
+            var branch = branchInput ? branchInput.value.trim() : '';
+            var depth = parseInt(document.getElementById('cloneDepth').value, 10) || 0;
+            var recursive = document.getElementById('cloneRecursive').checked;
+            
+            if (!repoUrl) {
+                showOutput('Please enter Repository URL!', true);
+                return;
+            }
+
+            showOutput('🔄 Cloning...');
+            
+            fetch('/git/clone', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({repo_url: repoUrl, branch: branch, depth: depth, recursive: recursive})
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                showOutput(result);
+                // Clear inputs on successful clone
+                repoUrlInput.value = '';
+                if (branchInput) branchInput.value = '';
+                // Refresh projects
+                refreshProjects();
+            })
+            .catch(function(error) { 
+                showOutput('❌ Clone error: ' + error.message, true); 
+            });
+        }
+
+        // loadProjectBranches fills a project's branch dropdown the first time it is opened
+        function loadProjectBranches(projectPath, select) {
+            if (select.getAttribute('data-loaded')) {
+                return;
+            }
+            select.setAttribute('data-loaded', 'true');
+
+            fetch('/git/branches?' + buildQuery({repo_path: projectPath}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        select.removeAttribute('data-loaded');
+                        showOutput('❌ ' + data.error, true);
+                        return;
+                    }
+
+                    var seen = {};
+                    var branches = data.branches || [];
+                    select.innerHTML = '';
+                    for (var i = 0; i < branches.length; i++) {
+                        var name = branches[i].name;
+                        if (branches[i].is_remote) {
+                            name = name.substring(name.indexOf('/') + 1);
+                        }
+                        if (seen[name]) {
+                            continue;
+                        }
+                        seen[name] = true;
+
+                        var option = document.createElement('option');
+                        option.value = name;
+                        option.textContent = name;
+                        if (branches[i].is_current) {
+                            option.selected = true;
+                            select.setAttribute('data-current', name);
+                        }
+                        select.appendChild(option);
+                    }
+                })
+                .catch(function(error) {
+                    select.removeAttribute('data-loaded');
+                    showOutput('❌ Branch list error: ' + error.message, true);
+                });
+        }
+
+        // pullBranch checks out the selected branch first when it differs from the current one
+        function pullBranch(projectPath, select) {
+            var branch = select.value;
+            var current = select.getAttribute('data-current');
+            if (!branch || branch === current) {
+                openPullModal(projectPath);
+                return;
+            }
+
+            showOutput('🔄 Switching to ' + branch + '...');
+            fetch('/git/checkout', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({repo_path: projectPath, branch: branch})
+            })
+            .then(function(response) { return response.json(); })
+            .then(function(data) {
+                if (data.error) {
+                    showOutput('❌ ' + data.error + '\n' + (data.output || ''), true);
+                    return;
+                }
+                select.setAttribute('data-current', data.branch);
+                showOutput('🔀 Switched from ' + data.previous_branch + ' to ' + data.branch + '\n' + data.output);
+                openPullModal(projectPath);
+            })
+            .catch(function(error) {
+                showOutput('❌ Checkout error: ' + error.message, true);
+            });
+        }
+
+        function gitPull(projectPath) {
+            showOutput('🔄 Pulling: ' + projectPath);
+            
+            fetch('/git/pull', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({repo_path: projectPath})
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                showOutput(result);
+            })
+            .catch(function(error) { 
+                showOutput('❌ Pull error: ' + error.message, true); 
+            });
+        }
+
+        // loadStashCount shows how many stashes a project has, hiding the badge when there are none
+        function loadStashCount(projectPath, badge) {
+            fetch('/git/stash/list?' + buildQuery({repo_path: projectPath}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    var stashes = data.stashes || [];
+                    badge.textContent = '📦 ' + stashes.length;
+                    badge.title = stashes.length + ' stashed change sets';
+                    badge.style.display = stashes.length > 0 ? 'inline-block' : 'none';
+                })
+                .catch(function() {
+                    badge.style.display = 'none';
+                });
+        }
+
+        function gitStash(projectPath, badge) {
+            var message = prompt('Stash message (optional):', '');
+            if (message === null) {
+                return;
+            }
+            showOutput('🔄 Stashing: ' + projectPath);
+
+            fetch('/git/stash', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({repo_path: projectPath, message: message.trim()})
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                showOutput(result);
+                loadStashCount(projectPath, badge);
+            })
+            .catch(function(error) {
+                showOutput('❌ Stash error: ' + error.message, true);
+            });
+        }
+
+        function gitStashPop(projectPath, badge) {
+            showOutput('🔄 Popping stash: ' + projectPath);
+
+            fetch('/git/stash/pop', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({repo_path: projectPath})
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                showOutput(result);
+                loadStashCount(projectPath, badge);
+            })
+            .catch(function(error) {
+                showOutput('❌ Stash pop error: ' + error.message, true);
+            });
+        }
+
+        // gitSubmoduleUpdate initialises the submodules and then lists where each one stands
+        function gitSubmoduleUpdate(projectPath) {
+            showOutput('🔄 Updating submodules: ' + projectPath);
+
+            fetch('/git/submodule/update', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({repo_path: projectPath})
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                return fetch('/git/submodule/status?' + buildQuery({repo_path: projectPath}))
+                    .then(function(response) { return response.json(); })
+                    .then(function(data) {
+                        var submodules = data.submodules || [];
+                        var lines = [result, ''];
+                        if (data.error) {
+                            lines.push('❌ ' + data.error);
+                        } else if (submodules.length === 0) {
+                            lines.push('No submodules');
+                        }
+                        for (var i = 0; i < submodules.length; i++) {
+                            var icon = submodules[i].status === 'ok' ? '✅' : '⚠️';
+                            lines.push(icon + ' ' + submodules[i].path + '  ' + submodules[i].commit.substring(0, 7) + '  ' + submodules[i].status);
+                        }
+                        showOutput(lines.join('\n'));
+                    });
+            })
+            .catch(function(error) {
+                showOutput('❌ Submodule update error: ' + error.message, true);
+            });
+        }
+
+        var currentPullPath = '';
+
+        // openPullModal fetches first so the incoming changes can be previewed before merging them
+        function openPullModal(projectPath) {
+            currentPullPath = projectPath;
+            var summary = document.getElementById('pullSummary');
+            var preview = document.getElementById('pullPreview');
+            summary.textContent = '🔄 Fetching...';
+            preview.textContent = '';
+            document.getElementById('pullModal').style.display = 'block';
+
+            fetch('/git/fetch', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({repo_path: projectPath})
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                if (result.indexOf('❌') === 0) {
+                    summary.textContent = result;
+                    return null;
+                }
+                // Diff from the common base so local commits don't show up as incoming removals
+                return fetch('/git/merge-base?' + buildQuery({repo_path: projectPath, a: 'HEAD', b: '@{upstream}'}))
+                    .then(function(response) { return response.json(); })
+                    .then(function(base) {
+                        if (base.error) {
+                            return base;
+                        }
+                        return fetch('/git/diff/stat?' + buildQuery({repo_path: projectPath, ref1: base.merge_base, ref2: '@{upstream}'}))
+                            .then(function(response) { return response.json(); });
+                    });
+            })
+            .then(function(data) {
+                if (!data || currentPullPath !== projectPath) {
+                    return;
+                }
+                if (data.error) {
+                    summary.textContent = '⚠️ No preview available: ' + data.error;
+                    return;
+                }
+
+                var stats = data.stats;
+                if (stats.files_changed === 0) {
+                    summary.textContent = '✅ No incoming changes';
+                    return;
+                }
+                summary.textContent = stats.files_changed + ' files changed, +' + stats.insertions + ' -' + stats.deletions;
+                fetch('/git/commit-count?' + buildQuery({repo_path: projectPath, range: 'HEAD..@{upstream}'}))
+                    .then(function(response) { return response.json(); })
+                    .then(function(count) {
+                        if (count.error || currentPullPath !== projectPath) {
+                            return;
+                        }
+                        summary.textContent = count.count + (count.count === 1 ? ' new commit' : ' new commits') + ' will be merged: ' + summary.textContent;
+                    })
+                    .catch(function() {});
+                var lines = [];
+                for (var i = 0; i < stats.file_stats.length; i++) {
+                    var file = stats.file_stats[i];
+                    lines.push('+' + file.additions + ' -' + file.deletions + '  ' + file.path);
+                }
+                preview.textContent = lines.join('\n');
+            })
+            .catch(function(error) {
+                summary.textContent = '❌ Error: ' + error.message;
+            });
+        }
+
+        function closePullModal() {
+            var modal = document.getElementById('pullModal');
+            if (modal) {
+                modal.style.display = 'none';
+            }
+            currentPullPath = '';
+        }
+
+        function confirmPull() {
+            var projectPath = currentPullPath;
+            closePullModal();
+            if (projectPath) {
+                gitPull(projectPath);
+            }
+        }
+
+        function openCommitModal(projectPath) {
+            currentPushPath = projectPath;
+            var modal = document.getElementById('commitModal');
+            var messageInput = document.getElementById('modalCommitMessage');
+            
+            if (modal && messageInput) {
+                messageInput.value = 'Update files';
+                modal.style.display = 'block';
+                messageInput.focus();
+                messageInput.select();
+            }
+
+            fetch('/config/commit-template?' + buildQuery({repo_path: projectPath}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    // Don't overwrite a message the user already started typing
+                    if (data.message && currentPushPath === projectPath && messageInput.value === 'Update files') {
+                        messageInput.value = data.message;
+                        messageInput.select();
+                    }
+                })
+                .catch(function() {});
+
+            var warning = document.getElementById('modalEmptyWarning');
+            warning.style.display = 'none';
+            document.getElementById('modalAllowEmpty').checked = false;
+            document.getElementById('modalDateOverride').open = false;
+            document.getElementById('modalCommitDate').value = '';
+
+            fetch('/git/status/changes?' + buildQuery({repo_path: projectPath}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (!data.error && !data.has_changes && currentPushPath === projectPath) {
+                        warning.style.display = 'block';
+                    }
+                })
+                .catch(function() {});
+
+            var branchSelect = document.getElementById('modalBranch');
+            branchSelect.innerHTML = '<option value="">Current branch</option>';
+
+            fetch('/git/branches?' + buildQuery({repo_path: projectPath}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error || currentPushPath !== projectPath) {
+                        return;
+                    }
+
+                    // Remote branches are offered by their name on the remote, e.g. origin/feature -> feature
+                    var seen = {};
+                    var branches = data.branches || [];
+                    for (var i = 0; i < branches.length; i++) {
+                        var name = branches[i].name;
+                        if (branches[i].is_remote) {
+                            name = name.substring(name.indexOf('/') + 1);
+                        }
+                        if (branches[i].is_current) {
+                            branchSelect.options[0].textContent = 'Current branch (' + name + ')';
+                            seen[name] = true;
+                        }
+                        if (seen[name]) {
+                            continue;
+                        }
+                        seen[name] = true;
+
+                        var option = document.createElement('option');
+                        option.value = name;
+                        option.textContent = name;
+                        branchSelect.appendChild(option);
+                    }
+                })
+                .catch(function() {});
+
+            var pending = document.getElementById('modalPendingCommits');
+            var pendingList = document.getElementById('modalPendingList');
+            pending.style.display = 'none';
+            pendingList.innerHTML = '';
+
+            // Commits since the last known remote HEAD; fails quietly for branches without an upstream
+            fetch('/git/log/since?' + buildQuery({repo_path: projectPath, since: '@{upstream}'}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    var commits = data.commits || [];
+                    if (data.error || commits.length === 0 || currentPushPath !== projectPath) {
+                        return;
+                    }
+                    for (var i = 0; i < commits.length; i++) {
+                        var item = document.createElement('li');
+                        item.textContent = commits[i].hash.substring(0, 7) + ' ' + commits[i].subject + ' (' + commits[i].author + ')';
+                        pendingList.appendChild(item);
+                    }
+                    pending.style.display = 'block';
+                })
+                .catch(function() {});
+
+            // The list above is capped, the count covers every unpushed commit
+            var pendingLabel = document.getElementById('modalPendingLabel');
+            pendingLabel.textContent = '📤 Unpushed commits that will be pushed too:';
+            fetch('/git/commit-count?' + buildQuery({repo_path: projectPath, range: '@{upstream}..HEAD'}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error || !data.count || currentPushPath !== projectPath) {
+                        return;
+                    }
+                    pendingLabel.textContent = '📤 You are about to push ' + data.count + (data.count === 1 ? ' commit' : ' commits') + ' in addition to the new one:';
+                })
+                .catch(function() {});
+        }
+
+        function closeCommitModal() {
+            var modal = document.getElementById('commitModal');
+            if (modal) {
+                modal.style.display = 'none';
+            }
+            currentPushPath = '';
+        }
+
+        function confirmPush() {
+            var messageInput = document.getElementById('modalCommitMessage');
+            var message = messageInput ? messageInput.value.trim() : 'Update files';
+            var signOff = document.getElementById('modalSignOff').checked;
+            var allowEmpty = document.getElementById('modalAllowEmpty').checked;
+            var branch = document.getElementById('modalBranch').value;
+            var commitDate = document.getElementById('modalCommitDate').value;
+            // closeCommitModal clears currentPushPath
+            var repoPath = currentPushPath;
+            
+            closeCommitModal();
+            
+            if (!repoPath) {
+                showOutput('❌ Push path unknown!', true);
+                return;
+            }
+            
+            showOutput('🔄 Pushing: ' + repoPath);
+            
+            var body = {repo_path: repoPath, message: message, sign_off: signOff, allow_empty: allowEmpty, branch: branch};
+            if (commitDate) {
+                // datetime-local is in the browser's time zone
+                body.commit_date = new Date(commitDate).toISOString();
+            }
+            fetch('/git/push', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify(body)
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                showOutput(result);
+            })
+            .catch(function(error) { 
+                showOutput('❌ Push error: ' + error.message, true); 
+            });
+        }
+
+        function gitStatus(projectPath) {
+            showOutput('🔄 Checking status: ' + projectPath);
+
+            var params = {repo_path: projectPath, modified: true, deleted: true, others: true};
+            fetch('/git/ls-files?' + buildQuery(params))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        showOutput('❌ Status error: ' + data.error, true);
+                        return;
+                    }
+
+                    var files = data.files || [];
+                    return fetch('/git/assume-unchanged?' + buildQuery({repo_path: projectPath}))
+                        .then(function(response) { return response.json(); })
+                        .then(function(assumed) {
+                            // Edits to assumed-unchanged files are hidden by git, so they are listed separately
+                            var hidden = assumed.files || [];
+                            var lines = [];
+                            if (files.length === 0) {
+                                lines.push('✅ ' + projectPath, 'Nothing to commit, working tree clean');
+                            } else {
+                                lines.push('📊 ' + projectPath + ': ' + files.length + ' changed files', '');
+                                for (var i = 0; i < files.length; i++) {
+                                    lines.push((files[i].staged ? '● ' : '  ') + files[i].status + '  ' + files[i].path);
+                                }
+                                lines.push('', '● staged   M modified   D deleted   ? untracked');
+                            }
+                            if (hidden.length > 0) {
+                                lines.push('', '🙈 Assumed unchanged (local edits not shown, toggle in 🧰 Tools):');
+                                for (var j = 0; j < hidden.length; j++) {
+                                    lines.push('  🙈 ' + hidden[j]);
+                                }
+                            }
+                            showOutput(lines.join('\n'));
+                        });
+                })
+                .catch(function(error) {
+                    showOutput('❌ Status error: ' + error.message, true);
+                });
+        }
+
+        function removeProject(projectPath) {
+            showOutput('🔄 Removing project: ' + projectPath);
+            
+            fetch('/git/remove', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({repo_path: projectPath})
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                showOutput(result);
+                refreshProjects();
+            })
+            .catch(function(error) { 
+                showOutput('❌ Remove error: ' + error.message, true); 
+            });
+        }
+
+        function cleanOrphans() {
+            showOutput('🔄 Searching for orphaned projects...');
+
+            fetch('/admin/orphans')
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        showOutput('❌ ' + data.error, true);
+                        return;
+                    }
+
+                    var orphans = data.orphans || [];
+                    if (orphans.length === 0) {
+                        showOutput('✅ No orphaned projects found');
+                        return;
+                    }
+
+                    if (!confirm('Remove these directories without a valid Git repository?\n\n' + orphans.join('\n'))) {
+                        showOutput('👻 Orphaned projects:\n' + orphans.join('\n'));
+                        return;
+                    }
+
+                    return fetch('/admin/orphans', {
+                        method: 'DELETE',
+                        headers: {'Content-Type': 'application/json'},
+                        body: JSON.stringify({paths: orphans, confirm: true})
+                    })
+                    .then(function(response) { return response.json(); })
+                    .then(function(result) {
+                        if (result.error) {
+                            showOutput('❌ ' + result.error, true);
+                        } else {
+                            showOutput('✅ Removed orphaned projects:\n' + result.removed.join('\n'));
+                        }
+                        refreshProjects();
+                    });
+                })
+                .catch(function(error) {
+                    showOutput('❌ Orphan cleanup error: ' + error.message, true);
+                });
+        }
+
+        var currentDiffPath = '';
+        var currentDiffStaged = false;
+        var diffIgnoreWhitespace = false;
+
+        function openDiffModal(projectPath, projectName) {
+            currentDiffPath = projectPath;
+            document.getElementById('diffTitle').textContent = '🔍 Diff: ' + projectName;
+            document.getElementById('diffModal').style.display = 'block';
+            loadDiff(false);
+        }
+
+        function closeDiffModal() {
+            var modal = document.getElementById('diffModal');
+            if (modal) {
+                modal.style.display = 'none';
+            }
+            currentDiffPath = '';
+        }
+
+        function toggleDiffIgnoreWhitespace() {
+            diffIgnoreWhitespace = !diffIgnoreWhitespace;
+            document.getElementById('diffIgnoreWs').className = 'btn btn-sm' + (diffIgnoreWhitespace ? '' : ' btn-secondary');
+            loadDiff(currentDiffStaged);
+        }
+
+        function loadDiff(staged) {
+            currentDiffStaged = staged;
+            var output = document.getElementById('diffOutput');
+            var buttons = document.querySelectorAll('.diff-mode');
+            for (var i = 0; i < buttons.length; i++) {
+                var active = buttons[i].getAttribute('data-staged') === String(staged);
+                buttons[i].className = 'btn btn-sm diff-mode' + (active ? '' : ' btn-secondary');
+            }
+            output.textContent = '🔄 Loading...';
+
+            var mode = document.getElementById('diffMode').value;
+            var params = {ignore_ws: diffIgnoreWhitespace ? 'true' : '', diff_mode: mode};
+            fetch((staged ? '/git/diff/staged?' : '/git/diff?') + buildQuery(params), {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({repo_path: currentDiffPath})
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                if (!result) {
+                    output.textContent = staged ? 'No staged changes' : 'No unstaged changes';
+                } else if (mode === 'color-words') {
+                    renderColorWordDiff(output, result);
+                } else if (mode !== 'line') {
+                    renderWordDiff(output, result);
+                } else {
+                    renderDiff(output, result);
+                }
+            })
+            .catch(function(error) {
+                output.textContent = '❌ Error: ' + error.message;
+            });
+        }
+
+        // renderDiff colours added lines green and removed lines red
+        function renderDiff(output, diff) {
+            output.innerHTML = '';
+            var lines = diff.split('\n');
+            for (var i = 0; i < lines.length; i++) {
+                var line = document.createElement('span');
+                if (lines[i].charAt(0) === '+') {
+                    line.className = 'stat-add';
+                } else if (lines[i].charAt(0) === '-') {
+                    line.className = 'stat-del';
+                }
+                line.textContent = lines[i] + '\n';
+                output.appendChild(line);
+            }
+        }
+
+        function appendDiffText(output, text, className) {
+            if (!text) {
+                return;
+            }
+            var span = document.createElement('span');
+            if (className) {
+                span.className = className;
+            }
+            span.textContent = text;
+            output.appendChild(span);
+        }
+
+        // renderWordDiff highlights the [-removed-] and {+added+} markers of git diff --word-diff=plain
+        function renderWordDiff(output, diff) {
+            output.innerHTML = '';
+            var marker = /\[-([\s\S]*?)-\]|\{\+([\s\S]*?)\+\}/g;
+            var last = 0;
+            var match;
+            while ((match = marker.exec(diff)) !== null) {
+                appendDiffText(output, diff.substring(last, match.index));
+                if (match[1] !== undefined) {
+                    appendDiffText(output, match[1], 'word-del');
+                } else {
+                    appendDiffText(output, match[2], 'word-add');
+                }
+                last = marker.lastIndex;
+            }
+            appendDiffText(output, diff.substring(last));
+        }
+
+        // renderColorWordDiff turns the red and green ANSI colours of git diff --word-diff=color into highlights
+        function renderColorWordDiff(output, diff) {
+            output.innerHTML = '';
+            var parts = diff.split(/\x1b\[([0-9;]*)m/);
+            var className = '';
+            for (var i = 0; i < parts.length; i++) {
+                if (i % 2 === 1) {
+                    className = parts[i] === '31' ? 'word-del' : (parts[i] === '32' ? 'word-add' : '');
+                } else {
+                    appendDiffText(output, parts[i], className);
+                }
+            }
+        }
+
+        function openLogModal(projectPath, projectName, author, file) {
+            logState.path = projectPath;
+            logState.file = file || '';
+            document.getElementById('logFileBanner').style.display = logState.file ? 'block' : 'none';
+            document.getElementById('logFileName').textContent = logState.file;
+            document.getElementById('logTitle').textContent = '📜 History: ' + projectName;
+            document.getElementById('logModal').style.display = 'block';
+            document.getElementById('logRelativeDates').checked = useRelativeDates();
+            setLogAuthor(author || '');
+        }
+
+        function closeLogModal() {
+            var modal = document.getElementById('logModal');
+            if (modal) {
+                modal.style.display = 'none';
+            }
+            logState.path = '';
+            logState.file = '';
+            document.getElementById('logFileBanner').style.display = 'none';
+            document.getElementById('logActionOutput').style.display = 'none';
+        }
+
+        function setLogFilter(filter) {
+            logState.filter = filter;
+            var buttons = document.querySelectorAll('.log-filter');
+            for (var i = 0; i < buttons.length; i++) {
+                var active = buttons[i].getAttribute('data-filter') === filter;
+                buttons[i].className = 'btn btn-sm log-filter' + (active ? '' : ' btn-secondary');
+            }
+            loadLog();
+        }
+
+        function setLogFile(file) {
+            logState.file = file;
+            document.getElementById('logFileBanner').style.display = file ? 'block' : 'none';
+            document.getElementById('logFileName').textContent = file;
+            loadLog();
+        }
+
+        function setLogAuthor(author) {
+            logState.author = author;
+            document.getElementById('logAuthorBanner').style.display = author ? 'block' : 'none';
+            document.getElementById('logAuthorName').textContent = author;
+            loadLog();
+        }
+
+        function useRelativeDates() {
+            return localStorage.getItem('logRelativeDates') === 'true';
+        }
+
+        function setRelativeDates(enabled) {
+            localStorage.setItem('logRelativeDates', enabled ? 'true' : 'false');
+            loadLog();
+        }
+
+        // formatCommitDate turns git's ISO-like "%ai" date into "3 days ago" when relative dates are enabled
+        function formatCommitDate(value) {
+            if (!useRelativeDates() || !window.Intl || !Intl.RelativeTimeFormat) {
+                return value;
+            }
+
+            var iso = value.replace(' ', 'T').replace(/ ([+-]\d\d)(\d\d)$/, '$1:$2');
+            var date = new Date(iso);
+            if (isNaN(date.getTime())) {
+                return value;
+            }
+
+            var seconds = (date.getTime() - Date.now()) / 1000;
+            var units = [['year', 31536000], ['month', 2592000], ['week', 604800], ['day', 86400], ['hour', 3600], ['minute', 60]];
+            var formatter = new Intl.RelativeTimeFormat(undefined, {numeric: 'auto'});
+            for (var i = 0; i < units.length; i++) {
+                if (Math.abs(seconds) >= units[i][1]) {
+                    return formatter.format(Math.round(seconds / units[i][1]), units[i][0]);
+                }
+            }
+            return formatter.format(Math.round(seconds), 'second');
+        }
+
+        function setLogStats(enabled) {
+            logState.stats = enabled;
+            loadLog();
+        }
+
+        // Compact mode trades author, date and stats for one line per commit, so far more commits fit on screen
+        function setLogCompact(enabled) {
+            logState.compact = enabled;
+            document.getElementById('logStats').disabled = enabled;
+            loadLog();
+        }
+
+        function setLogFirstParent(enabled) {
+            logState.firstParent = enabled;
+            loadLog();
+        }
+
+        function setLogDateRange() {
+            var after = document.getElementById('logAfter').value;
+            var before = document.getElementById('logBefore').value;
+            if (after && before && after > before) {
+                document.getElementById('logStatus').textContent = '❌ The start date must not be after the end date';
+                return;
+            }
+            logState.after = after;
+            logState.before = before;
+            loadLog();
+        }
+
+        function clearLogDateRange() {
+            document.getElementById('logAfter').value = '';
+            document.getElementById('logBefore').value = '';
+            setLogDateRange();
+        }
+
+        function setLogBranch(branch) {
+            logState.branch = branch;
+            loadLog();
+        }
+
+        function setLogOrder(order) {
+            logState.order = order;
+            var buttons = document.querySelectorAll('.log-order');
+            for (var i = 0; i < buttons.length; i++) {
+                var active = buttons[i].getAttribute('data-order') === order;
+                buttons[i].className = 'btn btn-sm log-order' + (active ? '' : ' btn-secondary');
+            }
+            loadLog();
+        }
+
+        function loadLog() {
+            var status = document.getElementById('logStatus');
+            var body = document.getElementById('logBody');
+            status.textContent = '🔄 Loading...';
+            body.innerHTML = '';
+            document.getElementById('commitDetail').style.display = 'none';
+
+            var url = '/git/log';
+            var params = {
+                repo_path: logState.path,
+                filter: logState.filter,
+                order: logState.order,
+                all: logState.branch === 'all',
+                author: logState.author,
+                include_stats: logState.stats,
+                first_parent: logState.firstParent,
+                path: logState.file,
+                after: logState.after,
+                before: nextDay(logState.before)
+            };
+
+            var compact = logState.compact;
+            if (logState.search && logState.searchMode === 'code') {
+                url = '/git/log/pickaxe';
+                params = {repo_path: logState.path, q: logState.search, regex: logState.regex};
+                compact = false;
+            } else if (logState.search) {
+                params.grep = logState.search;
+            }
+            if (compact) {
+                params.compact = true;
+                params.include_stats = false;
+                params.limit = 1000;
+            }
+            document.getElementById('logTable').className = 'log-table' + (compact ? ' compact' : '');
+
+            fetch(url + '?' + buildQuery(params))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        status.textContent = '❌ ' + data.error;
+                        return;
+                    }
+                    if (compact) {
+                        renderCompactLog(data.commits || []);
+                        return;
+                    }
+                    renderLog(data.commits || []);
+                })
+                .catch(function(error) {
+                    status.textContent = '❌ Error: ' + error.message;
+                });
+        }
+
+        // nextDay turns an inclusive YYYY-MM-DD end date into git's exclusive --before bound.
+        function nextDay(date) {
+            if (!date) {
+                return '';
+            }
+            var next = new Date(date + 'T00:00:00Z');
+            next.setUTCDate(next.getUTCDate() + 1);
+            return next.toISOString().substring(0, 10);
+        }
+
+        function searchLog() {
+            logState.search = document.getElementById('logSearch').value.trim();
+            logState.searchMode = document.getElementById('logSearchMode').value;
+            logState.regex = document.getElementById('logSearchRegex').checked;
+            loadLog();
+        }
+
+        function clearLogSearch() {
+            document.getElementById('logSearch').value = '';
+            logState.search = '';
+            loadLog();
+        }
+
+        function renderLog(commits) {
+            var status = document.getElementById('logStatus');
+            var body = document.getElementById('logBody');
+            status.textContent = commits.length === 0 ? 'No commits found' : '';
+
+            // In all-branches mode commits are grouped under the branch they were reached from
+            var groups = {};
+            var order = [];
+            for (var i = 0; i < commits.length; i++) {
+                var branch = commits[i].branch || '';
+                if (!groups[branch]) {
+                    groups[branch] = [];
+                    order.push(branch);
+                }
+                groups[branch].push(commits[i]);
+            }
+
+            for (var g = 0; g < order.length; g++) {
+                if (order[g]) {
+                    var header = document.createElement('tr');
+                    header.className = 'log-group';
+                    var headerCell = document.createElement('td');
+                    headerCell.colSpan = 4;
+                    headerCell.textContent = '🌿 ' + order[g] + ' (' + groups[order[g]].length + ')';
+                    header.appendChild(headerCell);
+                    body.appendChild(header);
+                }
+                renderLogRows(body, groups[order[g]]);
+            }
+        }
+
+        function renderCompactLog(commits) {
+            var body = document.getElementById('logBody');
+            document.getElementById('logStatus').textContent = commits.length === 0 ? 'No commits found' : commits.length + ' commits';
+            for (var i = 0; i < commits.length; i++) {
+                var row = document.createElement('tr');
+                var hashCell = document.createElement('td');
+                hashCell.className = 'hash';
+                hashCell.textContent = commits[i].hash;
+                var messageCell = document.createElement('td');
+                messageCell.colSpan = 3;
+                messageCell.textContent = commits[i].message;
+                row.appendChild(hashCell);
+                row.appendChild(messageCell);
+                row.onclick = (function(commit) {
+                    return function() { showCommitDetail({hash: commit.hash, subject: commit.message}); };
+                })(commits[i]);
+                body.appendChild(row);
+            }
+        }
+
+        function renderLogRows(body, commits) {
+            for (var i = 0; i < commits.length; i++) {
+                var commit = commits[i];
+                var row = document.createElement('tr');
+                var cells = [commit.hash.substring(0, 7), formatCommitDate(commit.date), commit.author, commit.subject];
+                for (var j = 0; j < cells.length; j++) {
+                    var cell = document.createElement('td');
+                    cell.textContent = cells[j];
+                    if (j === 0) {
+                        cell.className = 'hash';
+                        cell.title = commit.hash;
+                    }
+                    if (j === 1) {
+                        cell.title = commit.date;
+                    }
+                    if (j === 2) {
+                        cell.className = 'author';
+                        cell.title = 'Show commits by ' + commit.author;
+                        cell.onclick = (function(author) {
+                            return function(event) {
+                                event.stopPropagation();
+                                setLogAuthor(author);
+                            };
+                        })(commit.author);
+                    }
+                    row.appendChild(cell);
+                }
+                if (commit.stats) {
+                    row.lastChild.appendChild(renderStatBar(commit.stats));
+                }
+                var diffBtn = document.createElement('button');
+                diffBtn.className = 'btn btn-secondary btn-sm diff-toggle';
+                diffBtn.textContent = 'Show diff';
+                diffBtn.onclick = (function(commit, row) {
+                    return function(event) {
+                        event.stopPropagation();
+                        toggleCommitPatch(commit, row, this);
+                    };
+                })(commit, row);
+                row.lastChild.appendChild(diffBtn);
+                var fixupBtn = document.createElement('button');
+                fixupBtn.className = 'btn btn-secondary btn-sm diff-toggle';
+                fixupBtn.textContent = 'Create fixup';
+                fixupBtn.title = 'Stage all current changes and commit them as a fixup of this commit';
+                fixupBtn.onclick = (function(commit) {
+                    return function(event) {
+                        event.stopPropagation();
+                        createFixup(commit);
+                    };
+                })(commit);
+                row.lastChild.appendChild(fixupBtn);
+                row.onclick = (function(commit) {
+                    return function() { showCommitDetail(commit); };
+                })(commit);
+                body.appendChild(row);
+            }
+        }
+
+        function createFixup(commit) {
+            if (!confirm('Stage all current changes and commit them as a fixup of ' + commit.hash.substring(0, 7) + '?\n\n' + commit.subject)) {
+                return;
+            }
+            logAction('/git/fixup', {target_hash: commit.hash});
+        }
+
+        function autosquashRebase() {
+            var base = prompt('Rebase onto (fixup commits after this point are squashed):', '@{upstream}');
+            if (base === null) {
+                return;
+            }
+            logAction('/git/rebase/autosquash', {base: base.trim()});
+        }
+
+        // logAction runs a history changing request and reloads the log afterwards
+        function logAction(url, body) {
+            var output = document.getElementById('logActionOutput');
+            output.style.display = 'block';
+            output.textContent = '🔄 Running...';
+            body.repo_path = logState.path;
+
+            fetch(url, {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify(body)
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                output.textContent = result;
+                loadLog();
+            })
+            .catch(function(error) {
+                output.textContent = '❌ Error: ' + error.message;
+            });
+        }
+
+        function toggleCommitPatch(commit, row, button) {
+            var next = row.nextSibling;
+            if (next && next.className === 'log-patch') {
+                next.parentNode.removeChild(next);
+                button.textContent = 'Show diff';
+                return;
+            }
+
+            var patchRow = document.createElement('tr');
+            patchRow.className = 'log-patch';
+            var cell = document.createElement('td');
+            cell.colSpan = 4;
+            var pre = document.createElement('pre');
+            pre.className = 'output';
+            pre.textContent = '🔄 Loading diff...';
+            cell.appendChild(pre);
+            patchRow.appendChild(cell);
+            row.parentNode.insertBefore(patchRow, row.nextSibling);
+            button.textContent = 'Hide diff';
+
+            fetch('/git/log?' + buildQuery({repo_path: logState.path, rev: commit.hash, limit: 1, include_patch: 'true'}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        pre.textContent = '❌ ' + data.error;
+                        return;
+                    }
+                    var commits = data.commits || [];
+                    pre.textContent = commits.length > 0 && commits[0].patch ? commits[0].patch : 'No changes';
+                })
+                .catch(function(error) {
+                    pre.textContent = '❌ Error: ' + error.message;
+                });
+        }
+
+        function renderStatBar(stats) {
+            var additions = 0;
+            var deletions = 0;
+            var files = [];
+            for (var i = 0; i < stats.length; i++) {
+                additions += stats[i].additions;
+                deletions += stats[i].deletions;
+                files.push(stats[i].path + ' (+' + stats[i].additions + ' -' + stats[i].deletions + ')');
+            }
+
+            var bar = document.createElement('span');
+            bar.className = 'stat-bar';
+            bar.title = files.join('\n');
+            bar.innerHTML = '<span class="stat-add">+' + additions + '</span> <span class="stat-del">-' + deletions + '</span>';
+            return bar;
+        }
+
+        function showCommitDetail(commit) {
+            var detail = document.getElementById('commitDetail');
+            var tabs = document.getElementById('commitNoteTabs');
+            var output = document.getElementById('commitDetailOutput');
+
+            detail.style.display = 'block';
+            document.getElementById('commitDetailTitle').textContent = '🔖 ' + commit.hash.substring(0, 7) + ' ' + commit.subject;
+            tabs.innerHTML = '';
+            output.textContent = '🔄 Loading notes...';
+
+            fetch('/git/notes/all?' + buildQuery({repo_path: logState.path, hash: commit.hash}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        output.textContent = '❌ ' + data.error;
+                        return;
+                    }
+
+                    var namespaces = Object.keys(data.notes || {}).sort();
+                    if (namespaces.length === 0) {
+                        output.textContent = 'No notes attached to this commit';
+                        return;
+                    }
+
+                    var selectTab = function(namespace) {
+                        var buttons = tabs.querySelectorAll('button');
+                        for (var i = 0; i < buttons.length; i++) {
+                            buttons[i].className = 'btn btn-sm' + (buttons[i].textContent === namespace ? '' : ' btn-secondary');
+                        }
+                        output.textContent = data.notes[namespace];
+                    };
+
+                    for (var i = 0; i < namespaces.length; i++) {
+                        var tab = document.createElement('button');
+                        tab.textContent = namespaces[i];
+                        tab.onclick = (function(namespace) {
+                            return function() { selectTab(namespace); };
+                        })(namespaces[i]);
+                        tabs.appendChild(tab);
+                    }
+                    selectTab(namespaces[0]);
+                })
+                .catch(function(error) {
+                    output.textContent = '❌ Error: ' + error.message;
+                });
+        }
+
+        function openToolsModal(projectPath, projectName) {
+            currentToolsPath = projectPath;
+            var modal = document.getElementById('toolsModal');
+            var title = document.getElementById('toolsTitle');
+
+            if (modal && title) {
+                title.textContent = '🧰 Repository Tools: ' + projectName;
+                showToolsOutput('Tool results will be shown here...');
+                modal.style.display = 'block';
+                loadMaintenanceStatus();
+                loadStorageStats();
+                loadProjectIdentity();
+            }
+        }
+
+        function closeToolsModal() {
+            var modal = document.getElementById('toolsModal');
+            if (modal) {
+                modal.style.display = 'none';
+            }
+            currentToolsPath = '';
+        }
+
+        function showToolsOutput(text, isError) {
+            var output = document.getElementById('toolsOutput');
+            if (output) {
+                output.textContent = text;
+                output.className = 'output ' + (isError ? 'error' : 'success');
+            }
+        }
+
+        function buildQuery(params) {
+            var parts = [];
+            for (var key in params) {
+                var values = [].concat(params[key]);
+                for (var i = 0; i < values.length; i++) {
+                    if (values[i] === undefined || values[i] === null || values[i] === '') continue;
+                    parts.push(encodeURIComponent(key) + '=' + encodeURIComponent(values[i]));
+                }
+            }
+            return parts.join('&');
+        }
+
+        function toolsGet(url, params, render) {
+            params.repo_path = currentToolsPath;
+            showToolsOutput('🔄 Loading...');
+
+            fetch(url + '?' + buildQuery(params))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        showToolsOutput('❌ ' + data.error, true);
+                        return;
+                    }
+                    showToolsOutput(render(data));
+                })
+                .catch(function(error) {
+                    showToolsOutput('❌ Error: ' + error.message, true);
+                });
+        }
+
+        function resetRepository() {
+            var ref = document.getElementById('resetRef').value.trim() || 'HEAD';
+            var mode = document.querySelector('input[name="resetMode"]:checked').value;
+            var body = {ref: ref, mode: mode};
+
+            if (mode === 'hard') {
+                if (!confirm('Hard reset to ' + ref + '?\nAll uncommitted changes will be lost.')) return;
+                body.confirm = true;
+            }
+            toolsPost('/git/reset', body);
+        }
+
+        function toolsPost(url, body) {
+            body.repo_path = currentToolsPath;
+            showToolsOutput('🔄 Running...');
+
+            fetch(url, {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify(body)
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                showToolsOutput(result);
+            })
+            .catch(function(error) {
+                showToolsOutput('❌ Error: ' + error.message, true);
+            });
+        }
+
+        function formatCommits(commits) {
+            if (!commits || commits.length === 0) {
+                return 'No commits found';
+            }
+
+            var lines = [];
+            for (var i = 0; i < commits.length; i++) {
+                var commit = commits[i];
+                lines.push(commit.hash.substring(0, 7) + '  ' + commit.date + '  ' + commit.author + '  ' + commit.subject);
+                var files = commit.files || [];
+                for (var j = 0; j < files.length; j++) {
+                    lines.push('        ' + files[j]);
+                }
+            }
+            return lines.join('\n');
+        }
+
+        function showMaintenanceStatus(data) {
+            var badge = document.getElementById('maintenanceBadge');
+            if (data.error) {
+                badge.textContent = 'unknown';
+                badge.className = 'badge';
+                return;
+            }
+            badge.textContent = data.registered ? '✅ enrolled' : 'not enrolled';
+            badge.className = 'badge' + (data.registered ? ' success' : '');
+        }
+
+        function loadMaintenanceStatus() {
+            fetch('/git/maintenance/register?' + buildQuery({repo_path: currentToolsPath}))
+                .then(function(response) { return response.json(); })
+                .then(showMaintenanceStatus)
+                .catch(function() { showMaintenanceStatus({error: true}); });
+        }
+
+        function setMaintenance(enroll) {
+            var request = enroll
+                ? fetch('/git/maintenance/register', {
+                    method: 'POST',
+                    headers: {'Content-Type': 'application/json'},
+                    body: JSON.stringify({repo_path: currentToolsPath})
+                })
+                : fetch('/git/maintenance/register?' + buildQuery({repo_path: currentToolsPath}), {method: 'DELETE'});
+
+            showToolsOutput('🔄 Updating maintenance...');
+            request
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    showMaintenanceStatus(data);
+                    if (data.error) {
+                        showToolsOutput('❌ ' + data.error, true);
+                    } else {
+                        showToolsOutput('✅ Scheduled maintenance ' + (data.registered ? 'enabled' : 'disabled') + '\n' + data.output);
+                    }
+                })
+                .catch(function(error) {
+                    showToolsOutput('❌ Error: ' + error.message, true);
+                });
+        }
+
+        function formatDuration(seconds) {
+            if (seconds < 3600) return Math.floor(seconds / 60) + ' min';
+            if (seconds < 86400) return Math.floor(seconds / 3600) + ' h';
+            return Math.floor(seconds / 86400) + ' days';
+        }
+
+        function loadStorageStats() {
+            var badge = document.getElementById('storageStats');
+            fetch('/git/count-objects?' + buildQuery({repo_path: currentToolsPath}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        badge.textContent = 'unknown';
+                        return;
+                    }
+                    document.getElementById('reflogSize').textContent = data.reflog_kib === null ? 'unknown' : data.reflog_kib + ' KiB';
+                    var objects = data.objects;
+                    var text = objects['count'] + ' loose objects, ' + objects['packs'] + ' packs (' + objects['size-pack'] + ' KiB)';
+                    text += data.seconds_since_repack === null ? ', never repacked' : ', last repack ' + formatDuration(data.seconds_since_repack) + ' ago';
+                    badge.textContent = text;
+                })
+                .catch(function() { badge.textContent = 'unknown'; });
+        }
+
+        function optimizeStorage() {
+            var aggressive = document.getElementById('repackAggressive').checked;
+            showToolsOutput('🔄 Repacking...');
+
+            fetch('/git/repack', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({repo_path: currentToolsPath, aggressive: aggressive})
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                showToolsOutput(result);
+                loadStorageStats();
+            })
+            .catch(function(error) {
+                showToolsOutput('❌ Repack error: ' + error.message, true);
+            });
+        }
+
+        function expireReflog() {
+            var before = document.getElementById('reflogExpireBefore').value;
+            var all = document.getElementById('reflogExpireAll').checked;
+            var message = 'Delete ' + (all ? 'all reflog' : 'HEAD reflog') + ' entries older than ' + (before || '90 days') + '?\nCommits only reachable from them can no longer be recovered.';
+            if (!confirm(message)) return;
+
+            var body = {repo_path: currentToolsPath, all: all};
+            if (before) {
+                body.expire_before = new Date(before + 'T00:00:00').toISOString();
+            }
+            showToolsOutput('🔄 Expiring reflog...');
+
+            fetch('/git/reflog/expire', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify(body)
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                showToolsOutput(result);
+                loadStorageStats();
+            })
+            .catch(function(error) {
+                showToolsOutput('❌ Reflog expire error: ' + error.message, true);
+            });
+        }
+
+        function loadProjectIdentity() {
+            document.getElementById('metaUserName').value = '';
+            document.getElementById('metaUserEmail').value = '';
+            document.getElementById('backupCron').value = '';
+
+            fetch('/projects/meta?' + buildQuery({repo_path: currentToolsPath}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.meta) {
+                        document.getElementById('metaUserName').value = data.meta.git_user_name || '';
+                        document.getElementById('metaUserEmail').value = data.meta.git_user_email || '';
+                        document.getElementById('backupCron').value = data.meta.backup_cron || '';
+                    }
+                });
+        }
+
+        function saveProjectIdentity() {
+            fetch('/projects/meta', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({
+                    repo_path: currentToolsPath,
+                    git_user_name: document.getElementById('metaUserName').value.trim(),
+                    git_user_email: document.getElementById('metaUserEmail').value.trim()
+                })
+            })
+            .then(function(response) { return response.json(); })
+            .then(function(data) {
+                if (data.error) {
+                    showToolsOutput('❌ ' + data.error, true);
+                } else {
+                    showToolsOutput('✅ Git identity saved');
+                }
+            })
+            .catch(function(error) {
+                showToolsOutput('❌ Error: ' + error.message, true);
+            });
+        }
+
+        function backupToS3() {
+            var ref = document.getElementById('backupRef').value.trim() || 'HEAD';
+            toolsPost('/git/backup/s3', {ref: ref});
+        }
+
+        function saveBackupSchedule() {
+            fetch('/projects/meta', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({
+                    repo_path: currentToolsPath,
+                    backup_cron: document.getElementById('backupCron').value.trim()
+                })
+            })
+            .then(function(response) { return response.json(); })
+            .then(function(data) {
+                if (data.error) {
+                    showToolsOutput('❌ ' + data.error, true);
+                } else if (data.meta.backup_cron) {
+                    showToolsOutput('✅ Backups scheduled: ' + data.meta.backup_cron);
+                } else {
+                    showToolsOutput('✅ Scheduled backups disabled');
+                }
+            })
+            .catch(function(error) {
+                showToolsOutput('❌ Error: ' + error.message, true);
+            });
+        }
+
+        function loadFilteredLog(filter) {
+            var path = document.getElementById('historyPath').value.trim();
+            toolsGet('/git/log/filtered', {filter: filter, path: path}, function(data) {
+                return formatCommits(data.commits);
+            });
+        }
+
+        function stashBranch() {
+            var branchName = document.getElementById('stashBranchName').value.trim();
+            var stashIndex = parseInt(document.getElementById('stashIndex').value, 10) || 0;
+            if (!branchName) {
+                showToolsOutput('Please enter a branch name!', true);
+                return;
+            }
+
+            toolsPost('/git/stash/branch', {branch_name: branchName, stash_index: stashIndex});
+        }
+
+        function applyBundle() {
+            var bundlePath = document.getElementById('bundlePath').value.trim();
+            if (!bundlePath) {
+                showToolsOutput('Please enter a bundle path!', true);
+                return;
+            }
+
+            toolsPost('/git/bundle/fetch', {bundle_path: bundlePath});
+        }
+
+        function checkIgnore() {
+            var files = document.getElementById('checkIgnoreFiles').value.trim().split(/\s+/).filter(function(file) { return file; });
+            if (files.length === 0) {
+                showToolsOutput('Please enter at least one file!', true);
+                return;
+            }
+
+            toolsGet('/git/check-ignore', {file: files}, function(data) {
+                var results = data.results || [];
+                var lines = [];
+                for (var i = 0; i < results.length; i++) {
+                    var result = results[i];
+                    if (result.ignored) {
+                        lines.push('🙈 ' + result.file + ' is ignored by "' + result.pattern + '" (' + result.source + ':' + result.line + ')');
+                    } else if (result.pattern) {
+                        lines.push('👀 ' + result.file + ' is re-included by "' + result.pattern + '" (' + result.source + ':' + result.line + ')');
+                    } else {
+                        lines.push('👀 ' + result.file + ' is not ignored');
+                    }
+                }
+                return lines.join('\n') || 'No results';
+            });
+        }
+
+        function loadOrphanedNotes() {
+            var namespace = document.getElementById('notesNamespace').value.trim() || 'commits';
+            toolsGet('/git/notes/orphaned', {namespace: namespace}, function(data) {
+                var orphaned = data.orphaned || [];
+                document.getElementById('notesOrphanedSection').style.display = 'block';
+                document.getElementById('notesOrphanedCount').textContent = orphaned.length;
+                document.getElementById('notesOrphaned').textContent = orphaned.length > 0
+                    ? orphaned.join('\n')
+                    : 'No orphaned notes';
+                document.getElementById('notesPruneButton').disabled = orphaned.length === 0;
+                return '✅ Found ' + orphaned.length + ' orphaned notes in ' + namespace;
+            });
+        }
+
+        function mergeNotes() {
+            var notesRef = document.getElementById('notesMergeRef').value.trim();
+            if (!notesRef) {
+                showToolsOutput('Please enter the notes ref to merge!', true);
+                return;
+            }
+
+            toolsPost('/git/notes/merge', {
+                notes_ref: notesRef,
+                strategy: document.getElementById('notesMergeStrategy').value
+            });
+        }
+
+        function pruneNotes() {
+            var namespace = document.getElementById('notesNamespace').value.trim() || 'commits';
+            if (!confirm('Prune all orphaned notes in ' + namespace + '?')) {
+                return;
+            }
+
+            document.getElementById('notesOrphanedSection').style.display = 'none';
+            toolsPost('/git/notes/prune', {namespace: namespace});
+        }
+
+        function loadAssumedUnchanged(keepOutput) {
+            var table = document.getElementById('assumedTable');
+            var body = document.getElementById('assumedBody');
+
+            fetch('/git/assume-unchanged?' + buildQuery({repo_path: currentToolsPath}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        showToolsOutput('❌ ' + data.error, true);
+                        return;
+                    }
+
+                    body.innerHTML = '';
+                    var files = data.files || [];
+                    for (var i = 0; i < files.length; i++) {
+                        var row = document.createElement('tr');
+                        var pathCell = document.createElement('td');
+                        pathCell.textContent = '🙈 ' + files[i];
+                        var actionCell = document.createElement('td');
+                        var button = document.createElement('button');
+                        button.className = 'btn btn-secondary btn-sm';
+                        button.textContent = '👁️ Track changes';
+                        button.onclick = (function(path) {
+                            return function() { setAssumeUnchanged(path, false); };
+                        })(files[i]);
+                        actionCell.appendChild(button);
+                        row.appendChild(pathCell);
+                        row.appendChild(actionCell);
+                        body.appendChild(row);
+                    }
+                    table.style.display = files.length > 0 ? 'table' : 'none';
+                    if (!keepOutput) {
+                        showToolsOutput(files.length > 0 ? files.length + ' files assumed unchanged' : 'No files are assumed unchanged');
+                    }
+                })
+                .catch(function(error) {
+                    showToolsOutput('❌ Error: ' + error.message, true);
+                });
+        }
+
+        function setAssumeUnchanged(path, assume) {
+            if (!path) {
+                showToolsOutput('Please enter a file path!', true);
+                return;
+            }
+
+            fetch('/git/assume-unchanged', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({repo_path: currentToolsPath, file_path: path, assume: assume})
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                showToolsOutput(result);
+                loadAssumedUnchanged(true);
+            })
+            .catch(function(error) {
+                showToolsOutput('❌ Error: ' + error.message, true);
+            });
+        }
+
+        function loadWorktrees(keepOutput) {
+            var table = document.getElementById('worktreesTable');
+            var body = document.getElementById('worktreesBody');
+
+            fetch('/git/worktrees?' + buildQuery({repo_path: currentToolsPath}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        showToolsOutput('❌ ' + data.error, true);
+                        return;
+                    }
+
+                    body.innerHTML = '';
+                    var worktrees = data.worktrees || [];
+                    for (var i = 0; i < worktrees.length; i++) {
+                        var worktree = worktrees[i];
+                        var row = document.createElement('tr');
+
+                        var pathCell = document.createElement('td');
+                        pathCell.textContent = worktree.path + ' ';
+                        if (worktree.locked) {
+                            var padlock = document.createElement('span');
+                            padlock.textContent = '🔒';
+                            padlock.title = worktree.lock_reason || 'Locked';
+                            pathCell.appendChild(padlock);
+                        }
+
+                        var branchCell = document.createElement('td');
+                        branchCell.textContent = worktree.bare ? '(bare)' : (worktree.detached ? '(detached ' + worktree.head.substring(0, 7) + ')' : worktree.branch);
+
+                        var actionCell = document.createElement('td');
+                        // The main worktree cannot be locked
+                        if (i > 0) {
+                            var button = document.createElement('button');
+                            button.className = 'btn btn-secondary btn-sm';
+                            button.textContent = worktree.locked ? '🔓 Unlock' : '🔒 Lock';
+                            button.onclick = (function(worktree) {
+                                return function() { toggleWorktreeLock(worktree); };
+                            })(worktree);
+                            actionCell.appendChild(button);
+                        }
+
+                        row.appendChild(pathCell);
+                        row.appendChild(branchCell);
+                        row.appendChild(actionCell);
+                        body.appendChild(row);
+                    }
+                    table.style.display = worktrees.length > 0 ? 'table' : 'none';
+                    if (!keepOutput) {
+                        showToolsOutput(worktrees.length + ' worktrees');
+                    }
+                })
+                .catch(function(error) {
+                    showToolsOutput('❌ Error: ' + error.message, true);
+                });
+        }
+
+        function toggleWorktreeLock(worktree) {
+            var url = '/git/worktrees/unlock';
+            var body = {repo_path: currentToolsPath, worktree_path: worktree.path};
+            if (!worktree.locked) {
+                var reason = prompt('Lock worktree ' + worktree.path + '?\n\nReason (optional):', '');
+                if (reason === null) {
+                    return;
+                }
+                url = '/git/worktrees/lock';
+                body.reason = reason;
+            }
+
+            fetch(url, {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify(body)
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                showToolsOutput(result);
+                loadWorktrees(true);
+            })
+            .catch(function(error) {
+                showToolsOutput('❌ Error: ' + error.message, true);
+            });
+        }
+
+        function loadRemotes(keepOutput) {
+            var table = document.getElementById('remotesTable');
+            var body = document.getElementById('remotesBody');
+
+            fetch('/git/remotes?' + buildQuery({repo_path: currentToolsPath}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        showToolsOutput('❌ ' + data.error, true);
+                        return;
+                    }
+
+                    body.innerHTML = '';
+                    var remotes = data.remotes || [];
+                    for (var i = 0; i < remotes.length; i++) {
+                        var row = document.createElement('tr');
+                        var nameCell = document.createElement('td');
+                        renderRemoteName(nameCell, remotes[i].name);
+                        var urlCell = document.createElement('td');
+                        urlCell.textContent = remotes[i].fetch_url;
+                        if (remotes[i].push_url && remotes[i].push_url !== remotes[i].fetch_url) {
+                            urlCell.textContent += ' (push: ' + remotes[i].push_url + ')';
+                        }
+                        row.appendChild(nameCell);
+                        row.appendChild(urlCell);
+                        body.appendChild(row);
+                    }
+                    table.style.display = remotes.length > 0 ? 'table' : 'none';
+                    if (!keepOutput) {
+                        showToolsOutput(remotes.length + ' remotes');
+                    }
+                })
+                .catch(function(error) {
+                    showToolsOutput('❌ Error: ' + error.message, true);
+                });
+        }
+
+        function renderRemoteName(cell, name) {
+            cell.innerHTML = '';
+            var label = document.createElement('span');
+            label.textContent = name + ' ';
+            var editBtn = document.createElement('button');
+            editBtn.className = 'btn btn-secondary btn-sm';
+            editBtn.textContent = '✏️';
+            editBtn.title = 'Rename remote';
+            editBtn.onclick = function() { editRemoteName(cell, name); };
+            cell.appendChild(label);
+            cell.appendChild(editBtn);
+        }
+
+        function editRemoteName(cell, name) {
+            cell.innerHTML = '';
+            var input = document.createElement('input');
+            input.type = 'text';
+            input.value = name;
+            input.style.width = '140px';
+            var saveBtn = document.createElement('button');
+            saveBtn.className = 'btn btn-success btn-sm';
+            saveBtn.textContent = '💾';
+            saveBtn.onclick = function() {
+                var newName = input.value.trim();
+                if (!newName || newName === name) {
+                    renderRemoteName(cell, name);
+                    return;
+                }
+                renameRemote(name, newName);
+            };
+            var cancelBtn = document.createElement('button');
+            cancelBtn.className = 'btn btn-secondary btn-sm';
+            cancelBtn.textContent = '✖️';
+            cancelBtn.onclick = function() { renderRemoteName(cell, name); };
+            cell.appendChild(input);
+            cell.appendChild(saveBtn);
+            cell.appendChild(cancelBtn);
+            input.focus();
+        }
+
+        function renameRemote(oldName, newName) {
+            fetch('/git/remotes/rename', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({repo_path: currentToolsPath, old_name: oldName, new_name: newName})
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                showToolsOutput(result);
+                loadRemotes(true);
+            })
+            .catch(function(error) {
+                showToolsOutput('❌ Error: ' + error.message, true);
+            });
+        }
+
+        function loadRefs(pattern) {
+            var params = {
+                pattern: pattern,
+                format: '%(refname:short)|%(objectname:short)|%(creatordate:short)',
+                sort: '-creatordate'
+            };
+            toolsGet('/git/refs', params, function(data) {
+                var refs = data.refs || [];
+                if (refs.length === 0) {
+                    return 'No refs found';
+                }
+
+                var lines = [];
+                for (var i = 0; i < refs.length; i++) {
+                    lines.push(refs[i]['objectname:short'] + '  ' + refs[i]['creatordate:short'] + '  ' + refs[i]['refname:short']);
+                }
+                return lines.join('\n');
+            });
+        }
+
+        function loadTags(keepOutput) {
+            var table = document.getElementById('tagsTable');
+            var body = document.getElementById('tagsBody');
+
+            fetch('/git/tags?' + buildQuery({repo_path: currentToolsPath}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        showToolsOutput('❌ ' + data.error, true);
+                        return;
+                    }
+
+                    body.innerHTML = '';
+                    var tags = data.tags || [];
+                    for (var i = 0; i < tags.length; i++) {
+                        var tag = tags[i];
+                        var row = document.createElement('tr');
+                        var nameCell = document.createElement('td');
+                        nameCell.textContent = tag.name + ' ';
+                        nameCell.title = tag.is_annotated ? 'Annotated tag' : 'Lightweight tag';
+                        var hashCell = document.createElement('td');
+                        hashCell.className = 'hash';
+                        hashCell.textContent = tag.hash.substring(0, 7);
+                        hashCell.title = tag.hash;
+                        var dateCell = document.createElement('td');
+                        dateCell.textContent = tag.date.substring(0, 10);
+                        var actionCell = document.createElement('td');
+                        var deleteBtn = document.createElement('button');
+                        deleteBtn.className = 'btn btn-danger btn-sm';
+                        deleteBtn.textContent = '🗑️';
+                        deleteBtn.title = 'Delete tag';
+                        deleteBtn.onclick = (function(name) {
+                            return function() { deleteTag(name); };
+                        })(tag.name);
+                        actionCell.appendChild(deleteBtn);
+
+                        row.appendChild(nameCell);
+                        row.appendChild(hashCell);
+                        row.appendChild(dateCell);
+                        row.appendChild(actionCell);
+                        body.appendChild(row);
+
+                        // Only annotated tags can carry a signature
+                        if (tag.is_annotated) {
+                            verifyTag(tag.name, nameCell);
+                        }
+                    }
+                    table.style.display = tags.length > 0 ? 'table' : 'none';
+                    if (!keepOutput) {
+                        showToolsOutput(tags.length > 0 ? tags.length + ' tags' : 'No tags found');
+                    }
+                })
+                .catch(function(error) {
+                    showToolsOutput('❌ Error: ' + error.message, true);
+                });
+        }
+
+        function createTag() {
+            var name = document.getElementById('newTagName').value.trim();
+            if (!name) {
+                showToolsOutput('Please enter a tag name!', true);
+                return;
+            }
+            tagRequest('POST', {
+                name: name,
+                message: document.getElementById('newTagMessage').value.trim(),
+                annotated: document.getElementById('newTagAnnotated').checked
+            });
+        }
+
+        function deleteTag(name) {
+            if (!confirm('Delete tag ' + name + '?')) {
+                return;
+            }
+            var remote = confirm('Also delete ' + name + ' from origin?');
+            tagRequest('DELETE', {name: name, remote: remote});
+        }
+
+        function tagRequest(method, body) {
+            body.repo_path = currentToolsPath;
+            showToolsOutput('🔄 Running...');
+
+            fetch('/git/tags', {
+                method: method,
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify(body)
+            })
+            .then(function(response) { return response.text(); })
+            .then(function(result) {
+                showToolsOutput(result);
+                loadTags(true);
+            })
+            .catch(function(error) {
+                showToolsOutput('❌ Error: ' + error.message, true);
+            });
+        }
+
+        function verifyTag(tag, cell) {
+            fetch('/git/tags/verify?' + buildQuery({repo_path: currentToolsPath, tag: tag}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    var result = data.verification;
+                    if (data.error || !result || !result.signed) {
+                        return;
+                    }
+
+                    var shield = document.createElement('span');
+                    shield.textContent = '🛡️';
+                    if (result.validity === 'good') {
+                        shield.className = 'badge success';
+                        shield.title = 'Good signature from ' + result.signer_id + '\n' + result.timestamp + '\n' + result.key_fingerprint;
+                    } else if (result.validity === 'bad') {
+                        shield.className = 'badge danger';
+                        shield.title = 'BAD signature\n' + result.output;
+                    } else {
+                        shield.className = 'badge';
+                        shield.title = 'Signature could not be checked\n' + result.output;
+                    }
+                    cell.appendChild(shield);
+                });
+        }
+
+        function loadSymbolicRef() {
+            toolsGet('/git/symbolic-ref', {ref: 'HEAD'}, function(data) {
+                return 'HEAD -> ' + data.target;
+            });
+        }
+
+        function loadMergeBase() {
+            var a = document.getElementById('compareA').value.trim();
+            var b = document.getElementById('compareB').value.trim();
+            if (!a || !b) {
+                showToolsOutput('Please enter both commits or branches!', true);
+                return;
+            }
+
+            toolsGet('/git/merge-base', {a: a, b: b}, function(data) {
+                var lines = ['🔀 Common base of ' + a + ' and ' + b + ':', data.merge_base, ''];
+                if (data.a_is_ancestor && data.b_is_ancestor) {
+                    lines.push('✅ Both point to the same commit');
+                } else if (data.a_is_ancestor) {
+                    lines.push('⏩ ' + b + ' contains ' + a + ' (fast-forward possible)');
+                } else if (data.b_is_ancestor) {
+                    lines.push('⏩ ' + a + ' contains ' + b + ' (fast-forward possible)');
+                } else {
+                    lines.push('🔀 The branches have diverged');
+                }
+                return lines.join('\n');
+            });
+        }
+
+        function loadCherryLog() {
+            var left = document.getElementById('compareA').value.trim();
+            var right = document.getElementById('compareB').value.trim();
+            var section = document.getElementById('cherrySection');
+            var list = document.getElementById('cherryList');
+            if (!left || !right) {
+                showToolsOutput('Please enter both commits or branches!', true);
+                return;
+            }
+
+            section.style.display = 'none';
+            fetch('/git/log/cherry?' + buildQuery({repo_path: currentToolsPath, left: left, right: right}))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        showToolsOutput('❌ ' + data.error, true);
+                        return;
+                    }
+
+                    var commits = data.commits || [];
+                    list.innerHTML = '';
+                    for (var i = 0; i < commits.length; i++) {
+                        var label = document.createElement('label');
+                        label.style.display = 'block';
+                        var checkbox = document.createElement('input');
+                        checkbox.type = 'checkbox';
+                        checkbox.value = commits[i].hash;
+                        checkbox.checked = true;
+                        label.appendChild(checkbox);
+                        label.appendChild(document.createTextNode(' ' + commits[i].hash.substring(0, 7) + ' ' + commits[i].subject + ' (' + commits[i].author + ')'));
+                        list.appendChild(label);
+                    }
+
+                    document.getElementById('cherryTitle').textContent = 'Commits unique to ' + right + ' (not yet in ' + left + '):';
+                    section.style.display = commits.length > 0 ? 'block' : 'none';
+                    showToolsOutput(commits.length > 0 ? commits.length + ' commits unique to ' + right : 'All commits of ' + right + ' are already in ' + left);
+                })
+                .catch(function(error) {
+                    showToolsOutput('❌ Error: ' + error.message, true);
+                });
+        }
+
+        function cherryPickSelected() {
+            var boxes = document.querySelectorAll('#cherryList input:checked');
+            var commits = [];
+            // The log lists newest first, but commits must be applied oldest first
+            for (var i = boxes.length - 1; i >= 0; i--) {
+                commits.push(boxes[i].value);
+            }
+            if (commits.length === 0) {
+                showToolsOutput('Please select at least one commit!', true);
+                return;
+            }
+            if (!confirm('Cherry-pick ' + commits.length + ' commits onto the current branch?')) {
+                return;
+            }
+
+            toolsPost('/git/cherry-pick', {commits: commits});
+        }
+
+        function loadActivity() {
+            var chart = document.getElementById('activityChart');
+            var params = {
+                repo_path: currentToolsPath,
+                period: document.getElementById('activityPeriod').value,
+                since: document.getElementById('activitySince').value
+            };
+            chart.innerHTML = '';
+
+            fetch('/git/activity?' + buildQuery(params))
+                .then(function(response) { return response.json(); })
+                .then(function(data) {
+                    if (data.error) {
+                        showToolsOutput('❌ ' + data.error, true);
+                        return;
+                    }
+
+                    var buckets = data.activity || [];
+                    var max = 0;
+                    for (var i = 0; i < buckets.length; i++) {
+                        max = Math.max(max, buckets[i].commit_count);
+                    }
+
+                    for (var j = 0; j < buckets.length; j++) {
+                        var bucket = buckets[j];
+                        var row = document.createElement('div');
+                        row.className = 'activity-row';
+                        row.title = bucket.commit_count + ' commits by ' + bucket.unique_authors + ' authors';
+
+                        var label = document.createElement('span');
+                        label.className = 'period';
+                        label.textContent = bucket.period;
+                        var bar = document.createElement('div');
+                        bar.className = 'bar';
+                        bar.style.width = Math.round(bucket.commit_count / max * 70) + '%';
+                        var count = document.createElement('span');
+                        count.textContent = bucket.commit_count + ' (' + bucket.unique_authors + ' 👤)';
+
+                        row.appendChild(label);
+                        row.appendChild(bar);
+                        row.appendChild(count);
+                        chart.appendChild(row);
+                    }
+                    showToolsOutput(buckets.length > 0 ? buckets.length + ' periods with activity' : 'No commits in this time range');
+                })
+                .catch(function(error) {
+                    showToolsOutput('❌ Error: ' + error.message, true);
+                });
+        }
+
+        function loadReflog() {
+            var ref = document.getElementById('reflogRef').value.trim() || 'HEAD';
+            toolsGet('/git/reflog/walk', {ref: ref}, function(data) {
+                var entries = data.entries || [];
+                if (entries.length === 0) {
+                    return 'No reflog entries for ' + ref;
+                }
+
+                var lines = [];
+                for (var i = 0; i < entries.length; i++) {
+                    var entry = entries[i];
+                    lines.push(entry.selector + '  ' + formatCommitDate(entry.date) + '  ' + entry.hash.substring(0, 7) + '  ' + entry.action + '  (' + entry.subject + ')');
+                }
+                return lines.join('\n');
+            });
+        }
+
+        function loadRangeDiff() {
+            var r1 = document.getElementById('rangeDiffOld').value.trim();
+            var r2 = document.getElementById('rangeDiffNew').value.trim();
+            if (!r1 || !r2) {
+                showToolsOutput('Please enter both ranges!', true);
+                return;
+            }
+
+            toolsGet('/git/range-diff', {r1: r1, r2: r2}, function(data) {
+                return data.range_diff || 'The two series are identical';
+            });
+        }
+
+        function setSymbolicRef() {
+            var target = document.getElementById('symbolicRefTarget').value.trim();
+            if (!target) {
+                showToolsOutput('Please enter the target ref!', true);
+                return;
+            }
+
+            fetch('/git/symbolic-ref', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({repo_path: currentToolsPath, name: 'HEAD', target: target})
+            })
+            .then(function(response) { return response.json(); })
+            .then(function(data) {
+                if (data.error) {
+                    showToolsOutput('❌ ' + data.error, true);
+                } else {
+                    showToolsOutput('✅ HEAD -> ' + data.target);
+                }
+            })
+            .catch(function(error) {
+                showToolsOutput('❌ Error: ' + error.message, true);
+            });
+        }
+
+        function deployRef() {
+            var body = {
+                repo_path: currentToolsPath,
+                ref: document.getElementById('deployRef').value.trim(),
+                deploy_path: document.getElementById('deployPath').value.trim()
+            };
+            if (!body.ref || !body.deploy_path) {
+                showToolsOutput('Please enter ref and deploy path!', true);
+                return;
+            }
+
+            var send = function() {
+                return fetch('/git/deploy-ref', {
+                    method: 'POST',
+                    headers: {'Content-Type': 'application/json'},
+                    body: JSON.stringify(body)
+                }).then(function(response) { return response.json(); });
+            };
+
+            showToolsOutput('🔄 Checking deploy target...');
+            send()
+                .then(function(preview) {
+                    if (preview.error) {
+                        showToolsOutput('❌ ' + preview.error, true);
+                        return;
+                    }
+
+                    var names = [];
+                    for (var i = 0; i < preview.contents.length; i++) {
+                        names.push(preview.contents[i].name);
+                    }
+                    var contents = names.length ? names.join('\n') : '(empty)';
+                    body.deploy_path = preview.deploy_path;
+                    showToolsOutput('📁 ' + body.deploy_path + ':\n' + contents);
+
+                    if (!confirm('Deploy ' + body.ref + ' to ' + body.deploy_path + '?\n\nCurrent contents:\n' + contents)) {
+                        return;
+                    }
+
+                    body.confirm = true;
+                    showToolsOutput('🔄 Deploying...');
+                    return send().then(function(result) {
+                        if (result.error) {
+                            showToolsOutput('❌ ' + result.error, true);
+                        } else {
+                            showToolsOutput('✅ Deployed ' + body.ref + ' to ' + body.deploy_path + '\n' + result.output);
+                        }
+                    });
+                })
+                .catch(function(error) {
+                    showToolsOutput('❌ Deploy error: ' + error.message, true);
+                });
+        }
+
+        function loadBlame() {
+            var file = document.getElementById('blameFile').value.trim();
+            if (!file) {
+                showToolsOutput('Please enter a file path!', true);
+                return;
+            }
+
+            var ignore = document.getElementById('blameIgnore').value.trim().split(/\s+/).filter(function(rev) { return rev !== ''; });
+            toolsGet('/git/blame/ignore', {file: file, ignore: ignore}, function(data) {
+                var lines = [];
+                var entries = data.blame || [];
+                for (var i = 0; i < entries.length; i++) {
+                    var entry = entries[i];
+                    lines.push(entry.hash.substring(0, 7) + '  ' + entry.author + '  ' + entry.line + ': ' + entry.content);
+                }
+                return lines.join('\n') || 'Empty file';
+            });
+        }
+
+        function loadBisectVisualize() {
+            toolsGet('/git/bisect/visualize', {}, function(data) {
+                if (data.remaining === 1) {
+                    // Only the first bad commit is left, so show it in full
+                    var hash = data.output.trim().split(' ')[0];
+                    toolsGet('/git/commits', {hash: hash}, renderBisectCulprit);
+                    return '🎯 First bad commit: ' + data.output;
+                }
+                return '🔎 ' + data.remaining + ' commits left to test\n\n' + data.output;
+            });
+        }
+
+        function renderBisectCulprit(data) {
+            var commit = (data.commits || [])[0];
+            if (!commit) {
+                return 'Commit not found';
+            }
+
+            var lines = [
+                '🎯 First bad commit',
+                '',
+                'commit ' + commit.hash,
+                'Author: ' + commit.author,
+                'Date:   ' + formatCommitDate(commit.date),
+                '',
+                '    ' + commit.subject,
+                ''
+            ];
+            var stats = commit.stats || [];
+            for (var i = 0; i < stats.length; i++) {
+                lines.push('+' + stats[i].additions + ' -' + stats[i].deletions + '  ' + stats[i].path);
+            }
+            return lines.join('\n');
+        }
+
+        function subtreeSplit() {
+            var prefix = document.getElementById('subtreePrefix').value.trim();
+            if (!prefix) {
+                showToolsOutput('Please enter a directory prefix!', true);
+                return;
+            }
+
+            toolsPost('/git/subtree/split', {
+                prefix: prefix,
+                annotate: document.getElementById('subtreeAnnotate').value,
+                push_remote: document.getElementById('subtreeRemote').value.trim(),
+                push_branch: document.getElementById('subtreeBranch').value.trim()
+            });
+        }
+
+        function runFilterRepo() {
+            var args = document.getElementById('filterRepoArgs').value.trim().split(/\s+/).filter(function(arg) { return arg !== ''; });
+            if (args.length === 0) {
+                showToolsOutput('Please enter filter-repo arguments!', true);
+                return;
+            }
+
+            if (!confirm('Rewrite the history of this repository?\n\n' + currentToolsPath + '\ngit filter-repo ' + args.join(' '))) {
+                return;
+            }
+
+            toolsPost('/git/filter-repo', {args: args, confirm: true});
+        }
+
+        // Close modal with ESC key
+        document.addEventListener('keydown', function(event) {
+            if (event.key === 'Escape') {
+                closeCommitModal();
+                closeToolsModal();
+                closeLogModal();
+                closeDiffModal();
+                closePullModal();
+            }
+        });
+
+        // Close modal by clicking background
+        var commitModal = document.getElementById('commitModal');
+        if (commitModal) {
+            commitModal.addEventListener('click', function(event) {
+                if (event.target === this) {
+                    closeCommitModal();
+                }
+            });
+        }
+
+        // Close log modal by clicking background
+        var logModal = document.getElementById('logModal');
+        if (logModal) {
+            logModal.addEventListener('click', function(event) {
+                if (event.target === this) {
+                    closeLogModal();
+                }
+            });
+        }
+
+        // Close pull modal by clicking background
+        var pullModal = document.getElementById('pullModal');
+        if (pullModal) {
+            pullModal.addEventListener('click', function(event) {
+                if (event.target === this) {
+                    closePullModal();
+                }
+            });
+        }
+
+        // Close diff modal by clicking background
+        var diffModal = document.getElementById('diffModal');
+        if (diffModal) {
+            diffModal.addEventListener('click', function(event) {
+                if (event.target === this) {
+                    closeDiffModal();
+                }
+            });
+        }
+
+        // Close tools modal by clicking background
+        var toolsModal = document.getElementById('toolsModal');
+        if (toolsModal) {
+            toolsModal.addEventListener('click', function(event) {
+                if (event.target === this) {
+                    closeToolsModal();
+                }
+            });
+        }
+
+        // Commit with Enter key (Ctrl+Enter inside the message, where Enter adds a line)
+        document.addEventListener('keydown', function(event) {
+            if (event.key !== 'Enter' || document.getElementById('commitModal').style.display !== 'block') {
+                return;
+            }
+            if (event.target.tagName === 'TEXTAREA' && !event.ctrlKey && !event.metaKey) {
+                return;
+            }
+            event.preventDefault();
+            confirmPush();
+        });
+
+        // Load projects on page load
+        window.onload = function() {
+            refreshProjects();
+
+            // Deep link from the repository browser: /?history=<path>&name=<project>&file=<file>
+            var params = new URLSearchParams(window.location.search);
+            if (params.get('history')) {
+                openLogModal(params.get('history'), params.get('name') || params.get('history'), '', params.get('file'));
+            }
+        };
+    </script>
+</body>
+</html>`
+
+	t := template.Must(template.New("index").Parse(tmpl))
+	data := struct {
+		Host         string
+		User         string
+		AuthMethod   string
+		WorkingDir   string
+		GitHubTokens int
+		TokenAlert   string
+		PublicUI     bool
+
+		Profiles      []Profile
+		ActiveProfile string
+	}{
+		Host:         config.SSHHost,
+		User:         config.SSHUser,
+		AuthMethod:   config.AuthMethod,
+		WorkingDir:   config.WorkingDir,
+		GitHubTokens: len(config.GitHubTokens),
+		TokenAlert:   githubTokens.LastAlert(),
+		PublicUI:     config.WebUIUser == "" || config.WebUIPassword == "",
+
+		Profiles:      profileStore.All(),
+		ActiveProfile: config.ActiveProfile,
+	}
+
+	t.Execute(w, data)
+}
+
+func setupHandler(w http.ResponseWriter, r *http.Request) {
+	tmpl := `<!DOCTYPE html>
+<html>
+<head>
+    <title>SSH GitHub Manager - Setup</title>
+    <meta charset="UTF-8">
+    <style>
+        body { font-family: Arial, sans-serif; margin: 20px; background: #f5f5f5; }
+        .container { max-width: 800px; margin: 0 auto; background: white; padding: 30px; border-radius: 10px; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
+        .header { text-align: center; margin-bottom: 30px; color: #333; }
+        .form-group { margin: 15px 0; }
+        .form-group label { display: block; margin-bottom: 5px; font-weight: bold; }
+        .form-group input, .form-group select, .form-group textarea { width: 100%; padding: 10px; border: 1px solid #ddd; border-radius: 4px; font-size: 14px; box-sizing: border-box; }
+        .form-group input:focus, .form-group select:focus, .form-group textarea:focus { outline: none; border-color: #007bff; }
+        .btn { padding: 12px 24px; background: #007bff; color: white; border: none; border-radius: 5px; cursor: pointer; margin: 5px; }
+        .btn:hover { background: #0056b3; }
+        .btn-success { background: #28a745; }
+        .btn-success:hover { background: #1e7e34; }
+        .btn-secondary { background: #6c757d; }
+        .btn-secondary:hover { background: #5a6268; }
+        .auth-section { display: none; padding: 15px; background: #f8f9fa; border-radius: 5px; margin: 10px 0; }
+        .auth-section.active { display: block; }
+        .status { padding: 15px; border-radius: 5px; margin: 15px 0; }
+        .status.success { background: #d4edda; color: #155724; border: 1px solid #c3e6cb; }
+        .status.error { background: #f8d7da; color: #721c24; border: 1px solid #f5c6cb; }
+        .status.info { background: #d1ecf1; color: #0c5460; border: 1px solid #bee5eb; }
+        .status.warning { background: #fff3cd; color: #856404; border: 1px solid #ffeeba; }
+        .help-text { font-size: 12px; color: #666; margin-top: 5px; }
+        .token-row { display: flex; gap: 5px; margin-bottom: 5px; }
+        .form-group .token-row .token-label, .form-group .token-row .token-expires { width: 30%; }
+        .token-row .btn { margin: 0; padding: 8px 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>🔧 SSH GitHub Manager Setup</h1>
+            <p>Configure your server connection settings</p>
+        </div>
+
+        <form id="configForm">
+            <div class="form-group">
+                <label>🌐 Server Host/IP:</label>
+                <input type="text" id="sshHost" name="ssh_host" value="{{.SSHHost}}" placeholder="192.168.1.100 or example.com" required>
+                <div class="help-text">Your server IP address or domain name</div>
+            </div>
+
+            <div class="form-group">
+                <label>🔌 SSH Port:</label>
+                <input type="number" id="sshPort" name="ssh_port" value="{{.SSHPort}}" placeholder="22" required>
+                <div class="help-text">SSH port (usually 22)</div>
+            </div>
+
+            <div class="form-group">
+                <label>👤 SSH User:</label>
+                <input type="text" id="sshUser" name="ssh_user" value="{{.SSHUser}}" placeholder="root or username" required>
+                <div class="help-text">Server username</div>
+            </div>
+
+            <div class="form-group">
+                <label>🔐 Authentication Method:</label>
+                <select id="authMethod" name="auth_method" onchange="toggleAuthMethod()" required>
+                    <option value="password"{{if eq .AuthMethod "password"}} selected{{end}}>Password</option>
+                    <option value="key"{{if eq .AuthMethod "key"}} selected{{end}}>SSH Key</option>
+                    <option value="agent"{{if eq .AuthMethod "agent"}} selected{{end}}>SSH Agent</option>
+                </select>
+            </div>
+
+            <div id="passwordAuth" class="auth-section">
+                <div class="form-group">
+                    <label>🔑 SSH Password:</label>
+                    <input type="password" id="sshPassword" name="ssh_password" value="{{.SSHPassword}}" placeholder="Your server password">
+                    <div class="help-text">Password for SSH connection</div>
+                </div>
+            </div>
+
+            <div id="keyAuth" class="auth-section">
+                <div class="form-group">
+                    <label>🗝️ SSH Key Path:</label>
+                    <input type="text" id="sshKeyPath" name="ssh_key_path" value="{{.SSHKeyPath}}" placeholder="/home/username/.ssh/id_rsa">
+                    <div class="help-text">Full path to SSH private key file</div>
+                </div>
+                <div class="form-group">
+                    <label>🔐 Key Passphrase (optional):</label>
+                    <input type="password" id="sshKeyPassphrase" name="ssh_key_passphrase" value="{{.SSHKeyPassphrase}}" placeholder="Only for passphrase-protected keys" autocomplete="off">
+                    <div class="help-text">Stored encrypted in config.json</div>
+                </div>
+            </div>
+
+            <div id="agentAuth" class="auth-section">
+                <div class="help-text">Keys are taken from the running ssh-agent. <code>SSH_AUTH_SOCK</code> must be set in the environment of this server process.</div>
+            </div>
+
+            <div class="form-group">
+                <label>🔏 Known Hosts File (optional):</label>
+                <input type="text" id="knownHostsPath" name="known_hosts_path" value="{{.KnownHostsPath}}" placeholder="~/.ssh/known_hosts">
+                <div class="help-text">The server's host key is verified against this file. New hosts can be added with Test Connection.</div>
+            </div>
+
+            <div class="form-group">
+                <label>📁 Working Directory:</label>
+                <input type="text" id="workingDir" name="working_dir" value="{{.WorkingDir}}" placeholder="/root/projects" required>
+                <div class="help-text">Directory on server where Git repositories will be stored</div>
+            </div>
+
+            <div class="form-group">
+                <label>🚚 Deploy root (optional):</label>
+                <input type="text" id="deployRoot" name="deploy_root" value="{{.DeployRoot}}" placeholder="/var/www">
+                <div class="help-text">Deploy Ref only unpacks into directories below this one. Defaults to the working directory.</div>
+            </div>
+
+            <div class="form-group">
+                <label>🔍 Repository search depth (1–5):</label>
+                <input type="number" id="searchDepth" name="search_depth" value="{{if .SearchDepth}}{{.SearchDepth}}{{else}}2{{end}}" min="1" max="5">
+                <div class="help-text">How many directory levels below the working directory are searched for repositories. Raise it for nested monorepos, lower it for large flat directories.</div>
+            </div>
+
+            <div class="form-group">
+                <label>⏱️ Connection timeout (seconds):</label>
+                <input type="number" id="connectTimeout" name="connect_timeout" value="{{if .ConnectTimeout}}{{.ConnectTimeout}}{{else}}10{{end}}" min="1" max="300">
+                <div class="help-text">Applies to reaching the server and to the SSH handshake separately. Raise it for slow or distant hosts.</div>
+            </div>
+
+            <div class="form-group">
+                <label>🌐 Listen Address:</label>
+                <input type="text" id="listenAddr" name="listen_addr" value="{{.ListenAddr}}" placeholder=":8080">
+                <div class="help-text">Address of this web UI, e.g. <code>:8080</code> or <code>127.0.0.1:9000</code>. Currently listening on <code>{{currentListenAddr}}</code>. Changes take effect after a restart; <code>--addr</code> on the command line overrides it.</div>
+            </div>
+
+            <div class="form-group">
+                <label>🔑 Web UI Login:</label>
+                {{if not (and .WebUIUser .WebUIPassword)}}<div class="status warning">⚠️ No login is configured, so the web UI is publicly accessible to anyone who can reach it.</div>{{end}}
+                <input type="text" id="webUIUser" name="web_ui_user" value="{{.WebUIUser}}" placeholder="User" autocomplete="off">
+                <input type="password" id="webUIPassword" name="web_ui_password" value="" placeholder="{{if .WebUIPassword}}Leave empty to keep the current password{{else}}Password{{end}}" autocomplete="new-password" style="margin-top: 5px;">
+                <div class="help-text">The browser asks for these before showing any page. The password is stored as a bcrypt hash. Clear the user to turn the login off.</div>
+            </div>
+
+            <div class="form-group">
+                <label>✍️ Git Author (optional):</label>
+                <input type="text" id="gitAuthorName" name="git_author_name" value="{{.GitAuthorName}}" placeholder="Jane Doe">
+                <input type="email" id="gitAuthorEmail" name="git_author_email" value="{{.GitAuthorEmail}}" placeholder="jane@example.com" style="margin-top: 5px;">
+                <div class="help-text">Used for Signed-off-by trailers when a project has no identity of its own</div>
+            </div>
+
+            <div class="form-group">
+                <label>☁️ S3 Backups (optional):</label>
+                <input type="text" id="archiveS3Bucket" name="archive_s3_bucket" value="{{.ArchiveS3Bucket}}" placeholder="Bucket name">
+                <input type="text" id="archiveS3Region" name="archive_s3_region" value="{{.ArchiveS3Region}}" placeholder="Region (us-east-1)" style="margin-top: 5px;">
+                <input type="text" id="archiveS3KeyID" name="archive_s3_key_id" value="{{.ArchiveS3KeyID}}" placeholder="Access key ID" style="margin-top: 5px;">
+                <input type="password" id="archiveS3SecretKey" name="archive_s3_secret_key" value="{{.ArchiveS3SecretKey}}" placeholder="Secret access key" style="margin-top: 5px;">
+                <input type="text" id="archiveS3Endpoint" name="archive_s3_endpoint" value="{{.ArchiveS3Endpoint}}" placeholder="Endpoint URL for S3-compatible storage (optional)" style="margin-top: 5px;">
+                <div class="help-text">Project archives are uploaded as <code>&lt;project&gt;/&lt;project&gt;-&lt;commit&gt;-&lt;time&gt;.tar.gz</code></div>
+            </div>
+
+            <div class="form-group">
+                <label>📝 Commit Message Template (optional):</label>
+                <textarea id="commitMessageTemplate" name="commit_message_template" rows="4" placeholder="[{{"{{"}}.Branch{{"}}"}}] ">{{.CommitMessageTemplate}}</textarea>
+                <div class="help-text">Pre-fills the commit dialog. Placeholders: <code>{{"{{"}}.RepoName{{"}}"}}</code>, <code>{{"{{"}}.Branch{{"}}"}}</code>, <code>{{"{{"}}.Date{{"}}"}}</code>, <code>{{"{{"}}.User{{"}}"}}</code></div>
+            </div>
+
+            <div class="form-group">
+                <label>💬 Slack Signing Secret (optional):</label>
+                <input type="password" id="slackSigningSecret" name="slack_signing_secret" value="{{.SlackSigningSecret}}" placeholder="Slack app signing secret">
+                <div class="help-text">Enables the <code>/gitmanager pull &lt;repo&gt;</code> slash command at <code>/slack/command</code></div>
+            </div>
+
+            <div class="form-group">
+                <label>🐙 GitHub Tokens (Required!):</label>
+                <div id="githubTokens">
+                    {{range .GitHubTokens}}
+                    <div class="token-row">
+                        <input type="text" class="token-label" value="{{.Label}}" placeholder="Label">
+                        <input type="password" class="token-value" value="{{.Token}}" placeholder="ghp_xxxxxxxxxxxx">
+                        <input type="date" class="token-expires" value="{{if not .ExpiresAt.IsZero}}{{.ExpiresAt.Format "2006-01-02"}}{{end}}" title="Expiry date (optional)">
+                        <button type="button" class="btn btn-secondary" onclick="this.parentNode.remove()">✖️</button>
+                    </div>
+                    {{else}}
+                    <div class="token-row">
+                        <input type="text" class="token-label" placeholder="Label">
+                        <input type="password" class="token-value" placeholder="ghp_xxxxxxxxxxxx">
+                        <input type="date" class="token-expires" title="Expiry date (optional)">
+                        <button type="button" class="btn btn-secondary" onclick="this.parentNode.remove()">✖️</button>
+                    </div>
+                    {{end}}
+                </div>
+                <button type="button" class="btn btn-secondary" onclick="addTokenRow()">➕ Add token</button>
+                <div class="help-text">GitHub Personal Access Tokens are required for repositories. <a href="https://github.com/settings/tokens" target="_blank">Create one here</a>. With several tokens, the one with the most API requests left is used, and expired or exhausted tokens are skipped.</div>
+            </div>
+
+            <div style="text-align: center; margin-top: 30px;">
+                <button type="button" class="btn btn-secondary" onclick="testConnection()">🔍 Test Connection</button>
+                {{if .IsConfigured}}
+                <button type="button" class="btn btn-secondary" onclick="testGitHubCredentials()">🔐 Test GitHub credentials</button>
+                {{end}}
+                <button type="submit" class="btn btn-success">💾 Save Settings</button>
+            </div>
+        </form>
+
+        <div id="status"></div>
+
+        {{if .IsConfigured}}
+        <div style="text-align: center; margin-top: 20px;">
+            <button class="btn" onclick="window.location.href='/'">🏠 Back to Home</button>
+        </div>
+        {{end}}
+    </div>
+
+    <script>
+        function toggleAuthMethod() {
+            var authMethod = document.getElementById('authMethod').value;
+            var passwordAuth = document.getElementById('passwordAuth');
+            var keyAuth = document.getElementById('keyAuth');
+            var agentAuth = document.getElementById('agentAuth');
+            
+            passwordAuth.classList.toggle('active', authMethod === 'password');
+            keyAuth.classList.toggle('active', authMethod === 'key');
+            agentAuth.classList.toggle('active', authMethod === 'agent');
+        }
+
+        function showStatus(message, type) {
+            var status = document.getElementById('status');
+            status.innerHTML = '<div class="status ' + type + '">' + message + '</div>';
+        }
+
+        var pendingHostFingerprint = '';
+
+        // readConfigForm collects the setup form as a config object, converting numeric fields
+        function readConfigForm() {
+            var formData = new FormData(document.getElementById('configForm'));
+            var config = {};
+            for (var pair of formData.entries()) {
+                config[pair[0]] = pair[1];
+            }
+            config.search_depth = parseInt(config.search_depth, 10) || 0;
+            config.connect_timeout = parseInt(config.connect_timeout, 10) || 0;
+            config.github_tokens = readTokenRows();
+            // An empty password field keeps the stored hash
+            if (!config.web_ui_password) {
+                delete config.web_ui_password;
+            }
+            return config;
+        }
+
+        function readTokenRows() {
+            var tokens = [];
+            var rows = document.querySelectorAll('#githubTokens .token-row');
+            for (var i = 0; i < rows.length; i++) {
+                var token = rows[i].querySelector('.token-value').value.trim();
+                if (!token) {
+                    continue;
+                }
+                var expires = rows[i].querySelector('.token-expires').value;
+                tokens.push({
+                    label: rows[i].querySelector('.token-label').value.trim() || 'token ' + (tokens.length + 1),
+                    token: token,
+                    expires_at: expires ? expires + 'T00:00:00Z' : undefined
+                });
+            }
+            return tokens;
+        }
+
+        function addTokenRow() {
+            var row = document.createElement('div');
+            row.className = 'token-row';
+            row.innerHTML = '<input type="text" class="token-label" placeholder="Label">' +
+                '<input type="password" class="token-value" placeholder="ghp_xxxxxxxxxxxx">' +
+                '<input type="date" class="token-expires" title="Expiry date (optional)">' +
+                '<button type="button" class="btn btn-secondary" onclick="this.parentNode.remove()">✖️</button>';
+            document.getElementById('githubTokens').appendChild(row);
+        }
+
+        // testConnection streams each connection step from the server, so a slow host shows how far it got
+        function testConnection() {
+            var config = readConfigForm();
+            var steps = '';
+            
+            showStatus('🔄 Testing connection...', 'info');
+            
+            fetch('/ws/test-connection', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify(config)
+            })
+            .then(function(response) {
+                if ((response.headers.get('Content-Type') || '').indexOf('text/event-stream') !== 0) {
+                    return response.json().then(function(result) { showTestResult(result, ''); });
+                }
+
+                var reader = response.body.getReader();
+                var decoder = new TextDecoder();
+                var buffer = '';
+                var read = function() {
+                    return reader.read().then(function(chunk) {
+                        if (chunk.done) {
+                            return;
+                        }
+                        buffer += decoder.decode(chunk.value, {stream: true});
+                        var events = buffer.split('\n\n');
+                        buffer = events.pop();
+                        for (var i = 0; i < events.length; i++) {
+                            var event = parseServerEvent(events[i]);
+                            if (event.name === 'step') {
+                                steps += '✅ ' + escapeHtml(event.data.step) + ' <small>(' + event.data.elapsed_ms + ' ms)</small><br>';
+                                showStatus(steps + '🔄 Testing connection...', 'info');
+                            } else if (event.name === 'done') {
+                                showTestResult(event.data, steps);
+                            }
+                        }
+                        return read();
+                    });
+                };
+                return read();
+            })
+            .catch(function(error) {
+                showStatus(steps + '❌ Test error: ' + error.message, 'error');
+            });
+        }
+
+        function parseServerEvent(text) {
+            var event = {name: 'message', data: null};
+            var lines = text.split('\n');
+            for (var i = 0; i < lines.length; i++) {
+                if (lines[i].indexOf('event: ') === 0) {
+                    event.name = lines[i].substring(7);
+                } else if (lines[i].indexOf('data: ') === 0) {
+                    event.data = JSON.parse(lines[i].substring(6));
+                }
+            }
+            return event;
+        }
+
+        function escapeHtml(text) {
+            var div = document.createElement('div');
+            div.textContent = text;
+            return div.innerHTML;
+        }
+
+        // showTestResult shows the outcome below the steps completed so far
+        function showTestResult(result, steps) {
+            if (result.success) {
+                showStatus(steps + '✅ Connection successful! Server: ' + escapeHtml(result.message), 'success');
+            } else if (result.unknown_host) {
+                pendingHostFingerprint = result.unknown_host.fingerprint;
+                showStatus(steps + '⚠️ Unknown host ' + result.unknown_host.host + '<br>' +
+                    result.unknown_host.key_type + ' key fingerprint: <code>' + pendingHostFingerprint + '</code><br>' +
+                    'Only trust it if it matches the fingerprint of your server. ' +
+                    '<button type="button" class="btn btn-secondary" onclick="trustHost()">🔏 Trust and add</button>', 'error');
+            } else {
+                showStatus(steps + '❌ Connection error: ' + escapeHtml(result.error), 'error');
+            }
+        }
+
+        function trustHost() {
+            var config = readConfigForm();
+
+            showStatus('🔄 Adding host key...', 'info');
+
+            fetch('/test-connection/trust', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({
+                    ssh_host: config.ssh_host,
+                    ssh_port: config.ssh_port,
+                    fingerprint: pendingHostFingerprint
+                })
+            })
+            .then(function(response) { return response.json(); })
+            .then(function(result) {
+                if (result.success) {
+                    testConnection();
+                } else {
+                    showStatus('❌ Trust error: ' + result.error, 'error');
+                }
+            })
+            .catch(function(error) {
+                showStatus('❌ Trust error: ' + error.message, 'error');
+            });
+        }
+
+        function testGitHubCredentials() {
+            showStatus('🔄 Checking the git credential helper on the server...', 'info');
+
+            fetch('/git/credentials/test', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({host: 'github.com', protocol: 'https'})
+            })
+            .then(function(response) { return response.json(); })
+            .then(function(result) {
+                if (result.error) {
+                    showStatus('❌ ' + result.error, 'error');
+                } else if (result.credential.found) {
+                    showStatus('✅ A GitHub credential is stored for ' + (result.credential.username || 'github.com'), 'success');
+                } else {
+                    showStatus('⚠️ No GitHub credential is stored in the git credential helper', 'error');
+                }
+            })
+            .catch(function(error) {
+                showStatus('❌ Test error: ' + error.message, 'error');
+            });
+        }
+
+        document.getElementById('configForm').addEventListener('submit', function(e) {
+            e.preventDefault();
+            
+            var config = readConfigForm();
+            if (config.github_tokens.length === 0) {
+                showStatus('❌ At least one GitHub token is required', 'error');
+                return;
+            }
+            
+            showStatus('💾 Saving settings...', 'info');
+            
+            fetch('/save-config', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify(config)
+            })
+            .then(function(response) { return response.json(); })
+            .then(function(result) {
+                if (result.success) {
+                    showStatus('✅ Settings saved successfully! Redirecting in 2 seconds...', 'success');
+                    setTimeout(function() {
+                        window.location.href = '/';
+                    }, 2000);
+                } else {
+                    showStatus('❌ Save error: ' + result.error, 'error');
+                }
+            })
+            .catch(function(error) {
+                showStatus('❌ Error: ' + error.message, 'error');
+            });
+        });
+
+        // Show auth method on page load
+        window.onload = function() {
+            toggleAuthMethod();
+        };
+    </script>
+</body>
+</html>`
+
+	t := template.Must(template.New("setup").Funcs(template.FuncMap{
+		"currentListenAddr": func() string { return listenAddr },
+	}).Parse(tmpl))
+	t.Execute(w, config)
+}
+
+func projectsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check SSH connection
+	if err := ensureSSHConnection(); err != nil {
+		serveCachedProjects(w, "SSH connection not established: "+err.Error())
+		return
+	}
+
+	depth := config.SearchDepth
+	if value := r.URL.Query().Get("depth"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxSearchDepth {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":    fmt.Sprintf("Invalid depth %q (allowed: 1-%d)", value, maxSearchDepth),
+				"projects": []Project{},
+			})
+			return
+		}
+		depth = parsed
+	}
+
+	projects, err := sshManager.ListProjectsAtDepth(depth)
+	if err != nil {
+		serveCachedProjects(w, "Failed to get project list: "+err.Error())
+		return
+	}
+
+	if err := projectCache.Save(projects); err != nil {
+		log.Printf("⚠️ Project cache write failed: %v", err)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"projects": projects,
+		"error":    nil,
+	})
+}
+
+// serveCachedProjects answers with the last known project list when the server is unreachable.
+func serveCachedProjects(w http.ResponseWriter, reason string) {
+	projects, updatedAt, err := projectCache.Load()
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":    reason,
+			"projects": []Project{},
+		})
+		return
+	}
+
+	age := int64(time.Since(updatedAt).Seconds())
+	log.Printf("📦 Serving cached project list (%d seconds old)", age)
+	w.Header().Set("X-Cache-Age", strconv.FormatInt(age, 10))
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"projects":  projects,
+		"cached":    true,
+		"cache_age": age,
+		"warning":   reason,
+		"error":     nil,
+	})
+}
+
+func gitCloneHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Clone request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Check SSH connection
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoURL   string `json:"repo_url"`
+		Branch    string `json:"branch"`
+		Depth     int    `json:"depth"`
+		Recursive bool   `json:"recursive"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	if req.Branch != "" {
+		if err := git.ValidateRefName(req.Branch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "❌ Invalid branch name: %v", err)
+			return
+		}
+	}
+
+	if req.Depth < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "❌ Invalid clone depth: %d", req.Depth)
+		return
+	}
+
+	log.Printf("📥 Clone request: %s (branch: %s)", req.RepoURL, req.Branch)
+	result, err := sshManager.GitClone(req.RepoURL, req.Branch, req.Depth, req.Recursive)
+	if err != nil {
+		log.Printf("❌ Clone failed")
+		fmt.Fprintf(w, "❌ Clone error: %v\n%s", err, result)
+		return
+	}
+
+	log.Printf("✅ Clone successful")
+	fmt.Fprintf(w, "✅ Clone completed successfully!\n%s", result)
+}
+
+func gitPullHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Pull request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Check SSH connection
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	log.Printf("⬇️ Pull request: %s", req.RepoPath)
+	result, err := sshManager.GitPull(req.RepoPath)
+	if err != nil {
+		log.Printf("❌ Pull failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Pull error: %v\n%s", err, result)
+		if strings.Contains(result, "would be overwritten by merge") || strings.Contains(result, "stash them") {
+			fmt.Fprint(w, "\n💡 Your local changes conflict with the incoming ones. Stash them, pull again and then pop the stash.")
+		}
+		return
+	}
+
+	log.Printf("✅ Pull successful")
+	fmt.Fprintf(w, "✅ Pull completed successfully!\n%s", result)
+}
+
+func gitPushHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Push request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Check SSH connection
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath   string `json:"repo_path"`
+		Message    string `json:"message"`
+		SignOff    bool   `json:"sign_off"`
+		AllowEmpty bool   `json:"allow_empty"`
+		Branch     string `json:"branch"`
+
+		CommitDate *time.Time `json:"commit_date"` // RFC 3339
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+	if req.CommitDate != nil && req.CommitDate.After(time.Now()) {
+		fmt.Fprintf(w, "❌ Push error: commit date %s is in the future", req.CommitDate.Format(time.RFC3339))
+		return
+	}
+
+	opts := PushOptions{AllowEmpty: req.AllowEmpty, Branch: req.Branch, CommitDate: req.CommitDate}
+	if req.SignOff {
+		trailer, err := sshManager.signOffTrailer(req.RepoPath)
+		if err != nil {
+			fmt.Fprintf(w, "❌ Sign-off error: %v", err)
+			return
+		}
+		opts.Trailers = append(opts.Trailers, trailer)
+	}
+
+	log.Printf("⬆️ Push request: %s (message: %s)", req.RepoPath, req.Message)
+	result, err := sshManager.GitPush(req.RepoPath, req.Message, opts)
+	if err != nil {
+		log.Printf("❌ Push failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Push error: %v\n%s", err, result)
+		return
+	}
+
+	log.Printf("✅ Push successful")
+	fmt.Fprintf(w, "✅ Push completed successfully!\n%s", result)
+}
+
+func gitStatusHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Status request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Check SSH connection
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	log.Printf("📊 Status request: %s", req.RepoPath)
+	result, err := sshManager.GitStatus(req.RepoPath)
+	if err != nil {
+		log.Printf("❌ Status failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Status error: %v\n%s", err, result)
+		return
+	}
+
+	log.Printf("✅ Status successful")
+	fmt.Fprintf(w, "📊 Repository Status:\n%s", result)
+}
+
+func gitRemoveHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Remove request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Check SSH connection
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	log.Printf("🗑️ Remove request: %s", req.RepoPath)
+	result, err := sshManager.RemoveProject(req.RepoPath)
+	if err != nil {
+		log.Printf("❌ Remove failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Remove error: %v\n%s", err, result)
+		return
+	}
+
+	log.Printf("✅ Remove successful")
+	fmt.Fprintf(w, "✅ Project deleted successfully!\n%s", result)
+}
+
+func saveConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	// Start from the current configuration so settings missing from the form are kept
+	newConfig := *config
+	if err := json.NewDecoder(r.Body).Decode(&newConfig); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+
+	if newConfig.SearchDepth < 0 || newConfig.SearchDepth > maxSearchDepth {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   fmt.Sprintf("Repository search depth must be between 1 and %d", maxSearchDepth),
+		})
+		return
+	}
+
+	if newConfig.DeployRoot != "" && !path.IsAbs(strings.Replace(newConfig.DeployRoot, "\\", "/", -1)) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Deploy root must be an absolute path",
+		})
+		return
+	}
+
+	if newConfig.ListenAddr != "" {
+		if err := validateListenAddr(newConfig.ListenAddr); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   "Invalid listen address: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	// Without a user the login is off, and a later one has to choose a new password
+	if newConfig.WebUIUser == "" {
+		newConfig.WebUIPassword = ""
+	}
+	if newConfig.WebUIPassword != "" && !isPasswordHash(newConfig.WebUIPassword) {
+		hash, err := bcrypt.GenerateFromPassword([]byte(newConfig.WebUIPassword), bcrypt.DefaultCost)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"error":   "Password hashing failed: " + err.Error(),
+			})
+			return
+		}
+		newConfig.WebUIPassword = string(hash)
+	}
+
+	// Update configuration
+	newConfig.IsConfigured = true
+	config = &newConfig
+	githubTokens.SetTokens(config.GitHubTokens)
+
+	// Settings edited while a profile is active belong to that profile
+	if profile, ok := profileStore.Get(config.ActiveProfile); ok {
+		profile.Config = *config
+		profile.clearInstanceSettings()
+		if err := profileStore.Set(profile); err != nil {
+			log.Printf("⚠️ Profile %s not updated: %v", profile.ID, err)
+		}
+	}
+
+	// Recreate SSH manager
+	sshManager.Disconnect()
+	sshManager = NewSSHManager(config)
+
+	// Save to file
+	if err := saveConfig(config); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Configuration not saved: " + err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Configuration saved successfully",
+	})
+}
+
+func testConnectionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var testConfig Config
+	if err := json.NewDecoder(r.Body).Decode(&testConfig); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runConnectionTest(&testConfig, func(string) {}))
+}
+
+// testConnectionProgressHandler serves /ws/test-connection: it runs the same test as testConnectionHandler but streams
+// every connection step as a server-sent "step" event before the final "done" event carrying the usual result.
+func testConnectionProgressHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	var testConfig Config
+	if err := json.NewDecoder(r.Body).Decode(&testConfig); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	sendEvent := func(event string, payload interface{}) {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		flusher.Flush()
+	}
+
+	started := time.Now()
+	result := runConnectionTest(&testConfig, func(step string) {
+		sendEvent("step", map[string]interface{}{
+			"step":       step,
+			"elapsed_ms": time.Since(started).Milliseconds(),
+		})
+	})
+	sendEvent("done", result)
+}
+
+// runConnectionTest connects with cfg and runs a test command, returning the response for the setup page.
+func runConnectionTest(cfg *Config, progress func(step string)) map[string]interface{} {
+	// Create temporary SSH manager for testing
+	testManager := NewSSHManager(cfg)
+
+	if err := testManager.ConnectWithProgress(progress); err != nil {
+		response := map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		}
+		var unknownHost *ErrUnknownHost
+		if errors.As(err, &unknownHost) {
+			response["unknown_host"] = unknownHost
+		}
+		return response
+	}
+
+	// Run test command
+	output, err := testManager.ExecuteCommand("hostname && pwd")
+	testManager.Disconnect()
+
+	if err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"error":   "Command execution error: " + err.Error(),
+		}
+	}
+	progress("Test command succeeded")
+
+	return map[string]interface{}{
+		"success": true,
+		"message": strings.TrimSpace(output),
+	}
+}
+
+// trustHostHandler adds the host key of ssh_host:ssh_port to the server's own known_hosts file. Only the host, port and
+// fingerprint come from the request, so callers cannot choose which file is written.
+func trustHostHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		SSHHost     string `json:"ssh_host"`
+		SSHPort     string `json:"ssh_port"`
+		Fingerprint string `json:"fingerprint"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"error":   "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+
+	if req.SSHHost == "" || req.SSHPort == "" || req.Fingerprint == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"error":   "ssh_host, ssh_port and fingerprint are required",
+		})
+		return
+	}
+
+	target := &Config{SSHHost: req.SSHHost, SSHPort: req.SSHPort, SSHUser: config.SSHUser, KnownHostsPath: config.KnownHostsPath}
+	if err := NewSSHManager(target).TrustHost(req.Fingerprint); err != nil {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+	})
+}
+
+func configHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(redactedConfig(config))
+}
+
+// secrets returns the secret settings of c, apart from the GitHub tokens.
+func (c *Config) secrets() []*string {
+	return []*string{
+		&c.SSHPassword, &c.SSHKeyPassphrase, &c.SlackSigningSecret,
+		&c.ArchiveS3SecretKey, &c.WebUIPassword, &c.APIKey,
+	}
+}
+
+// restoreRedactedSecrets puts the stored value back wherever c holds the "***" of redactedConfig, so settings read
+// from the API can be sent back without losing their secrets. Redacted GitHub tokens are matched by position.
+func (c *Config) restoreRedactedSecrets(stored *Config) {
+	storedSecrets := stored.secrets()
+	for i, secret := range c.secrets() {
+		if *secret == "***" {
+			*secret = *storedSecrets[i]
+		}
+	}
+
+	tokens := make([]TokenEntry, 0, len(c.GitHubTokens))
+	for i, entry := range c.GitHubTokens {
+		if entry.Token == "***" {
+			if i >= len(stored.GitHubTokens) {
+				continue
+			}
+			entry.Token = stored.GitHubTokens[i].Token
+		}
+		tokens = append(tokens, entry)
+	}
+	c.GitHubTokens = tokens
+}
+
+// redactedConfig returns a copy of c with every configured secret replaced by "***", so /config shows which
+// secrets are set without revealing them.
+func redactedConfig(c *Config) Config {
+	redacted := *c
+	for _, secret := range redacted.secrets() {
+		if *secret != "" {
+			*secret = "***"
+		}
+	}
+
+	redacted.GitHubTokens = make([]TokenEntry, len(c.GitHubTokens))
+	for i, entry := range c.GitHubTokens {
+		entry.Token = "***"
+		redacted.GitHubTokens[i] = entry
+	}
+	return redacted
+}
+
+// ensureSSHConnection reconnects the shared SSH manager when no client is available.
+func ensureSSHConnection() error {
+	if sshManager.client == nil && sshManager.executor == nil {
+		log.Printf("🔌 SSH reconnecting")
+		if err := sshManager.Connect(); err != nil {
+			return err
+		}
+		sshReconnectionsTotal.Inc()
+		projectCache.Invalidate()
+	}
+	return nil
+}
+
+// writeInvalidPathStatus answers 400 for repository paths refused by validateRepoPath; the text handlers
+// otherwise report errors in the body only.
+func writeInvalidPathStatus(w http.ResponseWriter, err error) {
+	if status := statusForError(err, 0); status != 0 {
+		w.WriteHeader(status)
+	}
+}
+
+// statusForError returns 400 for repository paths refused by validateRepoPath, and fallback for any other error.
+func statusForError(err error, fallback int) int {
+	var invalid *ErrInvalidPath
+	if errors.As(err, &invalid) {
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func adminOrphansHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Orphans request received: %s", r.Method)
+
+	if r.Method != "GET" && r.Method != "DELETE" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	orphans, err := sshManager.FindOrphanedRepos(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"error": "Failed to find orphaned repositories: " + err.Error(),
+		})
+		return
+	}
+
+	if r.Method == "GET" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"orphans": orphans,
+			"error":   nil,
+		})
+		return
+	}
+
+	var req struct {
+		Paths   []string `json:"paths"`
+		Confirm bool     `json:"confirm"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+
+	if !req.Confirm {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Removal must be confirmed",
+		})
+		return
+	}
+
+	// Deletion is never implied: every path to remove must be listed
+	if len(req.Paths) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "paths must list the repositories to remove",
+		})
+		return
+	}
+
+	// Only remove paths that are still orphaned at the time of the request
+	requested := make(map[string]bool)
+	for _, path := range req.Paths {
+		requested[path] = true
+	}
+
+	removed := []string{}
+	for _, path := range orphans {
+		if !requested[path] {
+			continue
+		}
+		if _, err := sshManager.RemoveProject(path); err != nil {
+			writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+				"error":   "Failed to remove " + path + ": " + err.Error(),
+				"removed": removed,
+			})
+			return
+		}
+		removed = append(removed, path)
+	}
+
+	log.Printf("✅ Removed %d orphaned repositories", len(removed))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"removed": removed,
+		"error":   nil,
+	})
+}
+
+func gitFilteredLogHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Filtered log request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	commits, err := sshManager.FilteredLog(query.Get("repo_path"), query.Get("filter"), query.Get("path"), limit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Filtered log error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"commits": commits,
+		"error":   nil,
+	})
+}
+
+func gitBisectVisualizeHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Bisect visualize request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := sshManager.BisectVisualize(r.URL.Query().Get("repo_path"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Bisect visualize error: " + err.Error() + "\n" + result,
+		})
+		return
+	}
+
+	remaining := 0
+	for _, line := range strings.Split(result, "\n") {
+		if strings.TrimSpace(line) != "" {
+			remaining++
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"output":    result,
+		"remaining": remaining,
+		"error":     nil,
+	})
+}
+
+func filesCopyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 File copy request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	var req struct {
+		Src string `json:"src"`
+		Dst string `json:"dst"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+
+	if req.Src == "" || req.Dst == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Both src and dst are required",
+		})
+		return
+	}
+
+	if err := sshManager.CopyFile(req.Src, req.Dst); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, errOutsideWorkingDir):
+			status = http.StatusForbidden
+		case errors.Is(err, errSameFile):
+			status = http.StatusBadRequest
+		case errors.Is(err, os.ErrNotExist):
+			status = http.StatusNotFound
+		}
+		writeJSON(w, status, map[string]interface{}{
+			"error": "Copy error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"src":   req.Src,
+		"dst":   req.Dst,
+		"error": nil,
+	})
+}
+
+// gitFilterRepoHandler rewrites a repository's history. Like every route it needs the API key or the web UI login
+// (see apiKeyMiddleware).
+func gitFilterRepoHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Filter-repo request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string   `json:"repo_path"`
+		Args     []string `json:"args"`
+		Confirm  bool     `json:"confirm"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	// Rewriting history is destructive, require an explicit confirmation
+	if !req.Confirm {
+		http.Error(w, "❌ Filter-repo rewrites history and must be confirmed", http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("🧽 Filter-repo request: %s", req.RepoPath)
+	result, err := sshManager.FilterRepo(req.RepoPath, req.Args)
+	if err != nil {
+		log.Printf("❌ Filter-repo failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Filter-repo error: %v\n%s", err, result)
+		return
+	}
+
+	log.Printf("✅ Filter-repo successful")
+	fmt.Fprintf(w, "✅ Filter-repo completed successfully!\n%s", result)
+}
+
+func gitRefsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Refs request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	refs, err := sshManager.ForEachRef(query.Get("repo_path"), query.Get("pattern"), query.Get("format"), query.Get("sort"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Ref listing error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"refs":  refs,
+		"error": nil,
+	})
+}
+
+func gitSymbolicRefHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Symbolic ref request received: %s", r.Method)
+
+	if r.Method != "GET" && r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	if r.Method == "GET" {
+		query := r.URL.Query()
+		target, err := sshManager.GetSymbolicRef(query.Get("repo_path"), query.Get("ref"))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": "Symbolic ref error: " + err.Error(),
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"ref":    query.Get("ref"),
+			"target": target,
+			"error":  nil,
+		})
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+		Name     string `json:"name"`
+		Target   string `json:"target"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+
+	if err := sshManager.SetSymbolicRef(req.RepoPath, req.Name, req.Target); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Symbolic ref error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"ref":    req.Name,
+		"target": req.Target,
+		"error":  nil,
+	})
+}
+
+func gitDeployRefHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Deploy ref request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	var req struct {
+		RepoPath   string `json:"repo_path"`
+		Ref        string `json:"ref"`
+		DeployPath string `json:"deploy_path"`
+		Confirm    bool   `json:"confirm"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+
+	if req.Ref == "" || req.DeployPath == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "ref and deploy_path are required",
+		})
+		return
+	}
+	if strings.HasPrefix(req.Ref, "-") {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": fmt.Sprintf("invalid ref %q", req.Ref),
+		})
+		return
+	}
+
+	deployPath, err := sshManager.resolveDeployPath(req.DeployPath)
+	if err != nil {
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Without confirmation only show what is currently in the target directory
+	if !req.Confirm {
+		files, err := sshManager.ListFiles(deployPath)
+		if err != nil {
+			files = []FileInfo{}
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"confirm_required": true,
+			"deploy_path":      deployPath,
+			"contents":         files,
+			"error":            nil,
+		})
+		return
+	}
+
+	result, err := sshManager.DeployRef(req.RepoPath, req.Ref, deployPath)
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Deploy error: " + err.Error() + "\n" + result,
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"output": result,
+		"error":  nil,
+	})
+}
+
+func gitBlameIgnoreHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Blame request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	entries, err := sshManager.GitBlameIgnoreRev(query.Get("repo_path"), query.Get("file"), query["ignore"])
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Blame error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"blame": entries,
+		"error": nil,
+	})
+}
+
+func gitMaintenanceRegisterHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Maintenance request received: %s", r.Method)
+
+	if r.Method != "GET" && r.Method != "POST" && r.Method != "DELETE" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	repoPath := r.URL.Query().Get("repo_path")
+	output := ""
+
+	switch r.Method {
+	case "POST":
+		var req struct {
+			RepoPath string `json:"repo_path"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("❌ JSON decode error: %v", err)
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": "JSON parse error: " + err.Error(),
+			})
+			return
+		}
+
+		repoPath = req.RepoPath
+		result, err := sshManager.MaintenanceRegister(repoPath)
+		if err != nil {
+			writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+				"error": "Maintenance register error: " + err.Error() + "\n" + result,
+			})
+			return
+		}
+		output = result
+	case "DELETE":
+		if err := sshManager.MaintenanceUnregister(repoPath); err != nil {
+			writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+				"error": "Maintenance unregister error: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	registered, err := sshManager.MaintenanceRegistered(repoPath)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Maintenance status error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"registered": registered,
+		"output":     output,
+		"error":      nil,
+	})
+}
+
+// basicAuthPublicPaths stay reachable without credentials: the probes of container orchestrators,
+// and the Slack command, which is verified by its signing secret instead. registerMetrics adds /metrics
+// when scrapes carry their own token.
+var basicAuthPublicPaths = map[string]bool{
+	"/health":        true,
+	"/ready":         true,
+	"/slack/command": true,
+}
+
+// basicAuthVerified remembers Authorization headers already checked against a password hash,
+// since bcrypt is deliberately too slow to run for every request of a page.
+var basicAuthVerified sync.Map
+
+// basicAuthMiddleware asks for the web UI login, or for the API key as password while no login is configured. The
+// current config is read on every request, so credentials changed on the setup page apply immediately.
+func basicAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if basicAuthPublicPaths[r.URL.Path] || validBasicAuth(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("WWW-Authenticate", basicAuthChallenge)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+const basicAuthChallenge = `Basic realm="Remote Git Manager", charset="UTF-8"`
+
+func webUILoginConfigured() bool {
+	return config.WebUIUser != "" && config.WebUIPassword != ""
+}
+
+// validBasicAuth reports whether r carries the configured web UI login. Until a login is configured, browsers sign in
+// with the API key as password and any user name, so the web UI is never reachable without credentials.
+func validBasicAuth(r *http.Request) bool {
+	user, hash := config.WebUIUser, config.WebUIPassword
+	if user == "" || hash == "" {
+		_, password, ok := r.BasicAuth()
+		return ok && config.APIKey != "" && subtle.ConstantTimeCompare([]byte(password), []byte(config.APIKey)) == 1
+	}
+
+	key := sha256Hex([]byte(r.Header.Get("Authorization") + "\x00" + hash))
+	if _, ok := basicAuthVerified.Load(key); ok {
+		return true
+	}
+
+	givenUser, givenPassword, ok := r.BasicAuth()
+	if !ok || subtle.ConstantTimeCompare([]byte(givenUser), []byte(user)) != 1 ||
+		bcrypt.CompareHashAndPassword([]byte(hash), []byte(givenPassword)) != nil {
+		if ok {
+			log.Printf("🚫 Web UI login failed for %q: %s %s", givenUser, r.Method, r.URL.Path)
+		}
+		return false
+	}
+	basicAuthVerified.Store(key, true)
+	return true
+}
+
+// isAPIPath reports whether path is one of the routes machine clients call, which answer missing credentials with
+// a JSON error instead of the web UI's login prompt.
+func isAPIPath(path string) bool {
+	return path == "/projects" || strings.HasPrefix(path, "/projects/") ||
+		strings.HasPrefix(path, "/git/") ||
+		path == "/operations" ||
+		strings.HasPrefix(path, "/auth/") ||
+		strings.HasPrefix(path, "/system/")
+}
+
+// requestAPIKey returns the key sent as "Authorization: Bearer <key>" or "X-API-Key: <key>".
+func requestAPIKey(r *http.Request) (string, bool) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key, true
+	}
+	if key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return key, true
+	}
+	return "", false
+}
+
+// apiKeyMiddleware requires credentials on every route except basicAuthPublicPaths. Requests carrying the correct API
+// key go straight to next and those with a wrong one are rejected. Without a key, requests need the web UI login,
+// which the browser sends with the pages and their own API calls; pages go to fallback, the web UI's Basic Auth,
+// for the login prompt. While neither an API key nor a login is configured, every route is refused.
+func apiKeyMiddleware(next, fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if basicAuthPublicPaths[r.URL.Path] {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+
+		if key, sent := requestAPIKey(r); sent {
+			if config.APIKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(config.APIKey)) != 1 {
+				log.Printf("🚫 Invalid API key: %s %s", r.Method, r.URL.Path)
+				writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+					"error": "Invalid API key",
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if config.APIKey == "" && !webUILoginConfigured() {
+			log.Printf("🚫 No credentials configured: %s %s", r.Method, r.URL.Path)
+			writeJSON(w, http.StatusForbidden, map[string]interface{}{
+				"error": "This route is disabled until an API key or a web UI login is configured",
+			})
+			return
+		}
+
+		if !isAPIPath(r.URL.Path) {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		if !validBasicAuth(r) {
+			w.Header().Set("WWW-Authenticate", basicAuthChallenge)
+			writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+				"error": "An API key or the web UI login is required",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newAPIKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// ipFilterMiddleware rejects clients whose IP is denied or, when an allowlist is set, not allowed.
+func ipFilterMiddleware(allow, deny []string) func(http.Handler) http.Handler {
+	allowed := parseIPRanges(allow)
+	denied := parseIPRanges(deny)
+
+	return func(next http.Handler) http.Handler {
+		if len(allowed) == 0 && len(denied) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, err := clientIP(r)
+			if err != nil {
+				log.Printf("❌ Client IP error: %v", err)
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			if ipInRanges(ip, denied) || (len(allowed) > 0 && !ipInRanges(ip, allowed)) {
+				log.Printf("🚫 Blocked request from %s: %s %s", ip, r.Method, r.URL.Path)
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// parseIPRanges accepts CIDR ranges as well as single addresses; invalid entries are skipped.
+func parseIPRanges(entries []string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		log.Printf("⚠️ Ignoring invalid IP range: %s", entry)
+	}
+	return prefixes
+}
+
+func ipInRanges(ip netip.Addr, prefixes []netip.Prefix) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the request's source address, or the X-Forwarded-For hop added by the outermost trusted proxy
+// when running behind proxies. Hops are counted from the right: everything left of them came from the client and
+// can be forged.
+func clientIP(r *http.Request) (netip.Addr, error) {
+	if config.BehindProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			hops := strings.Split(strings.Join(forwarded, ","), ",")
+			trusted := config.TrustedProxies
+			if trusted <= 0 {
+				trusted = 1
+			}
+
+			hop := strings.TrimSpace(hops[max(len(hops)-trusted, 0)])
+			addr, err := netip.ParseAddr(hop)
+			if err != nil {
+				return netip.Addr{}, fmt.Errorf("invalid X-Forwarded-For address %q", hop)
+			}
+			return addr.Unmap(), nil
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid remote address %q", r.RemoteAddr)
+	}
+	return addr.Unmap().WithZone(""), nil
+}
+
+// verifySlackSignature checks the X-Slack-Signature header against the request body.
+func verifySlackSignature(secret string, header http.Header, body []byte) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid request timestamp")
+	}
+
+	// Reject old requests to prevent replay attacks
+	if age := time.Since(time.Unix(seconds, 0)); age > 5*time.Minute || age < -5*time.Minute {
+		return fmt.Errorf("request timestamp too old")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// matchProjects returns the projects named exactly like the query or, when there are none, those whose name contains
+// it (both case-insensitive). Callers act only on a single match and list the candidates otherwise, so a short name
+// never picks one of several projects by accident.
+func matchProjects(projects []Project, query string) []Project {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	for _, match := range []func(name string) bool{
+		func(name string) bool { return name == query },
+		func(name string) bool { return strings.Contains(name, query) },
+	} {
+		var matches []Project
+		for _, project := range projects {
+			if match(strings.ToLower(project.Name)) {
+				matches = append(matches, project)
+			}
+		}
+		if len(matches) > 0 {
+			return matches
+		}
+	}
+	return nil
+}
+
+func postSlackResponse(responseURL, text string) {
+	payload, _ := json.Marshal(map[string]string{
+		"response_type": "ephemeral",
+		"text":          text,
+	})
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(responseURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("❌ Slack response failed: %v", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+func slackCommandHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Slack command received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if config.SlackSigningSecret == "" {
+		http.Error(w, "Slack integration is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "Request body read failed", http.StatusBadRequest)
+		return
+	}
+
+	if err := verifySlackSignature(config.SlackSigningSecret, r.Header, body); err != nil {
+		log.Printf("❌ Slack signature verification failed: %v", err)
+		http.Error(w, "Invalid Slack signature", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "Invalid command payload", http.StatusBadRequest)
+		return
+	}
+
+	reply := func(text string) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"response_type": "ephemeral",
+			"text":          text,
+		})
+	}
+
+	args := strings.Fields(form.Get("text"))
+	if len(args) != 2 || args[0] != "pull" {
+		reply("Usage: /gitmanager pull <repo>")
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		reply("❌ SSH connection error: " + err.Error())
+		return
+	}
+
+	projects, err := sshManager.ListProjects()
+	if err != nil {
+		reply("❌ Failed to get project list: " + err.Error())
+		return
+	}
+
+	matches := matchProjects(projects, args[1])
+	if len(matches) == 0 {
+		reply("❌ No project matches " + args[1])
+		return
+	}
+	if len(matches) > 1 {
+		candidates := make([]string, len(matches))
+		for i, match := range matches {
+			candidates[i] = "• " + match.Name + " (" + match.Path + ")"
+		}
+		reply("⚠️ " + args[1] + " matches several projects, use one of:\n" + strings.Join(candidates, "\n"))
+		return
+	}
+	project := matches[0]
+
+	// Pull in the background, Slack expects an answer within 3 seconds
+	responseURL := form.Get("response_url")
+	backgroundJobs.Add(1)
+	go func() {
+		defer backgroundJobs.Done()
+		result, err := sshManager.GitPull(project.Path)
+		text := fmt.Sprintf("✅ Pulled %s\n```%s```", project.Name, result)
+		if err != nil {
+			text = fmt.Sprintf("❌ Pull of %s failed: %v\n```%s```", project.Name, err, result)
+		}
+		if responseURL != "" {
+			postSlackResponse(responseURL, text)
+		}
+	}()
+
+	log.Printf("💬 Slack pull queued: %s", project.Path)
+	reply("Pulling " + project.Name + "...")
+}
+
+func gitRepackHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Repack request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath   string `json:"repo_path"`
+		Aggressive bool   `json:"aggressive"`
+		WindowSize int    `json:"window_size"`
+		Depth      int    `json:"depth"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	log.Printf("📦 Repack request: %s", req.RepoPath)
+	result, err := sshManager.GitRepack(req.RepoPath, req.Aggressive, req.WindowSize, req.Depth)
+	if err != nil {
+		log.Printf("❌ Repack failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Repack error: %v\n%s", err, result)
+		return
+	}
+
+	log.Printf("✅ Repack successful")
+	fmt.Fprintf(w, "✅ Repack completed successfully!\n%s", result)
+}
+
+func gitCountObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Count objects request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	repoPath := r.URL.Query().Get("repo_path")
+	stats, err := sshManager.CountObjects(repoPath)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Count objects error: " + err.Error(),
+		})
+		return
+	}
+
+	response := map[string]interface{}{
+		"objects":              stats,
+		"last_repack":          nil,
+		"seconds_since_repack": nil,
+		"reflog_kib":           nil,
+		"error":                nil,
+	}
+
+	if reflogSize, err := sshManager.ReflogSize(repoPath); err == nil {
+		response["reflog_kib"] = reflogSize
+	}
+
+	if lastRepack, err := sshManager.LastRepack(repoPath); err == nil && !lastRepack.IsZero() {
+		response["last_repack"] = lastRepack
+		response["seconds_since_repack"] = int64(time.Since(lastRepack).Seconds())
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func gitReflogExpireHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Reflog expire request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath     string    `json:"repo_path"`
+		ExpireBefore time.Time `json:"expire_before"`
+		All          bool      `json:"all"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	if req.ExpireBefore.After(time.Now()) {
+		fmt.Fprintf(w, "❌ expire_before must not be in the future")
+		return
+	}
+
+	result, err := sshManager.ReflogExpire(req.RepoPath, req.ExpireBefore, req.All)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Reflog expire error: %v\n%s", err, result)
+		return
+	}
+
+	fmt.Fprintf(w, "✅ Reflog expired successfully!\n%s", result)
+}
+
+func githubGistsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Gist request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Description string            `json:"description"`
+		Files       map[string]string `json:"files"`
+		Public      bool              `json:"public"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+
+	gist, err := sshManager.CreateGist(r.Context(), req.Description, req.Files, req.Public)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, map[string]interface{}{
+			"error": "Gist error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"gist":  gist,
+		"error": nil,
+	})
+}
+
+func gitStashBranchHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Stash branch request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath   string `json:"repo_path"`
+		BranchName string `json:"branch_name"`
+		StashIndex int    `json:"stash_index"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	if err := git.ValidateRefName(req.BranchName); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "❌ Invalid branch name: %v", err)
+		return
+	}
+
+	log.Printf("🌿 Stash branch request: %s", req.RepoPath)
+	result, err := sshManager.StashBranch(req.RepoPath, req.BranchName, req.StashIndex)
+	if err != nil {
+		log.Printf("❌ Stash branch failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Stash branch error: %v\n%s", err, result)
+		return
+	}
+
+	log.Printf("✅ Stash branch successful")
+	fmt.Fprintf(w, "✅ Branch %s created from stash@{%d}!\n%s", req.BranchName, req.StashIndex, result)
+}
+
+func gitBundleFetchHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Bundle fetch request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath   string `json:"repo_path"`
+		BundlePath string `json:"bundle_path"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	log.Printf("🎁 Bundle fetch request: %s", req.RepoPath)
+	result, err := sshManager.FetchFromBundle(req.RepoPath, req.BundlePath)
+	if err != nil {
+		log.Printf("❌ Bundle fetch failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Bundle fetch error: %v\n%s", err, result)
+		return
+	}
+
+	log.Printf("✅ Bundle fetch successful")
+	fmt.Fprintf(w, "✅ Bundle applied! Branches are available under refs/remotes/bundle/\n%s", result)
+}
+
+func gitSubtreeSplitHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Subtree split request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath   string `json:"repo_path"`
+		Prefix     string `json:"prefix"`
+		Annotate   string `json:"annotate"`
+		PushRemote string `json:"push_remote"`
+		PushBranch string `json:"push_branch"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	if req.PushBranch != "" {
+		if err := git.ValidateRefName(req.PushBranch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "❌ Invalid branch name: %v", err)
+			return
+		}
+	}
+
+	log.Printf("✂️ Subtree split request: %s", req.RepoPath)
+	sha, err := sshManager.SubtreeSplit(req.RepoPath, req.Prefix, req.Annotate)
+	if err != nil {
+		log.Printf("❌ Subtree split failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Subtree split error: %v\n%s", err, sha)
+		return
+	}
+
+	if req.PushRemote == "" || req.PushBranch == "" {
+		fmt.Fprintf(w, "✅ Subtree split completed successfully!\n%s", sha)
+		return
+	}
+
+	result, err := sshManager.PushCommit(req.RepoPath, req.PushRemote, sha, req.PushBranch)
+	if err != nil {
+		fmt.Fprintf(w, "❌ Subtree split %s created but push failed: %v\n%s", sha, err, result)
+		return
+	}
+
+	log.Printf("✅ Subtree split pushed")
+	fmt.Fprintf(w, "✅ Subtree split %s pushed to %s!\n%s", sha, req.PushBranch, result)
+}
+
+func projectMetaHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Project metadata request received: %s", r.Method)
+
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"meta":  projectMetaStore.Get(r.URL.Query().Get("repo_path")),
+			"error": nil,
+		})
+	case "POST":
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": "Request body read failed: " + err.Error(),
+			})
+			return
+		}
+
+		var req struct {
+			RepoPath string `json:"repo_path"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			log.Printf("❌ JSON decode error: %v", err)
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": "JSON parse error: " + err.Error(),
+			})
+			return
+		}
+
+		if req.RepoPath == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": "repo_path is required",
+			})
+			return
+		}
+
+		// Fields missing from the request keep their stored values
+		meta := projectMetaStore.Get(req.RepoPath)
+		previousCron := meta.BackupCron
+		if err := json.Unmarshal(body, &meta); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": "JSON parse error: " + err.Error(),
+			})
+			return
+		}
+
+		meta.BackupCron = strings.TrimSpace(meta.BackupCron)
+		if meta.BackupCron != "" {
+			if _, err := cron.ParseStandard(meta.BackupCron); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+					"error": "Invalid backup cron: " + err.Error(),
+				})
+				return
+			}
+		}
+
+		if err := projectMetaStore.Set(req.RepoPath, meta); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+				"error": "Project metadata not saved: " + err.Error(),
+			})
+			return
+		}
+
+		if meta.BackupCron != previousCron {
+			if err := backupScheduler.Schedule(req.RepoPath, meta.BackupCron); err != nil {
+				log.Printf("❌ Backup schedule failed: %v", err)
+			}
+		}
+
+		log.Printf("✅ Project metadata saved: %s", req.RepoPath)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"meta":  meta,
+			"error": nil,
+		})
+	default:
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func gitLogHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Log request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	before, err := parseDateParam(query.Get("before"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Invalid before date: " + err.Error(),
+		})
+		return
+	}
+	after, err := parseDateParam(query.Get("after"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Invalid after date: " + err.Error(),
+		})
+		return
+	}
+
+	opts := GitLogOptions{
+		Filter:      query.Get("filter"),
+		Grep:        query.Get("grep"),
+		AllBranches: query.Get("all") == "true",
+
+		AuthorFilter:    query.Get("author"),
+		CommitterFilter: query.Get("committer"),
+
+		IncludeStats:    query.Get("include_stats") == "true",
+		FirstParentOnly: query.Get("first_parent") == "true",
+
+		Path:      query.Get("path"),
+		SortOrder: query.Get("order"),
+
+		IncludePatch: query.Get("include_patch") == "true",
+		Revision:     query.Get("rev"),
+
+		From:         query.Get("from"),
+		To:           query.Get("to"),
+		AncestryPath: query.Get("ancestry_path") == "true",
+
+		Before: before,
+		After:  after,
+	}
+
+	if query.Get("compact") == "true" {
+		commits, err := sshManager.GitLogCompact(query.Get("repo_path"), limit, opts)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": "Log error: " + err.Error(),
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"commits": commits,
+			"error":   nil,
+		})
+		return
+	}
+
+	commits, err := sshManager.GitLog(query.Get("repo_path"), limit, opts)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Log error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"commits": commits,
+		"error":   nil,
+	})
+}
+
+// parseDateParam accepts YYYY-MM-DD or RFC 3339 timestamps; an empty value yields the zero time.
+func parseDateParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse("2006-01-02", value); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC 3339, got %q", value)
+	}
+	return parsed, nil
+}
+
+func gitPickaxeHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Pickaxe request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	commits, err := sshManager.PickaxeSearch(query.Get("repo_path"), query.Get("q"), query.Get("regex") == "true", limit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Pickaxe error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"commits": commits,
+		"error":   nil,
+	})
+}
+
+func gitSearchCommitsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Commit search request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	var since, until time.Time
+	if value := query.Get("since"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": "Invalid since date (expected YYYY-MM-DD): " + value,
+			})
+			return
+		}
+		since = parsed
+	}
+	if value := query.Get("until"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": "Invalid until date (expected YYYY-MM-DD): " + value,
+			})
+			return
+		}
+		// Include the whole final day
+		until = parsed.Add(24*time.Hour - time.Second)
+	}
+	concurrency, _ := strconv.Atoi(query.Get("concurrency"))
+
+	hits, err := sshManager.SearchCommits(r.Context(), query.Get("q"), concurrency, since, until)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Commit search error: " + err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	count := 0
+	for hit := range hits {
+		data, err := json.Marshal(hit)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(w, "event: hit\ndata: %s\n\n", data)
+		flusher.Flush()
+		count++
+	}
+
+	fmt.Fprintf(w, "event: done\ndata: {\"count\":%d}\n\n", count)
+	flusher.Flush()
+	log.Printf("✅ Commit search streamed %d hits", count)
+}
+
+// systemProcessesHandler lists the server's processes. Like every route it needs the API key or the web UI login
+// (see apiKeyMiddleware).
+func systemProcessesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Processes request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	processes, err := sshManager.ListProcesses()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"error": "Process listing error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"processes": processes,
+		"error":     nil,
+	})
+}
+
+// systemProcessSignalHandler signals a process on the server, behind the same credentials as systemProcessesHandler.
+func systemProcessSignalHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Process signal request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pid, err := strconv.Atoi(r.PathValue("pid"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Invalid pid",
+		})
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	var req struct {
+		Signal string `json:"signal"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+
+	if req.Signal == "" {
+		req.Signal = "TERM"
+	}
+
+	if err := sshManager.KillProcess(pid, req.Signal); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Signal error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"pid":    pid,
+		"signal": req.Signal,
+		"error":  nil,
+	})
+}
+
+func gitNotesAllHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Notes request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	notes, err := sshManager.ShowAllNotes(query.Get("repo_path"), query.Get("hash"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Notes error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"notes": notes,
+		"error": nil,
+	})
+}
+
+func gitNotesOrphanedHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Orphaned notes request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	orphaned, err := sshManager.OrphanedNotes(query.Get("repo_path"), query.Get("namespace"))
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Orphaned notes error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"orphaned": orphaned,
+		"error":    nil,
+	})
+}
+
+func gitNotesPruneHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Notes prune request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath  string `json:"repo_path"`
+		Namespace string `json:"namespace"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	log.Printf("🧹 Notes prune request: %s", req.RepoPath)
+	result, err := sshManager.PruneGitNotes(req.RepoPath, req.Namespace)
+	if err != nil {
+		log.Printf("❌ Notes prune failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Notes prune error: %v\n%s", err, result)
+		return
+	}
+
+	log.Printf("✅ Notes prune successful")
+	if strings.TrimSpace(result) == "" {
+		result = "No orphaned notes found"
+	}
+	fmt.Fprintf(w, "✅ Notes prune completed successfully!\n%s", result)
+}
+
+func gitMergeBaseHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Merge base request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	repoPath, a, b := query.Get("repo_path"), query.Get("a"), query.Get("b")
+	if a == "" || b == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Both a and b are required",
+		})
+		return
+	}
+
+	base, err := sshManager.MergeBase(repoPath, a, b)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Merge base error: " + err.Error(),
+		})
+		return
+	}
+
+	aIsAncestor, err := sshManager.IsAncestor(repoPath, a, b)
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Ancestry check error: " + err.Error(),
+		})
+		return
+	}
+	bIsAncestor, err := sshManager.IsAncestor(repoPath, b, a)
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Ancestry check error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"merge_base":    base,
+		"a_is_ancestor": aIsAncestor,
+		"b_is_ancestor": bIsAncestor,
+		"error":         nil,
+	})
+}
+
+func gitTrailersHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Trailer request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+		Key      string `json:"key"`
+		Value    string `json:"value"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	log.Printf("🏷️ Trailer request: %s", req.RepoPath)
+	result, err := sshManager.AppendTrailer(req.RepoPath, req.Key, req.Value)
+	if err != nil {
+		log.Printf("❌ Trailer failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Trailer error: %v\n%s", err, result)
+		return
+	}
+
+	log.Printf("✅ Trailer added")
+	fmt.Fprintf(w, "✅ Trailer added to the latest commit!\n%s", result)
+}
+
+func gitCheckIgnoreHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Check ignore request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	results, err := sshManager.CheckIgnore(query.Get("repo_path"), query["file"])
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Check ignore error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"results": results,
+		"error":   nil,
+	})
+}
+
+func gitStatusChangesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Status changes request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	hasChanges, err := sshManager.HasChanges(r.URL.Query().Get("repo_path"))
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Status error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"has_changes": hasChanges,
+		"error":       nil,
+	})
+}
+
+func gitBackupS3Handler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 S3 backup request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+		Ref      string `json:"ref"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	log.Printf("☁️ S3 backup request: %s", req.RepoPath)
+	location, err := sshManager.BackupToS3(req.RepoPath, req.Ref)
+	if err != nil {
+		log.Printf("❌ S3 backup failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ S3 backup error: %v", err)
+		return
+	}
+
+	log.Printf("✅ S3 backup successful")
+	fmt.Fprintf(w, "✅ Backup uploaded successfully!\n%s", location)
+}
+
+func gitNotesMergeHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Notes merge request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+		NotesRef string `json:"notes_ref"`
+		Strategy string `json:"strategy"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	log.Printf("🗒️ Notes merge request: %s", req.RepoPath)
+	result, err := sshManager.MergeNotes(req.RepoPath, req.NotesRef, req.Strategy)
+	if err != nil {
+		log.Printf("❌ Notes merge failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Notes merge error: %v\n%s", err, result)
+		return
+	}
+
+	log.Printf("✅ Notes merge successful")
+	fmt.Fprintf(w, "✅ Notes merged successfully!\n%s", result)
+}
+
+func gitCredentialsTestHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Credential test request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	var req struct {
+		Host     string `json:"host"`
+		Protocol string `json:"protocol"`
+		Username string `json:"username"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := sshManager.TestCredential(req.Host, req.Protocol, req.Username)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Credential test error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"credential": result,
+		"error":      nil,
+	})
+}
+
+func commitTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Commit template request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if config.CommitMessageTemplate == "" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"message": "",
+			"error":   nil,
+		})
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	message, err := sshManager.renderCommitTemplate(r.URL.Query().Get("repo_path"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message": message,
+		"error":   nil,
+	})
+}
+
+// gitPackObjectsHandler writes a pack of the given objects. Like every route it needs the API key or the web UI login
+// (see apiKeyMiddleware), and the pack must be written inside the working directory.
+func gitPackObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Pack objects request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	var req struct {
+		RepoPath   string   `json:"repo_path"`
+		Hashes     []string `json:"hashes"`
+		OutputPath string   `json:"output_path"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+
+	outputPath, err := sshManager.resolvePackOutputPath(req.RepoPath, req.OutputPath)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errOutsideWorkingDir) {
+			status = http.StatusForbidden
+		}
+		writeJSON(w, status, map[string]interface{}{
+			"error": "Pack objects error: " + err.Error(),
+		})
+		return
+	}
+
+	packHash, err := sshManager.PackObjects(req.RepoPath, req.Hashes, outputPath)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Pack objects error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"pack_hash": packHash,
+		"pack_file": outputPath + "-" + packHash + ".pack",
+		"objects":   len(req.Hashes),
+		"error":     nil,
+	})
+}
+
+// browseEntry is a tree entry prepared for the browse page.
+type browseEntry struct {
+	Icon        string
+	Name        string
+	Size        string
+	Link        string
+	HistoryLink string
+}
+
+type browseCrumb struct {
+	Name string
+	Link string
+}
+
+type browsePage struct {
+	Project     string
+	Ref         string
+	Crumbs      []browseCrumb
+	Entries     []browseEntry
+	IsBlob      bool
+	Content     string
+	Highlighted template.HTML
+	Binary      bool
+	Error       string
+}
+
+const browseTemplate = `<!DOCTYPE html>
 <html>
 <head>
-    <title>SSH GitHub Manager</title>
+    <title>{{.Project}} - Browse</title>
     <meta charset="UTF-8">
     <style>
         body { font-family: Arial, sans-serif; margin: 20px; background: #f5f5f5; }
         .container { max-width: 1200px; margin: 0 auto; background: white; padding: 20px; border-radius: 10px; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
-        .header { text-align: center; margin-bottom: 30px; color: #333; }
-        .config-info { background: #e8f4f8; padding: 15px; border-radius: 5px; margin-bottom: 20px; }
-        .section { margin: 20px 0; padding: 20px; border: 1px solid #ddd; border-radius: 5px; }
-        .git-actions { display: flex; gap: 10px; flex-wrap: wrap; }
-        .btn { padding: 10px 20px; background: #007bff; color: white; border: none; border-radius: 5px; cursor: pointer; }
-        .btn:hover { background: #0056b3; }
-        .btn-success { background: #28a745; }
-        .btn-success:hover { background: #1e7e34; }
-        .btn-warning { background: #ffc107; color: #212529; }
-        .btn-warning:hover { background: #e0a800; }
-        .btn-danger { background: #dc3545; }
-        .btn-danger:hover { background: #c82333; }
-        .btn-secondary { background: #6c757d; }
-        .btn-secondary:hover { background: #5a6268; }
-        .form-group { margin: 10px 0; }
-        .form-group label { display: block; margin-bottom: 5px; font-weight: bold; }
-        .form-group input, .form-group select { width: 100%; padding: 8px; border: 1px solid #ddd; border-radius: 4px; box-sizing: border-box; }
-        .projects-list { border: 1px solid #ddd; border-radius: 5px; max-height: 500px; overflow-y: auto; }
-        .project-item { padding: 15px; border-bottom: 1px solid #eee; display: flex; align-items: center; justify-content: space-between; }
-        .project-item:hover { background: #f8f9fa; }
-        .project-item:last-child { border-bottom: none; }
-        .project-info { flex: 1; }
-        .project-name { font-weight: bold; color: #333; margin-bottom: 5px; }
-        .project-path { font-size: 0.9em; color: #666; }
-        .project-actions { display: flex; gap: 8px; flex-wrap: wrap; }
-        .btn-sm { padding: 8px 12px; font-size: 0.85em; }
-        .loading-text { text-align: center; padding: 20px; color: #666; }
-        .modal { display: none; position: fixed; top: 0; left: 0; width: 100%; height: 100%; background: rgba(0,0,0,0.5); z-index: 1000; }
-        .modal-content { position: absolute; top: 50%; left: 50%; transform: translate(-50%, -50%); background: white; padding: 30px; border-radius: 10px; min-width: 400px; }
-        .modal-header { margin-bottom: 20px; }
-        .modal-footer { margin-top: 20px; text-align: right; }
-        .output { background: #f8f9fa; padding: 15px; border-radius: 5px; font-family: monospace; white-space: pre-wrap; max-height: 300px; overflow-y: auto; }
-        .status { padding: 10px; border-radius: 5px; margin: 10px 0; }
-        .status.success { background: #d4edda; color: #155724; border: 1px solid #c3e6cb; }
-        .status.error { background: #f8d7da; color: #721c24; border: 1px solid #f5c6cb; }
+        .crumbs { font-size: 1.1em; margin-bottom: 15px; }
+        .crumbs a { color: #007bff; text-decoration: none; }
+        .badge { display: inline-block; padding: 3px 8px; border-radius: 10px; font-size: 0.8em; background: #e9ecef; color: #495057; }
+        table { width: 100%; border-collapse: collapse; font-size: 0.95em; }
+        td { padding: 6px 8px; border-bottom: 1px solid #eee; }
+        td a { color: #333; text-decoration: none; }
+        td a:hover { text-decoration: underline; }
+        td.size, td.history { text-align: right; white-space: nowrap; color: #6c757d; }
+        pre { background: #f8f9fa; border: 1px solid #ddd; border-radius: 5px; padding: 15px; overflow-x: auto; font-size: 13px; }
+        .status.error { background: #f8d7da; color: #721c24; padding: 10px; border-radius: 5px; }
     </style>
 </head>
 <body>
     <div class="container">
-        <div class="header">
-            <h1>🚀 SSH GitHub Manager</h1>
-            <div class="config-info">
-                <strong>📡 Server:</strong> {{.Host}} | 
-                <strong>👤 User:</strong> {{.User}} | 
-                <strong>🔐 Auth:</strong> {{.AuthMethod}} | 
-                <strong>📁 Dir:</strong> {{.WorkingDir}} |
-                <strong>🐙 Token:</strong> {{if .GitHubToken}}✅ Available{{else}}❌ Missing{{end}}
-                <br>
-                <button class="btn btn-secondary" onclick="window.location.href='/setup'">⚙️ Settings</button>
-                {{if not .GitHubToken}}
-                <span style="color: #dc3545; font-weight: bold;">⚠️ GitHub Token required!</span>
-                {{end}}
-            </div>
+        <div class="crumbs">
+            <a href="/">🏠</a> /
+            {{range $i, $crumb := .Crumbs}}{{if $i}} / {{end}}<a href="{{$crumb.Link}}">{{$crumb.Name}}</a>{{end}}
+            <span class="badge">{{.Ref}}</span>
         </div>
 
-        <div class="section">
-            <h3>📁 Projects</h3>
-            <div class="projects-list" id="projectsList">
-                <div class="loading-text">Loading...</div>
-            </div>
-            <button class="btn" onclick="refreshProjects()">🔄 Refresh</button>
-        </div>
+        {{if .Error}}
+        <div class="status error">❌ {{.Error}}</div>
+        {{else if .IsBlob}}
+            {{if .Binary}}
+            <div class="status error">Binary file, not shown</div>
+            {{else if .Highlighted}}
+            {{.Highlighted}}
+            {{else}}
+            <pre>{{.Content}}</pre>
+            {{end}}
+        {{else}}
+        <table>
+            {{range .Entries}}
+            <tr>
+                <td>{{.Icon}} <a href="{{.Link}}">{{.Name}}</a></td>
+                <td class="size">{{.Size}}</td>
+                <td class="history">{{if .HistoryLink}}<a href="{{.HistoryLink}}">📜 History</a>{{end}}</td>
+            </tr>
+            {{else}}
+            <tr><td>Empty directory</td></tr>
+            {{end}}
+        </table>
+        {{end}}
+    </div>
+</body>
+</html>`
+
+var browsePageTemplate = template.Must(template.New("browse").Parse(browseTemplate))
+
+func findProjectByName(name string) (Project, error) {
+	projects, err := sshManager.ListProjects()
+	if err != nil {
+		return Project{}, err
+	}
+	for _, project := range projects {
+		if project.Name == name {
+			return project, nil
+		}
+	}
+	return Project{}, fmt.Errorf("project %q not found", name)
+}
+
+func browseLink(project, kind, ref, path string) string {
+	link := "/browse/" + url.PathEscape(project) + "/" + kind + "/" + url.PathEscape(ref) + "/"
+	for i, part := range strings.Split(path, "/") {
+		if i > 0 {
+			link += "/"
+		}
+		link += url.PathEscape(part)
+	}
+	return link
+}
+
+// browseCrumbs links the project root and every directory of path.
+func browseCrumbs(project, ref, path string) []browseCrumb {
+	crumbs := []browseCrumb{{Name: project, Link: browseLink(project, "tree", ref, "")}}
+	if path == "" {
+		return crumbs
+	}
+
+	parts := strings.Split(path, "/")
+	for i := range parts {
+		crumbs = append(crumbs, browseCrumb{Name: parts[i], Link: browseLink(project, "tree", ref, strings.Join(parts[:i+1], "/"))})
+	}
+	return crumbs
+}
+
+func renderBrowsePage(w http.ResponseWriter, status int, page browsePage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := browsePageTemplate.Execute(w, page); err != nil {
+		log.Printf("❌ Browse template error: %v", err)
+	}
+}
+
+func browseTreeHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Browse tree request received: %s", r.URL.Path)
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name, ref, path := r.PathValue("project"), r.PathValue("ref"), strings.Trim(r.PathValue("path"), "/")
+	if ref == "" {
+		ref = "HEAD"
+	}
+	page := browsePage{Project: name, Ref: ref, Crumbs: browseCrumbs(name, ref, path)}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		page.Error = "SSH connection not established: " + err.Error()
+		renderBrowsePage(w, http.StatusServiceUnavailable, page)
+		return
+	}
+
+	project, err := findProjectByName(name)
+	if err != nil {
+		page.Error = err.Error()
+		renderBrowsePage(w, http.StatusNotFound, page)
+		return
+	}
+
+	entries, err := sshManager.GitListTree(project.Path, ref, path)
+	if err != nil {
+		page.Error = "Tree error: " + err.Error()
+		renderBrowsePage(w, http.StatusNotFound, page)
+		return
+	}
+
+	for _, entry := range entries {
+		item := browseEntry{Name: entry.Name}
+		switch entry.Type {
+		case "tree":
+			item.Icon = "📁"
+			item.Link = browseLink(name, "tree", ref, entry.Path)
+		case "commit":
+			// Submodules point into another repository
+			item.Icon = "🔗"
+			item.Link = "#"
+			item.Size = entry.Hash[:7]
+		default:
+			item.Icon = "📄"
+			item.Link = browseLink(name, "blob", ref, entry.Path)
+			item.Size = formatByteSize(entry.Size)
+		}
+		if entry.Type != "commit" {
+			item.HistoryLink = "/?" + url.Values{"history": {project.Path}, "name": {name}, "file": {entry.Path}}.Encode()
+		}
+		page.Entries = append(page.Entries, item)
+	}
+
+	renderBrowsePage(w, http.StatusOK, page)
+}
+
+func browseBlobHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Browse blob request received: %s", r.URL.Path)
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name, ref, path := r.PathValue("project"), r.PathValue("ref"), strings.Trim(r.PathValue("path"), "/")
+	page := browsePage{Project: name, Ref: ref, Crumbs: browseCrumbs(name, ref, path), IsBlob: true}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		page.Error = "SSH connection not established: " + err.Error()
+		renderBrowsePage(w, http.StatusServiceUnavailable, page)
+		return
+	}
+
+	project, err := findProjectByName(name)
+	if err != nil {
+		page.Error = err.Error()
+		renderBrowsePage(w, http.StatusNotFound, page)
+		return
+	}
+
+	content, err := sshManager.CatFile(project.Path, ref, path)
+	if err != nil {
+		page.Error = "File error: " + err.Error()
+		renderBrowsePage(w, http.StatusNotFound, page)
+		return
+	}
+
+	page.Binary = bytes.IndexByte(content, 0) >= 0
+	if !page.Binary {
+		page.Content = string(content)
+		if highlighted, err := highlightCode(path, page.Content); err == nil {
+			page.Highlighted = highlighted
+		} else {
+			log.Printf("⚠️ Highlighting failed for %s: %v", path, err)
+		}
+	}
+	renderBrowsePage(w, http.StatusOK, page)
+}
+
+// highlightCode renders content as an HTML fragment with inline styles, picking the lexer from the file name.
+func highlightCode(path, content string) (template.HTML, error) {
+	lexer := lexers.Match(filepath.Base(path))
+	if lexer == nil {
+		lexer = lexers.Analyse(content)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, content)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	formatter := chromahtml.New(chromahtml.WithLineNumbers(true))
+	if err := formatter.Format(&buf, styles.Get("github"), iterator); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+func filesContentHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 File content request received: %s", r.Method)
+
+	switch r.Method {
+	case "GET":
+	case "POST":
+		filesWriteHandler(w, r)
+		return
+	default:
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "path parameter is required",
+		})
+		return
+	}
+
+	content, err := sshManager.ReadFile(path)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, errOutsideWorkingDir):
+			status = http.StatusForbidden
+		case errors.Is(err, os.ErrNotExist):
+			status = http.StatusNotFound
+		case errors.Is(err, errFileTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeJSON(w, status, map[string]interface{}{
+			"error": "File read error: " + err.Error(),
+		})
+		return
+	}
+	isText := bytes.IndexByte(content, 0) < 0 && utf8.Valid(content)
+
+	if r.URL.Query().Get("format") == "json" {
+		if !isText || len(content) > maxJSONContentBytes {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+				"error": "Only UTF-8 text files below 1 MiB can be returned as JSON",
+			})
+			return
+		}
+		lines := strings.Count(string(content), "\n")
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			lines++
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"content":  string(content),
+			"encoding": "utf-8",
+			"lines":    lines,
+			"sha256":   sha256Hex(content),
+			"error":    nil,
+		})
+		return
+	}
+
+	// Browsers asking for HTML get a highlighted fragment; everyone else gets the raw content
+	if strings.Contains(r.Header.Get("Accept"), "text/html") && isText {
+		highlighted, err := highlightCode(path, string(content))
+		if err != nil {
+			log.Printf("❌ Highlighting failed: %v", err)
+			http.Error(w, "Highlighting error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, highlighted)
+		return
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = http.DetectContentType(content)
+	}
+	w.Header().Set("Content-Type", contentType)
+	// The file comes from the server, not from this app: never let it run scripts on our origin
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(content)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// filesWriteHandler saves edited file content. When expected_sha256 is given and the file changed since the client
+// read it, nothing is written and 409 is returned with the current hash.
+func filesWriteHandler(w http.ResponseWriter, r *http.Request) {
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	var req struct {
+		Path           string `json:"path"`
+		Content        string `json:"content"`
+		ExpectedSHA256 string `json:"expected_sha256"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+	if req.Path == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "path is required",
+		})
+		return
+	}
+
+	previousSHA256 := ""
+	previous, err := sshManager.ReadFile(req.Path)
+	switch {
+	case err == nil:
+		previousSHA256 = sha256Hex(previous)
+	case errors.Is(err, os.ErrNotExist):
+	default:
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, errOutsideWorkingDir):
+			status = http.StatusForbidden
+		case errors.Is(err, errFileTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeJSON(w, status, map[string]interface{}{
+			"error": "File read error: " + err.Error(),
+		})
+		return
+	}
+
+	if req.ExpectedSHA256 != "" && req.ExpectedSHA256 != previousSHA256 {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":           "The file was changed on the server since it was loaded",
+			"previous_sha256": previousSHA256,
+		})
+		return
+	}
+
+	if err := sshManager.WriteFile(req.Path, []byte(req.Content), 0); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errOutsideWorkingDir) {
+			status = http.StatusForbidden
+		}
+		writeJSON(w, status, map[string]interface{}{
+			"error": "File write error: " + err.Error(),
+		})
+		return
+	}
+
+	backupPath := ""
+	if previous != nil {
+		backupPath = req.Path + ".bak"
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"path":            req.Path,
+		"previous_sha256": previousSHA256,
+		"sha256":          sha256Hex([]byte(req.Content)),
+		"backup_path":     backupPath,
+		"error":           nil,
+	})
+}
+
+func formatByteSize(size int64) string {
+	switch {
+	case size < 0:
+		return ""
+	case size < 1024:
+		return fmt.Sprintf("%d B", size)
+	case size < 1024*1024:
+		return fmt.Sprintf("%.1f KiB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%.1f MiB", float64(size)/(1024*1024))
+	}
+}
+
+func gitLsFilesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 List files request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	files, err := sshManager.ListTrackedFiles(query.Get("repo_path"),
+		query.Get("modified") == "true", query.Get("deleted") == "true", query.Get("others") == "true")
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "List files error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"files": files,
+		"error": nil,
+	})
+}
+
+func gitRemotesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Remotes request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	remotes, err := sshManager.ListRemotes(r.URL.Query().Get("repo_path"))
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Remotes error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"remotes": remotes,
+		"error":   nil,
+	})
+}
+
+func gitRemoteRenameHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Remote rename request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+		OldName  string `json:"old_name"`
+		NewName  string `json:"new_name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	log.Printf("✏️ Remote rename request: %s", req.RepoPath)
+	result, err := sshManager.RenameRemote(req.RepoPath, req.OldName, req.NewName)
+	if err != nil {
+		log.Printf("❌ Remote rename failed")
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Remote rename error: %v\n%s", err, result)
+		return
+	}
+
+	log.Printf("✅ Remote rename successful")
+	fmt.Fprintf(w, "✅ Remote %s renamed to %s!\n%s", req.OldName, req.NewName, result)
+}
+
+func gitTagVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🛡️ Tag verify request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	tag := query.Get("tag")
+	if tag == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "tag parameter is required",
+		})
+		return
+	}
+
+	result, err := sshManager.VerifyTag(query.Get("repo_path"), tag)
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Tag verify error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"verification": result,
+		"error":        nil,
+	})
+}
+
+func gitRangeDiffHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Range diff request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	result, err := sshManager.RangeDiff(query.Get("repo_path"), query.Get("r1"), query.Get("r2"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Range diff error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"range_diff": result,
+		"error":      nil,
+	})
+}
+
+func gitLogSinceHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Log since request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	commits, err := sshManager.LogSinceCommit(query.Get("repo_path"), query.Get("since"), limit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Log since error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"commits": commits,
+		"error":   nil,
+	})
+}
+
+func gitWorktreesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Worktrees request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	worktrees, err := sshManager.ListWorktrees(r.URL.Query().Get("repo_path"))
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Worktrees error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"worktrees": worktrees,
+		"error":     nil,
+	})
+}
+
+func gitWorktreeLockHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Worktree lock request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath     string `json:"repo_path"`
+		WorktreePath string `json:"worktree_path"`
+		Reason       string `json:"reason"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	if err := sshManager.LockWorktree(req.RepoPath, req.WorktreePath, req.Reason); err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Worktree lock error: %v", err)
+		return
+	}
+
+	fmt.Fprintf(w, "✅ Worktree %s locked!", req.WorktreePath)
+}
+
+func gitWorktreeUnlockHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Worktree unlock request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath     string `json:"repo_path"`
+		WorktreePath string `json:"worktree_path"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	if err := sshManager.UnlockWorktree(req.RepoPath, req.WorktreePath); err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Worktree unlock error: %v", err)
+		return
+	}
+
+	fmt.Fprintf(w, "✅ Worktree %s unlocked!", req.WorktreePath)
+}
+
+func gitDiffHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Diff request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath         string `json:"repo_path"`
+		Staged           bool   `json:"staged"`
+		IgnoreWhitespace bool   `json:"ignore_ws"`
+		DiffMode         string `json:"diff_mode"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+	if r.URL.Query().Get("ignore_ws") == "true" {
+		req.IgnoreWhitespace = true
+	}
+	if mode := r.URL.Query().Get("diff_mode"); mode != "" {
+		req.DiffMode = mode
+	}
+
+	result, err := sshManager.GitDiff(req.RepoPath, req.Staged, req.IgnoreWhitespace, req.DiffMode)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Diff error: %v\n%s", err, result)
+		return
+	}
+
+	fmt.Fprint(w, result)
+}
+
+func gitDiffStagedHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Staged diff request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath         string `json:"repo_path"`
+		IgnoreWhitespace bool   `json:"ignore_ws"`
+		DiffMode         string `json:"diff_mode"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+	if r.URL.Query().Get("ignore_ws") == "true" {
+		req.IgnoreWhitespace = true
+	}
+	if mode := r.URL.Query().Get("diff_mode"); mode != "" {
+		req.DiffMode = mode
+	}
+
+	result, err := sshManager.GitDiffStaged(req.RepoPath, req.IgnoreWhitespace, req.DiffMode)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Staged diff error: %v\n%s", err, result)
+		return
+	}
+
+	fmt.Fprint(w, result)
+}
+
+func gitCherryLogHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Cherry log request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	commits, err := sshManager.CherryLog(query.Get("repo_path"), query.Get("left"), query.Get("right"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Cherry log error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"commits": commits,
+		"error":   nil,
+	})
+}
+
+func gitCherryPickHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Cherry-pick request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string   `json:"repo_path"`
+		Commits  []string `json:"commits"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	result, err := sshManager.CherryPick(req.RepoPath, req.Commits)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Cherry-pick error (aborted): %v\n%s", err, result)
+		return
+	}
+
+	fmt.Fprintf(w, "✅ %d commits cherry-picked!\n%s", len(req.Commits), result)
+}
+
+func gitReflogWalkHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Reflog request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	entries, err := sshManager.WalkReflog(query.Get("repo_path"), query.Get("ref"), limit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Reflog error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"entries": entries,
+		"error":   nil,
+	})
+}
+
+func gitBranchesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Branches request received: %s", r.Method)
+
+	if r.Method != "GET" && r.Method != "DELETE" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Method == "DELETE" {
+		gitDeleteBranchHandler(w, r)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	branches, err := sshManager.ListBranches(r.URL.Query().Get("repo_path"))
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Branches error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"branches": branches,
+		"error":    nil,
+	})
+}
+
+func gitDeleteBranchHandler(w http.ResponseWriter, r *http.Request) {
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+		Name     string `json:"name"`
+		Force    bool   `json:"force"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	result, err := sshManager.DeleteBranch(req.RepoPath, req.Name, req.Force)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Delete branch error: %v\n%s", err, result)
+		return
+	}
+
+	fmt.Fprintf(w, "✅ Branch %s deleted!\n%s", req.Name, result)
+}
+
+func gitCreateBranchHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Create branch request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+		Name     string `json:"name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	result, err := sshManager.CreateBranch(req.RepoPath, req.Name)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Create branch error: %v\n%s", err, result)
+		return
+	}
+
+	fmt.Fprintf(w, "✅ Branch %s created!\n%s", req.Name, result)
+}
+
+func gitFetchHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Fetch request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+		Remote   string `json:"remote"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	result, err := sshManager.GitFetch(req.RepoPath, req.Remote)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Fetch error: %v\n%s", err, result)
+		return
+	}
+
+	fmt.Fprintf(w, "✅ Fetch completed successfully!\n%s", result)
+}
+
+func gitDiffStatHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Diff stat request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	stats, err := sshManager.DiffStat(query.Get("repo_path"), query.Get("ref1"), query.Get("ref2"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Diff stat error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"stats": stats,
+		"error": nil,
+	})
+}
+
+func gitCheckoutHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Checkout request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+		Branch   string `json:"branch"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "JSON parse error: " + err.Error(),
+		})
+		return
+	}
+
+	previous, err := sshManager.CurrentBranch(req.RepoPath)
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Current branch error: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := sshManager.GitCheckout(req.RepoPath, req.Branch)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"previous_branch": previous,
+			"output":          result,
+			"error":           "Checkout error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"previous_branch": previous,
+		"branch":          req.Branch,
+		"output":          result,
+		"error":           nil,
+	})
+}
+
+func gitActivityHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Activity request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	since, err := parseDateParam(query.Get("since"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Invalid since date: " + err.Error(),
+		})
+		return
+	}
+
+	report, err := sshManager.ActivityReport(query.Get("repo_path"), query.Get("period"), since)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Activity error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"activity": report,
+		"error":    nil,
+	})
+}
+
+func gitStashHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Stash request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+		Message  string `json:"message"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	result, err := sshManager.GitStash(req.RepoPath, req.Message)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Stash error: %v\n%s", err, result)
+		return
+	}
+
+	fmt.Fprintf(w, "✅ Changes stashed!\n%s", result)
+}
+
+func gitStashPopHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Stash pop request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	result, err := sshManager.GitStashPop(req.RepoPath)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Stash pop error: %v\n%s", err, result)
+		return
+	}
+
+	fmt.Fprintf(w, "✅ Stash applied and dropped!\n%s", result)
+}
+
+func gitStashListHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Stash list request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	stashes, err := sshManager.GitStashList(r.URL.Query().Get("repo_path"))
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Stash list error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"stashes": stashes,
+		"error":   nil,
+	})
+}
+
+func gitShowCommitsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Show commits request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-        <div class="section">
-            <h3>📥 Clone Repository</h3>
-            <div class="form-group">
-                <label>Repository URL:</label>
-                <input type="text" id="repoUrl" placeholder="https://github.com/username/repository.git">
-            </div>
-            <div class="form-group">
-                <label>Branch (optional):</label>
-                <input type="text" id="branch" placeholder="main, master, develop...">
-            </div>
-            <button class="btn btn-success" onclick="gitClone()">📥 Clone Repository</button>
-        </div>
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
 
-        <div class="section">
-            <h3>📝 Output</h3>
-            <div class="output" id="output">Operation results will be shown here...</div>
-        </div>
-    </div>
+	query := r.URL.Query()
+	commits, err := sshManager.ShowCommits(query.Get("repo_path"), query["hash"])
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Show commits error: " + err.Error(),
+		})
+		return
+	}
 
-    <!-- Commit Modal -->
-    <div id="commitModal" class="modal">
-        <div class="modal-content">
-            <div class="modal-header">
-                <h3>💾 Commit Message</h3>
-            </div>
-            <div class="form-group">
-                <label>Commit Message:</label>
-                <input type="text" id="modalCommitMessage" placeholder="Update files" value="Update files">
-            </div>
-            <div class="modal-footer">
-                <button class="btn btn-secondary" onclick="closeCommitModal()">❌ Cancel</button>
-                <button class="btn btn-success" onclick="confirmPush()">✅ Commit & Push</button>
-            </div>
-        </div>
-    </div>
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"commits": commits,
+		"error":   nil,
+	})
+}
 
-    <script>
-        var currentPushPath = '';
+func gitSparseCheckoutReapplyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Sparse checkout reapply request received")
 
-        function showOutput(text, isError) {
-            var output = document.getElementById('output');
-            if (output) {
-                output.textContent = text;
-                output.className = 'output ' + (isError ? 'error' : 'success');
-            } else {
-                alert(text);
-            }
-        }
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-        function refreshProjects() {
-            var projectsList = document.getElementById('projectsList');
-            if (!projectsList) return;
-            
-            projectsList.innerHTML = '<div class="loading-text">Loading...</div>';
-            
-            fetch('/projects')
-                .then(function(response) { return response.json(); })
-                .then(function(data) {
-                    if (data.error) {
-                        projectsList.innerHTML = '<div class="loading-text">❌ ' + data.error + '</div>';
-                        return;
-                    }
-                    displayProjects(data.projects || []);
-                })
-                .catch(function(error) {
-                    projectsList.innerHTML = '<div class="loading-text">❌ Error: ' + error.message + '</div>';
-                });
-        }
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	result, err := sshManager.SparseCheckoutReapply(req.RepoPath)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "⚠️ Sparse checkout reapply failed, the working tree may not match the patterns (unstaged changes can block it): %v\n%s", err, result)
+		return
+	}
+
+	fmt.Fprintf(w, "✅ Sparse checkout patterns reapplied!\n%s", result)
+}
+
+func gitSubmoduleUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Submodule update request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	result, err := sshManager.GitSubmoduleUpdate(req.RepoPath)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Submodule update error: %v\n%s", err, result)
+		return
+	}
+
+	fmt.Fprintf(w, "✅ Submodules updated!\n%s", result)
+}
+
+func gitSubmoduleStatusHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Submodule status request received")
+
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	submodules, err := sshManager.SubmoduleStatus(r.URL.Query().Get("repo_path"))
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Submodule status error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"submodules": submodules,
+		"error":      nil,
+	})
+}
+
+func gitTagsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Tags request received: %s", r.Method)
+
+	switch r.Method {
+	case "GET":
+	case "POST":
+		gitCreateTagHandler(w, r)
+		return
+	case "DELETE":
+		gitDeleteTagHandler(w, r)
+		return
+	default:
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	tags, err := sshManager.ListTags(r.URL.Query().Get("repo_path"))
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Tags error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"tags":  tags,
+		"error": nil,
+	})
+}
+
+func gitCreateTagHandler(w http.ResponseWriter, r *http.Request) {
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath  string `json:"repo_path"`
+		Name      string `json:"name"`
+		Message   string `json:"message"`
+		Annotated bool   `json:"annotated"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	result, err := sshManager.CreateTag(req.RepoPath, req.Name, req.Message, req.Annotated)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Create tag error: %v\n%s", err, result)
+		return
+	}
+
+	fmt.Fprintf(w, "✅ Tag %s created!\n%s", req.Name, result)
+}
+
+func gitDeleteTagHandler(w http.ResponseWriter, r *http.Request) {
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+		Name     string `json:"name"`
+		Remote   bool   `json:"remote"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	result, err := sshManager.DeleteTag(req.RepoPath, req.Name, req.Remote)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Delete tag error: %v\n%s", err, result)
+		return
+	}
+
+	fmt.Fprintf(w, "✅ Tag %s deleted!\n%s", req.Name, result)
+}
+
+func gitAssumeUnchangedHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Assume unchanged request received: %s", r.Method)
+
+	if r.Method != "GET" && r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Method == "POST" {
+		gitSetAssumeUnchangedHandler(w, r)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	files, err := sshManager.ListAssumedUnchanged(r.URL.Query().Get("repo_path"))
+	if err != nil {
+		writeJSON(w, statusForError(err, http.StatusInternalServerError), map[string]interface{}{
+			"error": "Assume unchanged error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"files": files,
+		"error": nil,
+	})
+}
+
+func gitSetAssumeUnchangedHandler(w http.ResponseWriter, r *http.Request) {
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
+
+	var req struct {
+		RepoPath string `json:"repo_path"`
+		FilePath string `json:"file_path"`
+		Assume   bool   `json:"assume"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
+
+	if err := sshManager.AssumeUnchanged(req.RepoPath, req.FilePath, req.Assume); err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Assume unchanged error: %v", err)
+		return
+	}
+
+	if req.Assume {
+		fmt.Fprintf(w, "✅ %s is now assumed unchanged, local edits are hidden from status!", req.FilePath)
+	} else {
+		fmt.Fprintf(w, "✅ %s is tracked for changes again!", req.FilePath)
+	}
+}
+
+func gitFixupHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Fixup request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
 
-        function displayProjects(projects) {
-            var projectsList = document.getElementById('projectsList');
-            if (!projectsList) return;
-            
-            if (projects.length === 0) {
-                projectsList.innerHTML = '<div class="loading-text">📁 No Git repositories found</div>';
-                return;
-            }
-            
-            projectsList.innerHTML = '';
-            
-            for (var i = 0; i < projects.length; i++) {
-                var project = projects[i];
-                var item = document.createElement('div');
-                item.className = 'project-item';
-                
-                var info = document.createElement('div');
-                info.className = 'project-info';
-                
-                var name = document.createElement('div');
-                name.className = 'project-name';
-                name.textContent = '📁 ' + project.name;
-                
-                var path = document.createElement('div');
-                path.className = 'project-path';
-                path.textContent = project.path;
-                
-                info.appendChild(name);
-                info.appendChild(path);
-                
-                var actions = document.createElement('div');
-                actions.className = 'project-actions';
-                
-                var pullBtn = document.createElement('button');
-                pullBtn.className = 'btn btn-warning btn-sm';
-                pullBtn.textContent = '⬇️ Pull';
-                pullBtn.onclick = (function(projectPath) {
-                    return function() { gitPull(projectPath); };
-                })(project.path);
-                
-                var pushBtn = document.createElement('button');
-                pushBtn.className = 'btn btn-success btn-sm';
-                pushBtn.textContent = '⬆️ Push';
-                pushBtn.onclick = (function(projectPath) {
-                    return function() { openCommitModal(projectPath); };
-                })(project.path);
+	var req struct {
+		RepoPath   string `json:"repo_path"`
+		TargetHash string `json:"target_hash"`
+	}
 
-                var statusBtn = document.createElement('button');
-                statusBtn.className = 'btn btn-secondary btn-sm';
-                statusBtn.textContent = '📊 Status';
-                statusBtn.onclick = (function(projectPath) {
-                    return function() { gitStatus(projectPath); };
-                })(project.path);
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
 
-                var removeBtn = document.createElement('button');
-                removeBtn.className = 'btn btn-danger btn-sm';
-                removeBtn.textContent = '🗑️ Remove';
-                removeBtn.onclick = (function(projectPath, projectName) {
-                    return function() { 
-                        if (confirm('Are you sure you want to delete this project?\\n\\n' + projectName + '\\n' + projectPath)) {
-                            removeProject(projectPath);
-                        }
-                    };
-                })(project.path, project.name);
-                
-                actions.appendChild(pullBtn);
-                actions.appendChild(pushBtn);
-                actions.appendChild(statusBtn);
-                actions.appendChild(removeBtn);
-                
-                item.appendChild(info);
-                item.appendChild(actions);
-                projectsList.appendChild(item);
-            }
-        }
+	result, err := sshManager.FixupCommit(req.RepoPath, req.TargetHash)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Fixup error: %v\n%s", err, result)
+		return
+	}
 
-        function gitClone() {
-            var repoUrlInput = document.getElementById('repoUrl');
-            var branchInput = document.getElementById('branch');
-            
-            if (!repoUrlInput) {
-                showOutput('Repository URL input not found!', true);
-                return;
-            }
-            
-            var repoUrl = repoUrlInput.value.trim();
-            var branch = branchInput ? branchInput.value.trim() : '';
-            
-            if (!repoUrl) {
-                showOutput('Please enter Repository URL!', true);
-                return;
-            }
+	fmt.Fprintf(w, "✅ Fixup commit for %s created!\n%s", req.TargetHash[:7], result)
+}
 
-            showOutput('🔄 Cloning...');
-            
-            fetch('/git/clone', {
-                method: 'POST',
-                headers: {'Content-Type': 'application/json'},
-                body: JSON.stringify({repo_url: repoUrl, branch: branch})
-            })
-            .then(function(response) { return response.text(); })
-            .then(function(result) {
-                showOutput(result);
-                // Clear inputs on successful clone
-                repoUrlInput.value = '';
-                if (branchInput) branchInput.value = '';
-                // Refresh projects
-                refreshProjects();
-            })
-            .catch(function(error) { 
-                showOutput('❌ Clone error: ' + error.message, true); 
-            });
-        }
+func gitAutosquashRebaseHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Autosquash rebase request received")
 
-        function gitPull(projectPath) {
-            showOutput('🔄 Pulling: ' + projectPath);
-            
-            fetch('/git/pull', {
-                method: 'POST',
-                headers: {'Content-Type': 'application/json'},
-                body: JSON.stringify({repo_path: projectPath})
-            })
-            .then(function(response) { return response.text(); })
-            .then(function(result) {
-                showOutput(result);
-            })
-            .catch(function(error) { 
-                showOutput('❌ Pull error: ' + error.message, true); 
-            });
-        }
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-        function openCommitModal(projectPath) {
-            currentPushPath = projectPath;
-            var modal = document.getElementById('commitModal');
-            var messageInput = document.getElementById('modalCommitMessage');
-            
-            if (modal && messageInput) {
-                messageInput.value = 'Update files';
-                modal.style.display = 'block';
-                messageInput.focus();
-                messageInput.select();
-            }
-        }
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
+	}
 
-        function closeCommitModal() {
-            var modal = document.getElementById('commitModal');
-            if (modal) {
-                modal.style.display = 'none';
-            }
-            currentPushPath = '';
-        }
+	var req struct {
+		RepoPath string `json:"repo_path"`
+		Base     string `json:"base"`
+	}
 
-        function confirmPush() {
-            var messageInput = document.getElementById('modalCommitMessage');
-            var message = messageInput ? messageInput.value.trim() : 'Update files';
-            
-            closeCommitModal();
-            
-            if (!currentPushPath) {
-                showOutput('❌ Push path unknown!', true);
-                return;
-            }
-            
-            showOutput('🔄 Pushing: ' + currentPushPath);
-            
-            fetch('/git/push', {
-                method: 'POST',
-                headers: {'Content-Type': 'application/json'},
-                body: JSON.stringify({repo_path: currentPushPath, message: message})
-            })
-            .then(function(response) { return response.text(); })
-            .then(function(result) {
-                showOutput(result);
-            })
-            .catch(function(error) { 
-                showOutput('❌ Push error: ' + error.message, true); 
-            });
-        }
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ JSON decode error: %v", err)
+		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+		return
+	}
 
-        function gitStatus(projectPath) {
-            showOutput('🔄 Checking status: ' + projectPath);
-            
-            fetch('/git/status', {
-                method: 'POST',
-                headers: {'Content-Type': 'application/json'},
-                body: JSON.stringify({repo_path: projectPath})
-            })
-            .then(function(response) { return response.text(); })
-            .then(function(result) { 
-                showOutput(result); 
-            })
-            .catch(function(error) { 
-                showOutput('❌ Status error: ' + error.message, true); 
-            });
-        }
+	result, err := sshManager.AutosquashRebase(req.RepoPath, req.Base)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Autosquash rebase error (rebase aborted): %v\n%s", err, result)
+		return
+	}
 
-        function removeProject(projectPath) {
-            showOutput('🔄 Removing project: ' + projectPath);
-            
-            fetch('/git/remove', {
-                method: 'POST',
-                headers: {'Content-Type': 'application/json'},
-                body: JSON.stringify({repo_path: projectPath})
-            })
-            .then(function(response) { return response.text(); })
-            .then(function(result) {
-                showOutput(result);
-                refreshProjects();
-            })
-            .catch(function(error) { 
-                showOutput('❌ Remove error: ' + error.message, true); 
-            });
-        }
+	fmt.Fprintf(w, "✅ Fixup commits squashed!\n%s", result)
+}
 
-        // Close modal with ESC key
-        document.addEventListener('keydown', function(event) {
-            if (event.key === 'Escape') {
-                closeCommitModal();
-            }
-        });
+func gitCommitCountHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Commit count request received")
 
-        // Close modal by clicking background
-        var commitModal = document.getElementById('commitModal');
-        if (commitModal) {
-            commitModal.addEventListener('click', function(event) {
-                if (event.target === this) {
-                    closeCommitModal();
-                }
-            });
-        }
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	count, err := sshManager.CountCommits(query.Get("repo_path"), query.Get("range"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Commit count error: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"count": count,
+		"error": nil,
+	})
+}
+
+// filesUploadHandler streams a multipart upload to the server. The remote_path field must come before the file field,
+// so the parts can be read in order without spooling the file to memory or disk.
+func filesUploadHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 File upload request received")
+
+	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-        // Commit with Enter key
-        document.addEventListener('keydown', function(event) {
-            if (event.key === 'Enter' && document.getElementById('commitModal').style.display === 'block') {
-                confirmPush();
-            }
-        });
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
 
-        // Load projects on page load
-        window.onload = function() {
-            refreshProjects();
-        };
-    </script>
-</body>
-</html>`
+	limit := config.MaxUploadBytes
+	if limit <= 0 {
+		limit = defaultMaxUploadBytes
+	}
+	if r.ContentLength > limit+1<<20 {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]interface{}{
+			"error": fmt.Sprintf("Upload is larger than the %d byte limit", limit),
+		})
+		return
+	}
+	// Leave some room for the multipart headers and the remote_path field
+	r.Body = http.MaxBytesReader(w, r.Body, limit+1<<20)
 
-	t := template.Must(template.New("index").Parse(tmpl))
-	data := struct {
-		Host        string
-		User        string
-		AuthMethod  string
-		WorkingDir  string
-		GitHubToken string
-	}{
-		Host:        config.SSHHost,
-		User:        config.SSHUser,
-		AuthMethod:  config.AuthMethod,
-		WorkingDir:  config.WorkingDir,
-		GitHubToken: config.GitHubToken,
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Multipart form expected: " + err.Error(),
+		})
+		return
 	}
 
-	t.Execute(w, data)
-}
+	remotePath := ""
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			status := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			writeJSON(w, status, map[string]interface{}{
+				"error": "Upload read error: " + err.Error(),
+			})
+			return
+		}
 
-func setupHandler(w http.ResponseWriter, r *http.Request) {
-	tmpl := `<!DOCTYPE html>
-<html>
-<head>
-    <title>SSH GitHub Manager - Setup</title>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; margin: 20px; background: #f5f5f5; }
-        .container { max-width: 800px; margin: 0 auto; background: white; padding: 30px; border-radius: 10px; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
-        .header { text-align: center; margin-bottom: 30px; color: #333; }
-        .form-group { margin: 15px 0; }
-        .form-group label { display: block; margin-bottom: 5px; font-weight: bold; }
-        .form-group input, .form-group select { width: 100%; padding: 10px; border: 1px solid #ddd; border-radius: 4px; font-size: 14px; box-sizing: border-box; }
-        .form-group input:focus, .form-group select:focus { outline: none; border-color: #007bff; }
-        .btn { padding: 12px 24px; background: #007bff; color: white; border: none; border-radius: 5px; cursor: pointer; margin: 5px; }
-        .btn:hover { background: #0056b3; }
-        .btn-success { background: #28a745; }
-        .btn-success:hover { background: #1e7e34; }
-        .btn-secondary { background: #6c757d; }
-        .btn-secondary:hover { background: #5a6268; }
-        .auth-section { display: none; padding: 15px; background: #f8f9fa; border-radius: 5px; margin: 10px 0; }
-        .auth-section.active { display: block; }
-        .status { padding: 15px; border-radius: 5px; margin: 15px 0; }
-        .status.success { background: #d4edda; color: #155724; border: 1px solid #c3e6cb; }
-        .status.error { background: #f8d7da; color: #721c24; border: 1px solid #f5c6cb; }
-        .status.info { background: #d1ecf1; color: #0c5460; border: 1px solid #bee5eb; }
-        .help-text { font-size: 12px; color: #666; margin-top: 5px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>🔧 SSH GitHub Manager Setup</h1>
-            <p>Configure your server connection settings</p>
-        </div>
+		switch part.FormName() {
+		case "remote_path":
+			value, err := io.ReadAll(io.LimitReader(part, 4096))
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+					"error": "Upload read error: " + err.Error(),
+				})
+				return
+			}
+			remotePath = strings.TrimSpace(string(value))
+		case "file":
+			if remotePath == "" {
+				writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+					"error": "remote_path is required and must be sent before the file",
+				})
+				return
+			}
 
-        <form id="configForm">
-            <div class="form-group">
-                <label>🌐 Server Host/IP:</label>
-                <input type="text" id="sshHost" name="ssh_host" value="{{.SSHHost}}" placeholder="192.168.1.100 or example.com" required>
-                <div class="help-text">Your server IP address or domain name</div>
-            </div>
+			written, err := sshManager.UploadStream(part, remotePath, limit)
+			if err != nil {
+				status := http.StatusInternalServerError
+				var maxBytesErr *http.MaxBytesError
+				switch {
+				case errors.Is(err, errUploadTooLarge) || errors.As(err, &maxBytesErr):
+					status = http.StatusRequestEntityTooLarge
+				case errors.Is(err, errOutsideWorkingDir):
+					status = http.StatusForbidden
+				}
+				writeJSON(w, status, map[string]interface{}{
+					"error": "Upload error: " + err.Error(),
+				})
+				return
+			}
 
-            <div class="form-group">
-                <label>🔌 SSH Port:</label>
-                <input type="number" id="sshPort" name="ssh_port" value="{{.SSHPort}}" placeholder="22" required>
-                <div class="help-text">SSH port (usually 22)</div>
-            </div>
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"bytes_written": written,
+				"remote_path":   remotePath,
+				"error":         nil,
+			})
+			return
+		}
+	}
 
-            <div class="form-group">
-                <label>👤 SSH User:</label>
-                <input type="text" id="sshUser" name="ssh_user" value="{{.SSHUser}}" placeholder="root or username" required>
-                <div class="help-text">Server username</div>
-            </div>
+	writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+		"error": "file is required",
+	})
+}
 
-            <div class="form-group">
-                <label>🔐 Authentication Method:</label>
-                <select id="authMethod" name="auth_method" onchange="toggleAuthMethod()" required>
-                    <option value="password"{{if eq .AuthMethod "password"}} selected{{end}}>Password</option>
-                    <option value="key"{{if eq .AuthMethod "key"}} selected{{end}}>SSH Key</option>
-                </select>
-            </div>
+func filesDownloadHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 File download request received")
 
-            <div id="passwordAuth" class="auth-section">
-                <div class="form-group">
-                    <label>🔑 SSH Password:</label>
-                    <input type="password" id="sshPassword" name="ssh_password" value="{{.SSHPassword}}" placeholder="Your server password">
-                    <div class="help-text">Password for SSH connection</div>
-                </div>
-            </div>
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-            <div id="keyAuth" class="auth-section">
-                <div class="form-group">
-                    <label>🗝️ SSH Key Path:</label>
-                    <input type="text" id="sshKeyPath" name="ssh_key_path" value="{{.SSHKeyPath}}" placeholder="/home/username/.ssh/id_rsa">
-                    <div class="help-text">Full path to SSH private key file</div>
-                </div>
-            </div>
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		http.Error(w, "SSH connection not established: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
 
-            <div class="form-group">
-                <label>📁 Working Directory:</label>
-                <input type="text" id="workingDir" name="working_dir" value="{{.WorkingDir}}" placeholder="/root/projects" required>
-                <div class="help-text">Directory on server where Git repositories will be stored</div>
-            </div>
+	remotePath := r.URL.Query().Get("path")
+	if remotePath == "" {
+		http.Error(w, "path parameter is required", http.StatusBadRequest)
+		return
+	}
 
-            <div class="form-group">
-                <label>🐙 GitHub Token (Required!):</label>
-                <input type="password" id="githubToken" name="github_token" value="{{.GitHubToken}}" placeholder="ghp_xxxxxxxxxxxx" required>
-                <div class="help-text">GitHub Personal Access Token is required for repositories. <a href="https://github.com/settings/tokens" target="_blank">Create one here</a></div>
-            </div>
+	file, info, err := sshManager.DownloadFile(remotePath)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, errOutsideWorkingDir):
+			status = http.StatusForbidden
+		case errors.Is(err, os.ErrNotExist):
+			status = http.StatusNotFound
+		}
+		log.Printf("❌ Download failed: %v", err)
+		http.Error(w, "Download error: "+err.Error(), status)
+		return
+	}
+	defer file.Close()
 
-            <div style="text-align: center; margin-top: 30px;">
-                <button type="button" class="btn btn-secondary" onclick="testConnection()">🔍 Test Connection</button>
-                <button type="submit" class="btn btn-success">💾 Save Settings</button>
-            </div>
-        </form>
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": info.Name()}))
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
 
-        <div id="status"></div>
+	written, err := io.Copy(w, file)
+	if err != nil {
+		log.Printf("❌ Download interrupted after %d bytes: %v", written, err)
+		return
+	}
+	log.Printf("✅ Download successful: %d bytes", written)
+}
 
-        {{if .IsConfigured}}
-        <div style="text-align: center; margin-top: 20px;">
-            <button class="btn" onclick="window.location.href='/'">🏠 Back to Home</button>
-        </div>
-        {{end}}
-    </div>
+func filesListHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 File list request received")
 
-    <script>
-        function toggleAuthMethod() {
-            var authMethod = document.getElementById('authMethod').value;
-            var passwordAuth = document.getElementById('passwordAuth');
-            var keyAuth = document.getElementById('keyAuth');
-            
-            if (authMethod === 'password') {
-                passwordAuth.classList.add('active');
-                keyAuth.classList.remove('active');
-            } else {
-                passwordAuth.classList.remove('active');
-                keyAuth.classList.add('active');
-            }
-        }
+	if r.Method != "GET" {
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-        function showStatus(message, type) {
-            var status = document.getElementById('status');
-            status.innerHTML = '<div class="status ' + type + '">' + message + '</div>';
-        }
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
+		})
+		return
+	}
 
-        function testConnection() {
-            var formData = new FormData(document.getElementById('configForm'));
-            var config = {};
-            for (var pair of formData.entries()) {
-                config[pair[0]] = pair[1];
-            }
-            
-            showStatus('🔄 Testing connection...', 'info');
-            
-            fetch('/test-connection', {
-                method: 'POST',
-                headers: {'Content-Type': 'application/json'},
-                body: JSON.stringify(config)
-            })
-            .then(function(response) { return response.json(); })
-            .then(function(result) {
-                if (result.success) {
-                    showStatus('✅ Connection successful! Server: ' + result.message, 'success');
-                } else {
-                    showStatus('❌ Connection error: ' + result.error, 'error');
-                }
-            })
-            .catch(function(error) {
-                showStatus('❌ Test error: ' + error.message, 'error');
-            });
-        }
+	// An empty path lists the working directory itself
+	dir, files, err := sshManager.ListWorkingDirFiles(r.URL.Query().Get("path"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errOutsideWorkingDir) {
+			status = http.StatusForbidden
+		}
+		writeJSON(w, status, map[string]interface{}{
+			"error": "List error: " + err.Error(),
+		})
+		return
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].IsDir != files[j].IsDir {
+			return files[i].IsDir
+		}
+		return files[i].Name < files[j].Name
+	})
 
-        document.getElementById('configForm').addEventListener('submit', function(e) {
-            e.preventDefault();
-            
-            var formData = new FormData(this);
-            var config = {};
-            for (var pair of formData.entries()) {
-                config[pair[0]] = pair[1];
-            }
-            
-            showStatus('💾 Saving settings...', 'info');
-            
-            fetch('/save-config', {
-                method: 'POST',
-                headers: {'Content-Type': 'application/json'},
-                body: JSON.stringify(config)
-            })
-            .then(function(response) { return response.json(); })
-            .then(function(result) {
-                if (result.success) {
-                    showStatus('✅ Settings saved successfully! Redirecting in 2 seconds...', 'success');
-                    setTimeout(function() {
-                        window.location.href = '/';
-                    }, 2000);
-                } else {
-                    showStatus('❌ Save error: ' + result.error, 'error');
-                }
-            })
-            .catch(function(error) {
-                showStatus('❌ Error: ' + error.message, 'error');
-            });
-        });
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"path":  dir,
+		"root":  path.Clean(sshManager.config.WorkingDir),
+		"files": files,
+		"error": nil,
+	})
+}
 
-        // Show auth method on page load
-        window.onload = function() {
-            toggleAuthMethod();
-        };
-    </script>
-</body>
-</html>`
+func profilesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Profiles request received: %s", r.Method)
 
-	t := template.Must(template.New("setup").Parse(tmpl))
-	t.Execute(w, config)
+	switch r.Method {
+	case "GET":
+		profiles := profileStore.All()
+		for i := range profiles {
+			profiles[i] = redactedProfile(profiles[i])
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"profiles": profiles,
+			"active":   config.ActiveProfile,
+			"error":    nil,
+		})
+	case "POST":
+		// Fields missing from the request are taken from the current settings, so {"name": "..."} saves them as is
+		profile := Profile{Config: *config}
+		profile.GitHubTokens = slices.Clone(config.GitHubTokens) // decoding would otherwise overwrite the live tokens
+		if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+			log.Printf("❌ JSON decode error: %v", err)
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": "JSON parse error: " + err.Error(),
+			})
+			return
+		}
+		profile.restoreRedactedSecrets(config)
+		id, err := newProfileID()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+				"error": "Profile ID generation failed: " + err.Error(),
+			})
+			return
+		}
+		profile.ID = id
+		saveProfile(w, http.StatusCreated, profile)
+	default:
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
 }
 
-func projectsHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+func profileHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Profile request received: %s", r.Method)
+
+	id := r.PathValue("id")
+	profile, ok := profileStore.Get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"error": "Profile not found: " + id,
+		})
+		return
+	}
 
-	// Check SSH connection
-	if sshManager.client == nil {
-		if err := sshManager.Connect(); err != nil {
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"error":    "SSH connection not established: " + err.Error(),
-				"projects": []Project{},
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"profile": redactedProfile(profile),
+			"error":   nil,
+		})
+	case "PUT":
+		stored := profile.Config
+		stored.GitHubTokens = slices.Clone(profile.GitHubTokens)
+		if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+			log.Printf("❌ JSON decode error: %v", err)
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": "JSON parse error: " + err.Error(),
 			})
 			return
 		}
+		profile.restoreRedactedSecrets(&stored)
+		profile.ID = id
+		saveProfile(w, http.StatusOK, profile)
+	case "DELETE":
+		if id == config.ActiveProfile {
+			writeJSON(w, http.StatusConflict, map[string]interface{}{
+				"error": "The active profile cannot be deleted, switch to another profile first",
+			})
+			return
+		}
+		if err := profileStore.Delete(id); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+				"error": "Profile not deleted: " + err.Error(),
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"deleted": id,
+			"error":   nil,
+		})
+	default:
+		log.Printf("❌ Wrong method: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
+}
 
-	projects, err := sshManager.ListProjects()
-	if err != nil {
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"error":    "Failed to get project list: " + err.Error(),
-			"projects": []Project{},
+// redactedProfile returns profile with its settings passed through redactedConfig.
+func redactedProfile(profile Profile) Profile {
+	profile.Config = redactedConfig(&profile.Config)
+	return profile
+}
+
+// saveProfile validates and stores a created or updated profile and writes the response.
+func saveProfile(w http.ResponseWriter, status int, profile Profile) {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": "Profile name is required",
 		})
 		return
 	}
+	if profile.SearchDepth < 0 || profile.SearchDepth > maxSearchDepth {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error": fmt.Sprintf("Repository search depth must be between 1 and %d", maxSearchDepth),
+		})
+		return
+	}
+	profile.ActiveProfile = ""
+	profile.IsConfigured = true
+	profile.clearInstanceSettings()
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"projects": projects,
-		"error":    nil,
+	if err := profileStore.Set(profile); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"error": "Profile not saved: " + err.Error(),
+		})
+		return
+	}
+	log.Printf("✅ Profile saved: %s (%s)", profile.Name, profile.ID)
+	writeJSON(w, status, map[string]interface{}{
+		"profile": redactedProfile(profile),
+		"error":   nil,
 	})
 }
 
-func gitCloneHandler(w http.ResponseWriter, r *http.Request) {
-	log.Printf("🌐 Clone request received")
+// profileActivateHandler makes a profile the current configuration and reconnects to its server. The switch is kept
+// even when the connection fails, so the settings can be fixed from the setup page.
+func profileActivateHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Profile activation request received")
 
 	if r.Method != "POST" {
 		log.Printf("❌ Wrong method: %s", r.Method)
@@ -1060,124 +15091,212 @@ func gitCloneHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check SSH connection
-	if sshManager.client == nil {
-		log.Printf("🔌 SSH reconnecting")
-		if err := sshManager.Connect(); err != nil {
-			log.Printf("❌ SSH connection error: %v", err)
-			fmt.Fprintf(w, "❌ SSH connection error: %v", err)
-			return
-		}
+	id := r.PathValue("id")
+	profile, ok := profileStore.Get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"error": "Profile not found: " + id,
+		})
+		return
 	}
 
-	var req struct {
-		RepoURL string `json:"repo_url"`
-		Branch  string `json:"branch"`
-	}
+	configMu.Lock()
+	newConfig := profile.Config
+	newConfig.ActiveProfile = profile.ID
+	// The listen address and credentials belong to this manager instance, not to the server being managed
+	newConfig.ListenAddr = config.ListenAddr
+	newConfig.WebUIUser = config.WebUIUser
+	newConfig.WebUIPassword = config.WebUIPassword
+	newConfig.APIKey = config.APIKey
+	newConfig.IsConfigured = true
+	config = &newConfig
+	githubTokens.SetTokens(config.GitHubTokens)
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("❌ JSON decode error: %v", err)
-		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
-		return
-	}
+	sshManager.Disconnect()
+	manager := NewSSHManager(config)
+	sshManager = manager
+	projectCache.Invalidate()
 
-	log.Printf("📥 Clone request: %s (branch: %s)", req.RepoURL, req.Branch)
-	result, err := sshManager.GitClone(req.RepoURL, req.Branch)
+	err := saveConfig(config)
+	// Connecting may take until the timeout, so it runs without holding up other configuration changes
+	configMu.Unlock()
 	if err != nil {
-		log.Printf("❌ Clone failed")
-		fmt.Fprintf(w, "❌ Clone error: %v\n%s", err, result)
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"error": "Configuration not saved: " + err.Error(),
+		})
 		return
 	}
+	log.Printf("🗂️ Switched to profile %s (%s)", profile.Name, profile.ID)
 
-	log.Printf("✅ Clone successful")
-	fmt.Fprintf(w, "✅ Clone completed successfully!\n%s", result)
-}
+	connectError := ""
+	if err := manager.Connect(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		connectError = err.Error()
+	}
 
-func gitPullHandler(w http.ResponseWriter, r *http.Request) {
-	log.Printf("🌐 Pull request received")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"profile":       profile.Name,
+		"connected":     connectError == "",
+		"connect_error": connectError,
+		"error":         nil,
+	})
+}
 
-	if r.Method != "POST" {
-		log.Printf("❌ Wrong method: %s", r.Method)
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+func operationsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Operations request received: %s", r.Method)
+
+	switch r.Method {
+	case "GET":
+		limit := 50
+		if value := r.URL.Query().Get("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > 1000 {
+				writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+					"error": "limit must be between 1 and 1000",
+				})
+				return
+			}
+			limit = parsed
+		}
+		offset := 0
+		if value := r.URL.Query().Get("offset"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+					"error": "offset must be a non-negative number",
+				})
+				return
+			}
+			offset = parsed
+		}
 
-	// Check SSH connection
-	if sshManager.client == nil {
-		log.Printf("🔌 SSH reconnecting")
-		if err := sshManager.Connect(); err != nil {
-			log.Printf("❌ SSH connection error: %v", err)
-			fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		records, total, err := operationLog.List(limit, offset)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+				"error": "Operation log read failed: " + err.Error(),
+			})
 			return
 		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"operations": records,
+			"total":      total,
+			"limit":      limit,
+			"offset":     offset,
+			"error":      nil,
+		})
+	case "DELETE":
+		if err := operationLog.Purge(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+				"error": "Operation log purge failed: " + err.Error(),
+			})
+			return
+		}
+		log.Printf("🗑️ Operation log purged")
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"error": nil,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
+}
 
-	var req struct {
-		RepoPath string `json:"repo_path"`
-	}
+// readyCheckTimeout bounds the command run by /ready, and readyCheckTTL is how long its result is reused
+// so that frequent probes do not each open an SSH session.
+const (
+	readyCheckTimeout = 2 * time.Second
+	readyCheckTTL     = 10 * time.Second
+)
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("❌ JSON decode error: %v", err)
-		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
-		return
+var readyCheck struct {
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+}
+
+// checkReady runs a no-op command on the server, or returns the result of the last run within readyCheckTTL.
+func checkReady() (time.Time, error) {
+	readyCheck.mu.Lock()
+	defer readyCheck.mu.Unlock()
+
+	if time.Since(readyCheck.checked) < readyCheckTTL {
+		return readyCheck.checked, readyCheck.err
 	}
 
-	log.Printf("⬇️ Pull request: %s", req.RepoPath)
-	result, err := sshManager.GitPull(req.RepoPath)
-	if err != nil {
-		log.Printf("❌ Pull failed")
-		fmt.Fprintf(w, "❌ Pull error: %v\n%s", err, result)
-		return
+	done := make(chan error, 1)
+	go func() {
+		_, err := sshManager.ExecuteCommand("true")
+		done <- err
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(readyCheckTimeout):
+		err = fmt.Errorf("SSH command timed out after %v", readyCheckTimeout)
 	}
 
-	log.Printf("✅ Pull successful")
-	fmt.Fprintf(w, "✅ Pull completed successfully!\n%s", result)
+	readyCheck.checked = time.Now()
+	readyCheck.err = err
+	return readyCheck.checked, err
 }
 
-func gitPushHandler(w http.ResponseWriter, r *http.Request) {
-	log.Printf("🌐 Push request received")
-
-	if r.Method != "POST" {
-		log.Printf("❌ Wrong method: %s", r.Method)
+// healthHandler serves GET /health, a liveness probe that only looks at the SSH client without using it:
+//
+//	200 {"status": "ok", "ssh_connected": true, "uptime_seconds": 42}
+//	503 {"status": "degraded", "ssh_connected": false, "uptime_seconds": 42}
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Check SSH connection
+	uptime := int64(time.Since(serverStarted).Seconds())
 	if sshManager.client == nil {
-		log.Printf("🔌 SSH reconnecting")
-		if err := sshManager.Connect(); err != nil {
-			log.Printf("❌ SSH connection error: %v", err)
-			fmt.Fprintf(w, "❌ SSH connection error: %v", err)
-			return
-		}
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status":         "degraded",
+			"ssh_connected":  false,
+			"uptime_seconds": uptime,
+		})
+		return
 	}
 
-	var req struct {
-		RepoPath string `json:"repo_path"`
-		Message  string `json:"message"`
-	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":         "ok",
+		"ssh_connected":  true,
+		"uptime_seconds": uptime,
+	})
+}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("❌ JSON decode error: %v", err)
-		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+// readyHandler serves GET /ready, a readiness probe that runs `true` on the server within readyCheckTimeout.
+// The result is cached for readyCheckTTL:
+//
+//	200 {"status": "ready", "checked_at": "..."}
+//	503 {"status": "not ready", "checked_at": "...", "error": "..."}
+func readyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	log.Printf("⬆️ Push request: %s (message: %s)", req.RepoPath, req.Message)
-	result, err := sshManager.GitPush(req.RepoPath, req.Message)
+	checked, err := checkReady()
 	if err != nil {
-		log.Printf("❌ Push failed")
-		fmt.Fprintf(w, "❌ Push error: %v\n%s", err, result)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status":     "not ready",
+			"checked_at": checked,
+			"error":      err.Error(),
+		})
 		return
 	}
 
-	log.Printf("✅ Push successful")
-	fmt.Fprintf(w, "✅ Push completed successfully!\n%s", result)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":     "ready",
+		"checked_at": checked,
+	})
 }
 
-func gitStatusHandler(w http.ResponseWriter, r *http.Request) {
-	log.Printf("🌐 Status request received")
+// authRotateKeyHandler replaces the API key. Like the other API routes it needs the current key or the web UI login.
+func authRotateKeyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 API key rotation request received")
 
 	if r.Method != "POST" {
 		log.Printf("❌ Wrong method: %s", r.Method)
@@ -1185,40 +15304,37 @@ func gitStatusHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check SSH connection
-	if sshManager.client == nil {
-		log.Printf("🔌 SSH reconnecting")
-		if err := sshManager.Connect(); err != nil {
-			log.Printf("❌ SSH connection error: %v", err)
-			fmt.Fprintf(w, "❌ SSH connection error: %v", err)
-			return
-		}
-	}
-
-	var req struct {
-		RepoPath string `json:"repo_path"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("❌ JSON decode error: %v", err)
-		fmt.Fprintf(w, "❌ JSON parse error: %v", err)
+	apiKey, err := newAPIKey()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"error": "API key generation failed: " + err.Error(),
+		})
 		return
 	}
 
-	log.Printf("📊 Status request: %s", req.RepoPath)
-	result, err := sshManager.GitStatus(req.RepoPath)
-	if err != nil {
-		log.Printf("❌ Status failed")
-		fmt.Fprintf(w, "❌ Status error: %v\n%s", err, result)
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	newConfig := *config
+	newConfig.APIKey = apiKey
+	if err := saveConfig(&newConfig); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"error": "Configuration not saved: " + err.Error(),
+		})
 		return
 	}
+	// sshManager keeps its settings, which do not include the API key, so requests using it are not disturbed
+	config = &newConfig
 
-	log.Printf("✅ Status successful")
-	fmt.Fprintf(w, "📊 Repository Status:\n%s", result)
+	log.Printf("🔑 API key rotated")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"api_key": apiKey,
+		"error":   nil,
+	})
 }
 
-func gitRemoveHandler(w http.ResponseWriter, r *http.Request) {
-	log.Printf("🌐 Remove request received")
+func gitResetHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Reset request received")
 
 	if r.Method != "POST" {
 		log.Printf("❌ Wrong method: %s", r.Method)
@@ -1226,18 +15342,17 @@ func gitRemoveHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check SSH connection
-	if sshManager.client == nil {
-		log.Printf("🔌 SSH reconnecting")
-		if err := sshManager.Connect(); err != nil {
-			log.Printf("❌ SSH connection error: %v", err)
-			fmt.Fprintf(w, "❌ SSH connection error: %v", err)
-			return
-		}
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		fmt.Fprintf(w, "❌ SSH connection error: %v", err)
+		return
 	}
 
 	var req struct {
 		RepoPath string `json:"repo_path"`
+		Ref      string `json:"ref"`
+		Mode     string `json:"mode"`
+		Confirm  bool   `json:"confirm"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -1246,108 +15361,117 @@ func gitRemoveHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("🗑️ Remove request: %s", req.RepoPath)
-	result, err := sshManager.RemoveProject(req.RepoPath)
-	if err != nil {
-		log.Printf("❌ Remove failed")
-		fmt.Fprintf(w, "❌ Remove error: %v\n%s", err, result)
+	if !resetModes[req.Mode] {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "❌ Reset error: mode must be soft, mixed or hard")
 		return
 	}
-
-	log.Printf("✅ Remove successful")
-	fmt.Fprintf(w, "✅ Project deleted successfully!\n%s", result)
-}
-
-func saveConfigHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	// A hard reset throws away uncommitted work, so it has to be asked for explicitly
+	if req.Mode == "hard" && !req.Confirm {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		fmt.Fprintf(w, "❌ Reset error: a hard reset discards uncommitted changes, send \"confirm\": true to proceed")
 		return
 	}
 
-	var newConfig Config
-	if err := json.NewDecoder(r.Body).Decode(&newConfig); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   "JSON parse error: " + err.Error(),
-		})
+	result, err := sshManager.GitReset(req.RepoPath, req.Ref, req.Mode)
+	if err != nil {
+		writeInvalidPathStatus(w, err)
+		fmt.Fprintf(w, "❌ Reset error: %v\n%s", err, result)
 		return
 	}
 
-	// Update configuration
-	newConfig.IsConfigured = true
-	config = &newConfig
-
-	// Recreate SSH manager
-	sshManager.Disconnect()
-	sshManager = NewSSHManager(config)
-
-	// Save to file
-	if err := saveConfig(config); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   "Configuration not saved: " + err.Error(),
-		})
-		return
+	ref := req.Ref
+	if ref == "" {
+		ref = "HEAD"
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Configuration saved successfully",
-	})
+	fmt.Fprintf(w, "✅ Reset (%s) to %s completed successfully!\n%s", req.Mode, ref, result)
 }
 
-func testConnectionHandler(w http.ResponseWriter, r *http.Request) {
+// gitPullAllHandler pulls every discovered project and streams one JSON object per finished pull
+// ({"path", "name", "output", "error", "completed", "total"}), then {"finished": true, "total", "failed"}.
+func gitPullAllHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("🌐 Pull all request received")
+
 	if r.Method != "POST" {
+		log.Printf("❌ Wrong method: %s", r.Method)
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var testConfig Config
-	if err := json.NewDecoder(r.Body).Decode(&testConfig); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   "JSON parse error: " + err.Error(),
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"error": "Streaming is not supported",
 		})
 		return
 	}
 
-	// Create temporary SSH manager for testing
-	testManager := NewSSHManager(&testConfig)
+	concurrency := defaultPullConcurrency
+	if value := r.URL.Query().Get("concurrency"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxPullConcurrency {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": fmt.Sprintf("Invalid concurrency %q (allowed: 1-%d)", value, maxPullConcurrency),
+			})
+			return
+		}
+		concurrency = parsed
+	}
 
-	if err := testManager.Connect(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
+	if err := ensureSSHConnection(); err != nil {
+		log.Printf("❌ SSH connection error: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error": "SSH connection not established: " + err.Error(),
 		})
 		return
 	}
 
-	// Run test command
-	output, err := testManager.ExecuteCommand("hostname && pwd")
-	testManager.Disconnect()
-
+	projects, err := sshManager.ListProjects()
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   "Command execution error: " + err.Error(),
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"error": "Project listing error: " + err.Error(),
 		})
 		return
 	}
+	paths := make([]string, 0, len(projects))
+	for _, project := range projects {
+		paths = append(paths, project.Path)
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": strings.TrimSpace(output),
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var mu sync.Mutex
+	encoder := json.NewEncoder(w)
+	completed, failed := 0, 0
+	sshManager.bulkPull(paths, concurrency, func(repoPath string, result BulkResult) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		completed++
+		var errorText interface{}
+		if result.Error != nil {
+			failed++
+			errorText = result.Error.Error()
+		}
+		encoder.Encode(map[string]interface{}{
+			"path":      repoPath,
+			"name":      path.Base(repoPath),
+			"output":    result.Output,
+			"error":     errorText,
+			"completed": completed,
+			"total":     len(paths),
+		})
+		flusher.Flush()
 	})
-}
 
-func configHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(config)
+	encoder.Encode(map[string]interface{}{
+		"finished": true,
+		"total":    len(paths),
+		"failed":   failed,
+	})
+	flusher.Flush()
+	log.Printf("✅ Pull all streamed %d results (%d failed)", len(paths), failed)
 }
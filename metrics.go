@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	sshCommandsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ssh_commands_total",
+		Help: "Commands run on the server over SSH, by result.",
+	}, []string{"status"})
+
+	sshCommandDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "ssh_command_duration_seconds",
+		Help:    "Time taken by commands run on the server over SSH.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	gitOperationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "git_operations_total",
+		Help: "Clone, pull, push, status and remove operations started, by operation.",
+	}, []string{"operation"})
+
+	sshReconnectionsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ssh_reconnections_total",
+		Help: "Successful SSH reconnections after the connection was lost.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(sshCommandsTotal, sshCommandDuration, gitOperationsTotal, sshReconnectionsTotal)
+
+	// Export every series from the start, so rates work before the first error or push
+	for _, status := range []string{"success", "error"} {
+		sshCommandsTotal.WithLabelValues(status)
+	}
+	for _, operation := range []string{"clone", "pull", "push", "status", "remove"} {
+		gitOperationsTotal.WithLabelValues(operation)
+	}
+}
+
+// observeSSHCommand records a finished SSH command.
+func observeSSHCommand(duration time.Duration, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	sshCommandsTotal.WithLabelValues(status).Inc()
+	sshCommandDuration.Observe(duration.Seconds())
+}
+
+// registerMetrics serves the metrics at /metrics on mux. Scrapes that must send a token are not asked for the web UI
+// login as well, since a scraper can send only one Authorization header.
+func registerMetrics(mux *http.ServeMux, token string) {
+	mux.Handle("/metrics", metricsHandler(token))
+	if token != "" {
+		basicAuthPublicPaths["/metrics"] = true
+	}
+}
+
+// metricsHandler serves the metrics for Prometheus. With a token set, scrapes must send "Authorization: Bearer <token>".
+func metricsHandler(token string) http.Handler {
+	handler := promhttp.Handler()
+	if token == "" {
+		return handler
+	}
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newTestRegistry registers the manager's collectors in a registry of their own, so tests read them without the
+// Go runtime metrics of the default registry.
+func newTestRegistry(t *testing.T) *prometheus.Registry {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(sshCommandsTotal, sshCommandDuration, gitOperationsTotal, sshReconnectionsTotal)
+	return registry
+}
+
+// gatheredValue returns the counter, or the histogram's sample count, of the series name{label="value"}.
+func gatheredValue(t *testing.T, registry *prometheus.Registry, name, label, value string) float64 {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			matches := label == ""
+			for _, pair := range metric.GetLabel() {
+				if pair.GetName() == label && pair.GetValue() == value {
+					matches = true
+				}
+			}
+			if !matches {
+				continue
+			}
+			if histogram := metric.GetHistogram(); histogram != nil {
+				return float64(histogram.GetSampleCount())
+			}
+			return metric.GetCounter().GetValue()
+		}
+	}
+	t.Fatalf("series %s{%s=%q} not exported", name, label, value)
+	return 0
+}
+
+func TestObserveSSHCommand(t *testing.T) {
+	registry := newTestRegistry(t)
+	successes := gatheredValue(t, registry, "ssh_commands_total", "status", "success")
+	failures := gatheredValue(t, registry, "ssh_commands_total", "status", "error")
+	observed := gatheredValue(t, registry, "ssh_command_duration_seconds", "", "")
+
+	observeSSHCommand(20*time.Millisecond, nil)
+	observeSSHCommand(time.Second, nil)
+	observeSSHCommand(time.Millisecond, errors.New("exit status 1"))
+
+	if got := gatheredValue(t, registry, "ssh_commands_total", "status", "success") - successes; got != 2 {
+		t.Errorf("successes grew by %v, want 2", got)
+	}
+	if got := gatheredValue(t, registry, "ssh_commands_total", "status", "error") - failures; got != 1 {
+		t.Errorf("errors grew by %v, want 1", got)
+	}
+	if got := gatheredValue(t, registry, "ssh_command_duration_seconds", "", "") - observed; got != 3 {
+		t.Errorf("durations grew by %v, want 3", got)
+	}
+}
+
+func TestGitOperationsTotal(t *testing.T) {
+	registry := newTestRegistry(t)
+	withFakeServer(t,
+		fakeReply{match: "git status", output: "## main"},
+		fakeReply{match: "git pull", output: "Already up to date."},
+	)
+	pulls := gatheredValue(t, registry, "git_operations_total", "operation", "pull")
+	statuses := gatheredValue(t, registry, "git_operations_total", "operation", "status")
+	pushes := gatheredValue(t, registry, "git_operations_total", "operation", "push")
+
+	sshManager.GitStatus(testWorkingDir + "/app")
+	sshManager.GitPull(testWorkingDir + "/app")
+	sshManager.GitPull(testWorkingDir + "/app")
+
+	if got := gatheredValue(t, registry, "git_operations_total", "operation", "pull") - pulls; got != 2 {
+		t.Errorf("pulls grew by %v, want 2", got)
+	}
+	if got := gatheredValue(t, registry, "git_operations_total", "operation", "status") - statuses; got != 1 {
+		t.Errorf("statuses grew by %v, want 1", got)
+	}
+	if got := gatheredValue(t, registry, "git_operations_total", "operation", "push") - pushes; got != 0 {
+		t.Errorf("pushes grew by %v, want 0", got)
+	}
+}
+
+func TestMetricsSkipBasicAuthWithToken(t *testing.T) {
+	withConfig(t, &Config{WebUIUser: "admin", WebUIPassword: "$2a$10$invalidinvalidinvalidinvalidinvalidinvalidinvalidinva"})
+	t.Cleanup(func() { delete(basicAuthPublicPaths, "/metrics") })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/operations", func(w http.ResponseWriter, r *http.Request) {})
+	registerMetrics(mux, "scrape-token")
+	handler := basicAuthMiddleware(mux)
+
+	for _, tt := range []struct {
+		path, authorization string
+		want                int
+	}{
+		{"/metrics", "Bearer scrape-token", http.StatusOK},
+		{"/metrics", "Bearer wrong", http.StatusUnauthorized},
+		{"/metrics", "", http.StatusUnauthorized},
+		{"/operations", "Bearer scrape-token", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.authorization != "" {
+			req.Header.Set("Authorization", tt.authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("GET %s with %q = %d, want %d", tt.path, tt.authorization, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestMetricsKeepBasicAuthWithoutToken(t *testing.T) {
+	withConfig(t, &Config{WebUIUser: "admin", WebUIPassword: "$2a$10$invalidinvalidinvalidinvalidinvalidinvalidinvalidinva"})
+
+	mux := http.NewServeMux()
+	registerMetrics(mux, "")
+
+	rec := httptest.NewRecorder()
+	basicAuthMiddleware(mux).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /metrics = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}